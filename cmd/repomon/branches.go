@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/plars/repomon/internal/git"
+	"github.com/plars/repomon/internal/report"
+	"github.com/plars/repomon/internal/timespec"
+	"github.com/spf13/cobra"
+)
+
+// branchesOptions holds the flags specific to the 'branches' command.
+type branchesOptions struct {
+	olderThan string
+	format    string
+	jobs      int
+	noCache   bool
+	offline   bool
+	debug     bool
+}
+
+// BranchFormatter is implemented by the report formatters that can format the stale
+// branch report.
+type BranchFormatter interface {
+	FormatBranches(results []git.RepoResult) (string, error)
+}
+
+func (r *repomonRunner) branchesCmd(rootOpts *rootOptions) *cobra.Command {
+	branchesOpts := &branchesOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "branches",
+		Short: "Lists stale branches of each repository",
+		Long: `Lists the branches of each repository whose last commit is older than --older-than,
+with the author of that commit and whether the branch is merged into the default
+branch. Remote repositories list their branches; local repositories list their
+remote-tracking branches, as last fetched. With the clone cache, remote repositories
+keep a commit-only clone of every branch beside the clone used for reports.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := r.executeBranches(cmd.Context(), branchesOpts, rootOpts); err != nil {
+				slog.Error("Branches command failed", "error", err)
+				os.Exit(exitCode(err))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&branchesOpts.olderThan, "older-than", "90d", "list branches with no commit for this long (e.g. 30d, 12w)")
+	cmd.Flags().StringVar(&branchesOpts.format, "format", report.FormatText, "output format: 'text', 'json' or 'jsonl' (one JSON object per repository)")
+	cmd.Flags().IntVarP(&branchesOpts.jobs, "jobs", "j", 0, fmt.Sprintf("maximum number of remote repositories to fetch at once (default %d)", git.DefaultJobs))
+	cmd.Flags().BoolVar(&branchesOpts.noCache, "no-cache", false, "disable caching for remote repositories")
+	cmd.Flags().BoolVar(&branchesOpts.offline, "offline", false, "report from cached clones only, without fetching")
+	cmd.Flags().BoolVar(&branchesOpts.debug, "debug", false, "enable debug logging")
+	return cmd
+}
+
+// executeBranches contains the core logic for the 'branches' command.
+func (r *repomonRunner) executeBranches(ctx context.Context, branchesOpts *branchesOptions, rootOpts *rootOptions) error {
+	logger := slog.New(slog.NewTextHandler(r.err, nil))
+
+	cfg, err := r.loadConfig(rootOpts.configFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no config file found — run 'repomon add <repo>' to get started")
+		}
+		logger.Error("Failed to load configuration", "error", err)
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	olderThan, err := timespec.ParseDuration(branchesOpts.olderThan)
+	if err != nil {
+		return fmt.Errorf("invalid --older-than: %w", err)
+	}
+	if olderThan <= 0 {
+		return fmt.Errorf("invalid --older-than %q: must be positive", branchesOpts.olderThan)
+	}
+	if !report.ValidFormat(branchesOpts.format) {
+		return fmt.Errorf("invalid format %q: must be 'text', 'json' or 'jsonl'", branchesOpts.format)
+	}
+	formatter, ok := r.newFormatter(report.Options{Format: branchesOpts.format}).(BranchFormatter)
+	if !ok {
+		return fmt.Errorf("format %q does not support the branch report", branchesOpts.format)
+	}
+
+	settings, err := loadMonitorSettings(cfg, branchesOpts.jobs)
+	if err != nil {
+		return err
+	}
+
+	if branchesOpts.debug {
+		logger = slog.New(slog.NewTextHandler(r.err, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	requestedGroupName := rootOpts.group
+	if requestedGroupName == "" {
+		requestedGroupName = "default"
+	}
+	repos, _, err := cfg.GetRepos(requestedGroupName)
+	if err != nil {
+		logger.Error("Failed to get repositories", "error", err)
+		return fmt.Errorf("failed to get repositories: %w", err)
+	}
+
+	monitor, err := r.newConfiguredMonitor(cfg, settings, repos, branchesOpts.noCache, branchesOpts.offline, logger)
+	if err != nil {
+		return err
+	}
+	progress, err := r.progressReporter(progressAuto)
+	if err != nil {
+		return err
+	}
+	monitor.SetProgress(progress)
+	if cfg.DateField != "" {
+		if !git.ValidDateField(cfg.DateField) {
+			return fmt.Errorf("invalid date field %q: must be 'author' or 'committer'", cfg.DateField)
+		}
+		monitor.SetDateField(git.DateField(cfg.DateField))
+	}
+
+	results, err := monitor.GetStaleBranches(ctx, time.Now().Add(-olderThan))
+	if err != nil {
+		logger.Error("Failed to get stale branches", "error", err)
+		return fmt.Errorf("failed to get stale branches: %w", err)
+	}
+
+	output, err := formatter.FormatBranches(results)
+	if err != nil {
+		logger.Error("Failed to format report", "error", err)
+		return fmt.Errorf("failed to format report: %w", err)
+	}
+	fmt.Fprint(r.output, output)
+	return checkResults(ctx, results, failOnErrors)
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/plars/repomon/internal/config"
+	"github.com/plars/repomon/internal/git"
+	"github.com/spf13/cobra"
+)
+
+// cacheOptions holds the flags specific to the 'cache' commands.
+type cacheOptions struct {
+	force bool
+}
+
+func (r *repomonRunner) cacheCmd(rootOpts *rootOptions) *cobra.Command {
+	cacheOpts := &cacheOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspects and cleans the clone cache for remote repositories",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "Lists cached repositories with their size and last fetch time",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := r.executeCacheList(rootOpts); err != nil {
+				slog.Error("Cache list command failed", "error", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "prune",
+		Short: "Removes cached repositories that are no longer in any group",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := r.executeCachePrune(cmd.Context(), rootOpts); err != nil {
+				slog.Error("Cache prune command failed", "error", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	clearCmd := &cobra.Command{
+		Use:   "clear [repo]",
+		Short: "Removes all cached repositories, or the given one",
+		Long: `Removes all cached repositories, or only the given one. The repository can be
+identified by its short name (as shown in 'cache list') or by its URL. Directories in
+the cache that repomon did not create are only removed when named.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := r.executeCacheClear(cmd.Context(), args, rootOpts, cacheOpts); err != nil {
+				slog.Error("Cache clear command failed", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+	clearCmd.Flags().BoolVarP(&cacheOpts.force, "force", "f", false, "skip confirmation prompt")
+	cmd.AddCommand(clearCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "ack [repo]",
+		Short: "Acknowledges history rewrites so they are no longer reported",
+		Long: `Stops reporting the history rewrites, such as force pushes, found in all cached
+repositories or only the given one. Rewrites are otherwise reported for a week after
+they are found. The repository is identified as for 'cache clear'.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := r.executeCacheAck(cmd.Context(), args, rootOpts); err != nil {
+				slog.Error("Cache ack command failed", "error", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "verify",
+		Short: "Checks cached repositories and removes corrupt ones",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := r.executeCacheVerify(cmd.Context(), rootOpts); err != nil {
+				slog.Error("Cache verify command failed", "error", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	return cmd
+}
+
+// openCache loads the configuration and returns it with the clone cache it uses.
+func (r *repomonRunner) openCache(rootOpts *rootOptions) (*config.Config, *git.CachingGitCloner, string, error) {
+	cfg, err := r.loadConfig(rootOpts.configFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, "", fmt.Errorf("no config file found — run 'repomon add <repo>' to get started")
+		}
+		return nil, nil, "", fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	cacheDir := ""
+	if cfg.Cache != nil {
+		cacheDir = cfg.Cache.Dir
+	}
+	if cacheDir == "" {
+		cacheDir, err = git.DefaultCacheDir()
+		if err != nil {
+			return nil, nil, "", err
+		}
+	}
+	return cfg, git.NewCachingGitCloner(cacheDir), cacheDir, nil
+}
+
+// executeCacheList contains the core logic for the 'cache list' command.
+func (r *repomonRunner) executeCacheList(rootOpts *rootOptions) error {
+	_, cache, cacheDir, err := r.openCache(rootOpts)
+	if err != nil {
+		return err
+	}
+
+	entries, err := cache.Entries()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(r.output, "No cached repositories in %s.\n", cacheDir)
+		return nil
+	}
+
+	var total int64
+	fmt.Fprintf(r.output, "Cached repositories in %s:\n", cacheDir)
+	for _, entry := range entries {
+		url := entry.URL
+		if url == "" {
+			url = "(unknown URL)"
+		}
+		fetched := "never fetched"
+		if !entry.FetchedAt.IsZero() {
+			fetched = "fetched " + entry.FetchedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(r.output, "  - %s: %s (%s, %s)\n", entry.DisplayName(), url, formatSize(entry.Size), fetched)
+		total += entry.Size
+	}
+	fmt.Fprintf(r.output, "Total: %d repositories, %s\n", len(entries), formatSize(total))
+	return nil
+}
+
+// executeCachePrune contains the core logic for the 'cache prune' command.
+func (r *repomonRunner) executeCachePrune(ctx context.Context, rootOpts *rootOptions) error {
+	cfg, cache, _, err := r.openCache(rootOpts)
+	if err != nil {
+		return err
+	}
+
+	removed, err := cache.Prune(ctx, cfg.AllRepos())
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		fmt.Fprintf(r.output, "Nothing to prune: every cached repository is in a group.\n")
+		return nil
+	}
+
+	fmt.Fprintf(r.output, "Removed %d cached repositories that are no longer in any group:\n", len(removed))
+	printCacheEntries(r, removed)
+	return nil
+}
+
+// executeCacheClear contains the core logic for the 'cache clear' command.
+func (r *repomonRunner) executeCacheClear(ctx context.Context, args []string, rootOpts *rootOptions, cacheOpts *cacheOptions) error {
+	_, cache, cacheDir, err := r.openCache(rootOpts)
+	if err != nil {
+		return err
+	}
+
+	entries, err := cache.Entries()
+	if err != nil {
+		return err
+	}
+	var foreign []git.CacheEntry
+	if len(args) == 1 {
+		entries = matchCacheEntries(entries, args[0])
+		if len(entries) == 0 {
+			return fmt.Errorf("no cached repository matches '%s'", args[0])
+		}
+	} else {
+		// Directories repomon did not create are only removed when named
+		var owned []git.CacheEntry
+		for _, entry := range entries {
+			if cache.Owned(entry) {
+				owned = append(owned, entry)
+			} else {
+				foreign = append(foreign, entry)
+			}
+		}
+		entries = owned
+	}
+	if len(foreign) > 0 {
+		fmt.Fprintf(r.output, "Leaving %d directories repomon did not create; name one to remove it:\n", len(foreign))
+		printCacheEntries(r, foreign)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(r.output, "No cached repositories in %s.\n", cacheDir)
+		return nil
+	}
+
+	// If not forced, prompt for confirmation
+	if !cacheOpts.force {
+		fmt.Fprintf(r.output, "Remove %d cached repositories from %s? [y/N]: ", len(entries), cacheDir)
+		reader := bufio.NewReader(r.stdin)
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "y" && response != "yes" {
+			fmt.Fprintf(r.output, "Cancelled.\n")
+			return nil
+		}
+	}
+
+	for _, entry := range entries {
+		if err := cache.Remove(ctx, entry); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(r.output, "Removed %d cached repositories:\n", len(entries))
+	printCacheEntries(r, entries)
+	return nil
+}
+
+// executeCacheAck contains the core logic for the 'cache ack' command.
+func (r *repomonRunner) executeCacheAck(ctx context.Context, args []string, rootOpts *rootOptions) error {
+	_, cache, _, err := r.openCache(rootOpts)
+	if err != nil {
+		return err
+	}
+
+	entries, err := cache.Entries()
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		entries = matchCacheEntries(entries, args[0])
+		if len(entries) == 0 {
+			return fmt.Errorf("no cached repository matches '%s'", args[0])
+		}
+	}
+
+	var acknowledged []git.CacheEntry
+	for _, entry := range entries {
+		ok, err := cache.AcknowledgeRewrite(ctx, entry)
+		if err != nil {
+			return err
+		}
+		if ok {
+			acknowledged = append(acknowledged, entry)
+		}
+	}
+	if len(acknowledged) == 0 {
+		fmt.Fprintf(r.output, "No history rewrites to acknowledge.\n")
+		return nil
+	}
+
+	fmt.Fprintf(r.output, "Acknowledged the history rewrites of %d cached repositories:\n", len(acknowledged))
+	printCacheEntries(r, acknowledged)
+	return nil
+}
+
+// executeCacheVerify contains the core logic for the 'cache verify' command.
+func (r *repomonRunner) executeCacheVerify(ctx context.Context, rootOpts *rootOptions) error {
+	_, cache, _, err := r.openCache(rootOpts)
+	if err != nil {
+		return err
+	}
+
+	problems, err := cache.Verify(ctx)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		fmt.Fprintf(r.output, "All cached repositories are intact.\n")
+		return nil
+	}
+
+	fmt.Fprintf(r.output, "Removed %d corrupt cached repositories (they will be cloned again on the next run):\n", len(problems))
+	for _, p := range problems {
+		fmt.Fprintf(r.output, "  - %s: %v\n", p.Entry.DisplayName(), p.Err)
+	}
+	return nil
+}
+
+// matchCacheEntries returns the entries identified by a short name (optionally with
+// #branch), a URL, or a cache directory name
+func matchCacheEntries(entries []git.CacheEntry, identifier string) []git.CacheEntry {
+	var matches []git.CacheEntry
+	for _, entry := range entries {
+		short := entry.DisplayName()
+		if before, _, ok := strings.Cut(short, "#"); ok {
+			short = before
+		}
+		if identifier == entry.DisplayName() || identifier == entry.URL || identifier == entry.Name ||
+			(entry.Branch != "" && identifier == entry.URL+"#"+entry.Branch) ||
+			(entry.Branch == "" && identifier == short) {
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
+
+// printCacheEntries lists cache entries by name and URL
+func printCacheEntries(r *repomonRunner, entries []git.CacheEntry) {
+	for _, entry := range entries {
+		url := entry.URL
+		if url == "" {
+			url = entry.Name
+		}
+		fmt.Fprintf(r.output, "  - %s: %s\n", entry.DisplayName(), url)
+	}
+}
+
+// formatSize formats a size in bytes for display
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
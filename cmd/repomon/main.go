@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/plars/repomon/internal/config"
 	"github.com/plars/repomon/internal/git"
@@ -22,7 +26,23 @@ type rootOptions struct {
 // GitMonitor defines the interface for monitoring git repositories.
 type GitMonitor interface {
 	GetRecentCommits(ctx context.Context) ([]git.RepoResult, error)
+	StreamRecentCommits(ctx context.Context, fn func(index int, result git.RepoResult)) error
+	GetStaleBranches(ctx context.Context, olderThan time.Time) ([]git.RepoResult, error)
 	SetDays(days int)
+	SetCloner(cloner git.GitCloner)
+	SetWindow(since, until time.Time)
+	SetStat(stat bool)
+	SetStatus(status bool)
+	SetKeyring(kr *git.Keyring)
+	SetDateField(field git.DateField)
+	SetClockSkew(skew time.Duration)
+	SetCacheTTL(ttl time.Duration)
+	SetCacheLimits(maxSize int64, maxAge time.Duration)
+	SetOffline(offline bool)
+	SetConcurrency(jobs int, hostLimits map[string]int)
+	SetTimeout(timeout time.Duration)
+	SetRetries(retries int)
+	SetProgress(progress git.ProgressReporter)
 }
 
 // ReportFormatter defines the interface for formatting reports.
@@ -39,7 +59,7 @@ type repomonRunner struct {
 	// Dependency injection for testing
 	loadConfig    func(string) (*config.Config, error)
 	newGitMonitor func([]config.Repo, bool, string) GitMonitor
-	newFormatter  func() ReportFormatter
+	newFormatter  func(report.Options) ReportFormatter
 }
 
 func newDefaultRunner(out, err io.Writer, stdin io.Reader) *repomonRunner {
@@ -51,8 +71,14 @@ func newDefaultRunner(out, err io.Writer, stdin io.Reader) *repomonRunner {
 		newGitMonitor: func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
 			return git.NewMonitorWithCache(repos, cacheEnabled, cacheDir)
 		},
-		newFormatter: func() ReportFormatter {
-			return report.NewFormatter()
+		newFormatter: func(opts report.Options) ReportFormatter {
+			switch opts.Format {
+			case report.FormatJSON:
+				return report.NewJSONFormatter()
+			case report.FormatJSONLines:
+				return report.NewJSONLinesFormatter()
+			}
+			return report.NewFormatterWithOptions(opts)
 		},
 	}
 }
@@ -81,8 +107,11 @@ showing the most recent commits to each repository in an easy-to-read format.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			runOpts.daysExplicitlySet = cmd.Flags().Changed("days")
 			if err := runner.executeRun(cmd.Context(), args, runOpts, rootOpts); err != nil {
-				slog.Error("Run command failed", "error", err)
-				os.Exit(1)
+				// JSON progress has already written the failure as an event
+				if runOpts.progress != progressJSON {
+					slog.Error("Run command failed", "error", err)
+				}
+				os.Exit(exitCode(err))
 			}
 		},
 	}
@@ -92,8 +121,25 @@ showing the most recent commits to each repository in an easy-to-read format.`,
 	rootCmd.PersistentFlags().StringVarP(&rootOpts.group, "group", "g", "", "repository group to use (default: 'default')")
 	// Bind run-specific flags to runOptions
 	rootCmd.Flags().IntVarP(&runOpts.days, "days", "d", 1, "number of days to look back in history")
+	rootCmd.Flags().StringVar(&runOpts.since, "since", "", "start of the window: a date, RFC3339 time, duration (36h, 2w) or day (yesterday, last monday)")
+	rootCmd.Flags().StringVar(&runOpts.until, "until", "", "end of the window, in the same forms as --since (a date includes that whole day)")
 	rootCmd.Flags().BoolVar(&runOpts.debug, "debug", false, "enable debug logging")
 	rootCmd.Flags().BoolVar(&runOpts.noCache, "no-cache", false, "disable caching for remote repositories")
+	rootCmd.Flags().BoolVar(&runOpts.offline, "offline", false, "report from cached clones only, without fetching")
+	rootCmd.Flags().IntVarP(&runOpts.jobs, "jobs", "j", 0, fmt.Sprintf("maximum number of remote repositories to fetch at once (default %d)", git.DefaultJobs))
+	rootCmd.Flags().StringVar(&runOpts.dateField, "date-field", "", "commit date to filter on: 'author' (default) or 'committer'")
+	rootCmd.Flags().StringVar(&runOpts.progress, "progress", "auto", "progress reporting: 'auto' (a bar on terminals), 'bar', 'none' or 'json' (events and logs as JSON lines on stderr)")
+	rootCmd.Flags().StringVar(&runOpts.dormantAfter, "dormant-after", "", "list repositories with no commit for this long (e.g. 90d) as dormant")
+	rootCmd.Flags().StringVar(&runOpts.deadline, "deadline", "", "stop after this long (e.g. 10m) and report the repositories done by then")
+	rootCmd.Flags().StringVar(&runOpts.failOn, "fail-on", failOnErrors, "exit with an error when repositories 'errors' (fail), are 'inactive' (fail or miss expectations), or 'none'")
+	rootCmd.Flags().StringVar(&runOpts.format, "format", report.FormatText, "output format: 'text', 'json' or 'jsonl' (one JSON object per repository)")
+	rootCmd.Flags().BoolVar(&runOpts.verifySignatures, "verify-signatures", false, "verify commit signatures against the configured keys")
+	rootCmd.Flags().BoolVar(&runOpts.stat, "stat", false, "show files changed, insertions and deletions for each commit (slower)")
+	rootCmd.Flags().BoolVar(&runOpts.status, "status", false, "show uncommitted changes, stashes and unpushed commits of local repositories")
+	rootCmd.Flags().BoolVar(&runOpts.fetch, "fetch", false, "fetch local repositories first and show the upstream commits not pulled yet")
+	rootCmd.Flags().BoolVar(&runOpts.long, "long", false, "show full commit messages")
+	rootCmd.Flags().StringArrayVar(&runOpts.authors, "author", nil, "only show commits with an author or co-author matching this (repeatable)")
+	rootCmd.Flags().StringVar(&runOpts.layout, "layout", report.LayoutList, "report layout: 'list' or 'conventional' (group commits by Conventional Commits type)")
 
 	versionCmd := runner.versionCmd()
 
@@ -116,7 +162,20 @@ showing the most recent commits to each repository in an easy-to-read format.`,
 
 	rootCmd.AddCommand(runner.rmCmd(rootOpts))
 
-	if err := rootCmd.Execute(); err != nil {
+	rootCmd.AddCommand(runner.cacheCmd(rootOpts))
+
+	rootCmd.AddCommand(runner.branchesCmd(rootOpts))
+
+	// Interrupting cancels the run, which still reports the repositories done by then.
+	// A second interrupt kills repomon outright.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		slog.Error("Command execution failed", "error", err)
 		os.Exit(1)
 	}
@@ -3,39 +3,141 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/plars/repomon/internal/config"
 	"github.com/plars/repomon/internal/git"
+	"github.com/plars/repomon/internal/report"
 )
 
 // mockGitMonitor is a mock implementation of the GitMonitor interface.
 type mockGitMonitor struct {
-	results []git.RepoResult
-	err     error
-	days    int
+	results   []git.RepoResult
+	err       error
+	days      int
+	cloner    git.GitCloner
+	since     time.Time
+	until     time.Time
+	stat      bool
+	status    bool
+	keyring   *git.Keyring
+	dateField git.DateField
+	clockSkew time.Duration
+	cacheTTL  time.Duration
+	maxSize   int64
+	maxAge    time.Duration
+	offline   bool
+	jobs      int
+	hosts     map[string]int
+	timeout   time.Duration
+	retries   int
+	progress  git.ProgressReporter
+	olderThan time.Time
 }
 
 func (m *mockGitMonitor) GetRecentCommits(ctx context.Context) ([]git.RepoResult, error) {
 	return m.results, m.err
 }
 
+// StreamRecentCommits hands over the results last to first, as if the last repository
+// finished first
+func (m *mockGitMonitor) StreamRecentCommits(ctx context.Context, fn func(index int, result git.RepoResult)) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i := len(m.results) - 1; i >= 0; i-- {
+		fn(i, m.results[i])
+	}
+	return nil
+}
+
+func (m *mockGitMonitor) GetStaleBranches(ctx context.Context, olderThan time.Time) ([]git.RepoResult, error) {
+	m.olderThan = olderThan
+	return m.results, m.err
+}
+
+func (m *mockGitMonitor) SetProgress(progress git.ProgressReporter) {
+	m.progress = progress
+}
+
 func (m *mockGitMonitor) SetDays(days int) {
 	m.days = days
 }
 
+func (m *mockGitMonitor) SetCloner(cloner git.GitCloner) {
+	m.cloner = cloner
+}
+
+func (m *mockGitMonitor) SetWindow(since, until time.Time) {
+	m.since = since
+	m.until = until
+}
+
+func (m *mockGitMonitor) SetStat(stat bool) {
+	m.stat = stat
+}
+
+func (m *mockGitMonitor) SetStatus(status bool) {
+	m.status = status
+}
+
+func (m *mockGitMonitor) SetKeyring(kr *git.Keyring) {
+	m.keyring = kr
+}
+
+func (m *mockGitMonitor) SetDateField(field git.DateField) {
+	m.dateField = field
+}
+
+func (m *mockGitMonitor) SetCacheTTL(ttl time.Duration) {
+	m.cacheTTL = ttl
+}
+
+func (m *mockGitMonitor) SetCacheLimits(maxSize int64, maxAge time.Duration) {
+	m.maxSize = maxSize
+	m.maxAge = maxAge
+}
+
+func (m *mockGitMonitor) SetConcurrency(jobs int, hostLimits map[string]int) {
+	m.jobs = jobs
+	m.hosts = hostLimits
+}
+
+func (m *mockGitMonitor) SetTimeout(timeout time.Duration) {
+	m.timeout = timeout
+}
+
+func (m *mockGitMonitor) SetRetries(retries int) {
+	m.retries = retries
+}
+
+func (m *mockGitMonitor) SetOffline(offline bool) {
+	m.offline = offline
+}
+
+func (m *mockGitMonitor) SetClockSkew(skew time.Duration) {
+	m.clockSkew = skew
+}
+
 // mockFormatter is a mock implementation of the ReportFormatter interface.
 type mockFormatter struct {
-	output string
-	err    error
+	output  string
+	err     error
+	results []git.RepoResult
 }
 
 func (m *mockFormatter) Format(results []git.RepoResult) (string, error) {
+	m.results = results
 	return m.output, m.err
 }
 
@@ -251,6 +353,56 @@ func TestExecuteRun(t *testing.T) {
 			rootOpts:       &rootOptions{group: "default"},
 			expectedOutput: "Debug report",
 		},
+		{
+			name: "Invalid layout",
+			cfg: &config.Config{
+				Days: 1,
+				Groups: map[string]*config.Group{
+					"default": {Repos: []string{"/path/to/repo"}},
+				},
+			},
+			runOpts:       &runOptions{days: 1, layout: "tree"},
+			rootOpts:      &rootOptions{group: "default"},
+			expectedError: "invalid layout",
+		},
+		{
+			name: "Invalid format",
+			cfg: &config.Config{
+				Days: 1,
+				Groups: map[string]*config.Group{
+					"default": {Repos: []string{"/path/to/repo"}},
+				},
+			},
+			runOpts:       &runOptions{days: 1, format: "yaml"},
+			rootOpts:      &rootOptions{group: "default"},
+			expectedError: "invalid format",
+		},
+		{
+			name: "Invalid date field",
+			cfg: &config.Config{
+				Days:      1,
+				DateField: "commit",
+				Groups: map[string]*config.Group{
+					"default": {Repos: []string{"/path/to/repo"}},
+				},
+			},
+			runOpts:       &runOptions{days: 1},
+			rootOpts:      &rootOptions{group: "default"},
+			expectedError: "invalid date field",
+		},
+		{
+			name: "Missing signing keys",
+			cfg: &config.Config{
+				Days:       1,
+				Signatures: &config.SignatureConfig{Verify: true, Keyring: "/nonexistent/keys.asc"},
+				Groups: map[string]*config.Group{
+					"default": {Repos: []string{"/path/to/repo"}},
+				},
+			},
+			runOpts:       &runOptions{days: 1},
+			rootOpts:      &rootOptions{group: "default"},
+			expectedError: "failed to load signing keys",
+		},
 	}
 
 	for _, tt := range tests {
@@ -268,7 +420,7 @@ func TestExecuteRun(t *testing.T) {
 			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
 				return &mockGitMonitor{results: tt.monitorResults, err: tt.monitorErr}
 			}
-			runner.newFormatter = func() ReportFormatter {
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
 				return &mockFormatter{output: tt.formatOutput, err: tt.formatErr}
 			}
 
@@ -343,7 +495,7 @@ func TestExecuteRun_DaysResolution(t *testing.T) {
 				m := &mockGitMonitor{results: []git.RepoResult{}}
 				return &capturingMonitor{mock: m, onSetDays: func(d int) { capturedDays = d }}
 			}
-			runner.newFormatter = func() ReportFormatter {
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
 				return &mockFormatter{output: ""}
 			}
 
@@ -366,135 +518,634 @@ func (c *capturingMonitor) GetRecentCommits(ctx context.Context) ([]git.RepoResu
 	return c.mock.GetRecentCommits(ctx)
 }
 
+func (c *capturingMonitor) StreamRecentCommits(ctx context.Context, fn func(index int, result git.RepoResult)) error {
+	return c.mock.StreamRecentCommits(ctx, fn)
+}
+
+func (c *capturingMonitor) GetStaleBranches(ctx context.Context, olderThan time.Time) ([]git.RepoResult, error) {
+	return c.mock.GetStaleBranches(ctx, olderThan)
+}
+
+func (c *capturingMonitor) SetProgress(progress git.ProgressReporter) {
+	c.mock.SetProgress(progress)
+}
+
 func (c *capturingMonitor) SetDays(days int) {
 	c.onSetDays(days)
 	c.mock.SetDays(days)
 }
 
-func TestExecuteAdd(t *testing.T) {
+func (c *capturingMonitor) SetCloner(cloner git.GitCloner) {
+	c.mock.SetCloner(cloner)
+}
+
+func (c *capturingMonitor) SetWindow(since, until time.Time) {
+	c.mock.SetWindow(since, until)
+}
+
+func (c *capturingMonitor) SetStat(stat bool) {
+	c.mock.SetStat(stat)
+}
+
+func (c *capturingMonitor) SetStatus(status bool) {
+	c.mock.SetStatus(status)
+}
+
+func (c *capturingMonitor) SetKeyring(kr *git.Keyring) {
+	c.mock.SetKeyring(kr)
+}
+
+func (c *capturingMonitor) SetDateField(field git.DateField) {
+	c.mock.SetDateField(field)
+}
+
+func (c *capturingMonitor) SetClockSkew(skew time.Duration) {
+	c.mock.SetClockSkew(skew)
+}
+
+func (c *capturingMonitor) SetCacheTTL(ttl time.Duration) {
+	c.mock.SetCacheTTL(ttl)
+}
+
+func (c *capturingMonitor) SetCacheLimits(maxSize int64, maxAge time.Duration) {
+	c.mock.SetCacheLimits(maxSize, maxAge)
+}
+
+func (c *capturingMonitor) SetConcurrency(jobs int, hostLimits map[string]int) {
+	c.mock.SetConcurrency(jobs, hostLimits)
+}
+
+func (c *capturingMonitor) SetTimeout(timeout time.Duration) {
+	c.mock.SetTimeout(timeout)
+}
+
+func (c *capturingMonitor) SetRetries(retries int) {
+	c.mock.SetRetries(retries)
+}
+
+func (c *capturingMonitor) SetOffline(offline bool) {
+	c.mock.SetOffline(offline)
+}
+
+func TestExecuteRun_VerifySignatures(t *testing.T) {
 	tests := []struct {
-		name           string
-		cfg            *config.Config
-		loadErr        error
-		addRepoErr     error
-		saveErr        error
-		args           []string
-		rootOpts       *rootOptions
-		expectedOutput string
-		expectedError  string
+		name        string
+		signatures  *config.SignatureConfig
+		flag        bool
+		wantKeyring bool
+	}{
+		{name: "disabled by default", wantKeyring: false},
+		{name: "enabled by flag", flag: true, wantKeyring: true},
+		{name: "enabled by config", signatures: &config.SignatureConfig{Verify: true}, wantKeyring: true},
+		{name: "configured but not enabled", signatures: &config.SignatureConfig{Verify: false}, wantKeyring: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days:       1,
+					Signatures: tt.signatures,
+					Groups: map[string]*config.Group{
+						"default": {Repos: []string{"/path/to/repo"}},
+					},
+				}, nil
+			}
+			mock := &mockGitMonitor{}
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				return mock
+			}
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
+				return &mockFormatter{}
+			}
+
+			err := runner.executeRun(context.Background(), nil, &runOptions{days: 1, verifySignatures: tt.flag}, &rootOptions{group: "default"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if (mock.keyring != nil) != tt.wantKeyring {
+				t.Errorf("Expected keyring set=%v, got %v", tt.wantKeyring, mock.keyring != nil)
+			}
+		})
+	}
+}
+
+func TestExecuteRun_Window(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           *config.Config
+		runOpts       *runOptions
+		wantSince     bool
+		wantUntil     bool
+		wantDays      int
+		expectedError string
 	}{
 		{
-			name: "Add repository successfully",
-			cfg: &config.Config{
-				Days: 1,
-				Groups: map[string]*config.Group{
-					"default": {Repos: []string{}},
-				},
-			},
-			args:           []string{"/path/to/new-repo"},
-			rootOpts:       &rootOptions{group: "default"},
-			expectedOutput: "Added '/path/to/new-repo' to group 'default'",
+			name:      "since and until flags",
+			runOpts:   &runOptions{days: 1, since: "2024-10-01", until: "2024-10-14"},
+			wantSince: true,
+			wantUntil: true,
+			wantDays:  1,
 		},
 		{
-			name: "Add to non-existent group creates new group",
-			cfg: &config.Config{
-				Days: 1,
-				Groups: map[string]*config.Group{
-					"default": {Repos: []string{"/existing/repo"}},
-				},
-			},
-			args:           []string{"/path/to/work-repo"},
-			rootOpts:       &rootOptions{group: "work"},
-			expectedOutput: "Added '/path/to/work-repo' to group 'work'",
+			name:      "until alone keeps the days window",
+			runOpts:   &runOptions{days: 1, until: "6h"},
+			cfg:       &config.Config{Days: 3},
+			wantUntil: true,
+			wantDays:  3,
 		},
 		{
-			name:          "Add with no args fails",
-			cfg:           &config.Config{Groups: make(map[string]*config.Group)},
-			args:          []string{},
-			rootOpts:      &rootOptions{group: "default"},
-			expectedError: "repository argument is required",
+			name:      "config since",
+			runOpts:   &runOptions{days: 1},
+			cfg:       &config.Config{Days: 7, Since: "2w"},
+			wantSince: true,
+			wantDays:  7,
 		},
 		{
-			name:    "Config not found creates new config",
-			loadErr: fmt.Errorf("failed to read config file: %w", os.ErrNotExist),
-			args:    []string{"/path/to/repo"},
-			rootOpts: &rootOptions{
-				configFile: "missing.yaml",
-				group:      "default",
-			},
-			expectedOutput: "Added '/path/to/repo' to group 'default'",
+			name:     "--days overrides config since",
+			runOpts:  &runOptions{days: 2, daysExplicitlySet: true},
+			cfg:      &config.Config{Days: 7, Since: "2w", Until: "yesterday"},
+			wantDays: 2,
 		},
 		{
-			name:    "Other config load failure returns error",
-			loadErr: fmt.Errorf("permission denied"),
-			args:    []string{"/path/to/repo"},
-			rootOpts: &rootOptions{
-				configFile: "missing.yaml",
-				group:      "default",
-			},
-			expectedError: "failed to load configuration",
+			name:          "--days with --since",
+			runOpts:       &runOptions{days: 2, daysExplicitlySet: true, since: "2w"},
+			expectedError: "cannot be used together",
 		},
 		{
-			name: "Add duplicate repository fails",
-			cfg: &config.Config{
-				Days: 1,
-				Groups: map[string]*config.Group{
-					"default": {Repos: []string{"/existing/repo"}},
-				},
-			},
-			args:          []string{"/existing/repo"},
-			rootOpts:      &rootOptions{group: "default"},
-			expectedError: "already exists",
+			name:          "invalid since",
+			runOpts:       &runOptions{days: 1, since: "the other day"},
+			expectedError: "invalid --since",
 		},
 		{
-			name: "Add to group with temp config path",
-			cfg: &config.Config{
-				Days: 1,
-				Groups: map[string]*config.Group{
-					"default": {Repos: []string{}},
-				},
-			},
-			args:           []string{"/path/to/repo"},
-			rootOpts:       &rootOptions{configFile: "", group: "default"},
-			expectedOutput: "Added '/path/to/repo' to group 'default'",
+			name:          "invalid until",
+			runOpts:       &runOptions{days: 1, until: "later"},
+			expectedError: "invalid --until",
+		},
+		{
+			name:          "until before since",
+			runOpts:       &runOptions{days: 1, since: "2024-10-14", until: "2024-10-01"},
+			expectedError: "invalid window",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			outBuf := new(bytes.Buffer)
-			errBuf := new(bytes.Buffer)
-			runner := newDefaultRunner(outBuf, errBuf, nil)
+			cfg := tt.cfg
+			if cfg == nil {
+				cfg = &config.Config{Days: 1}
+			}
+			cfg.Groups = map[string]*config.Group{
+				"default": {Repos: []string{"/path/to/repo"}},
+			}
 
+			runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
 			runner.loadConfig = func(path string) (*config.Config, error) {
-				if tt.loadErr != nil {
-					return nil, tt.loadErr
+				return cfg, nil
+			}
+			mock := &mockGitMonitor{}
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				return mock
+			}
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
+				return &mockFormatter{}
+			}
+
+			err := runner.executeRun(context.Background(), nil, tt.runOpts, &rootOptions{group: "default"})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedError, err)
 				}
-				return tt.cfg, nil
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
 			}
+			if mock.days != tt.wantDays {
+				t.Errorf("Expected days=%d, got %d", tt.wantDays, mock.days)
+			}
+			if mock.since.IsZero() == tt.wantSince {
+				t.Errorf("Expected since set=%v, got %v", tt.wantSince, mock.since)
+			}
+			if mock.until.IsZero() == tt.wantUntil {
+				t.Errorf("Expected until set=%v, got %v", tt.wantUntil, mock.until)
+			}
+		})
+	}
+}
 
-			err := runner.executeAdd(tt.args, tt.rootOpts)
+func TestExecuteRun_CloneBackend(t *testing.T) {
+	tests := []struct {
+		name          string
+		clone         *config.CloneConfig
+		wantGoGit     bool
+		wantCache     bool
+		expectedError string
+	}{
+		{name: "git backend keeps the cache", clone: &config.CloneConfig{Backend: "git"}, wantCache: true},
+		{name: "go-git backend", clone: &config.CloneConfig{Backend: "go-git", Storage: "disk"}, wantGoGit: true},
+		{name: "invalid backend", clone: &config.CloneConfig{Backend: "svn"}, expectedError: "invalid clone backend"},
+		{name: "invalid storage", clone: &config.CloneConfig{Backend: "go-git", Storage: "tape"}, expectedError: "invalid clone storage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days:  1,
+					Cache: &config.CacheConfig{Enabled: true},
+					Clone: tt.clone,
+					Groups: map[string]*config.Group{
+						"default": {Repos: []string{"https://github.com/plars/repomon"}},
+					},
+				}, nil
+			}
+			mock := &mockGitMonitor{}
+			var gotCache bool
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				gotCache = cacheEnabled
+				return mock
+			}
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
+				return &mockFormatter{}
+			}
 
+			err := runner.executeRun(context.Background(), nil, &runOptions{days: 1}, &rootOptions{group: "default"})
 			if tt.expectedError != "" {
 				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
-					t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
-				}
-			} else {
-				if err != nil {
-					t.Errorf("Unexpected error: %v", err)
-				}
-				if !strings.Contains(outBuf.String(), tt.expectedOutput) {
-					t.Errorf("Expected output containing %q, got %q", tt.expectedOutput, outBuf.String())
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedError, err)
 				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, ok := mock.cloner.(*git.GoGitCloner); ok != tt.wantGoGit {
+				t.Errorf("Expected go-git cloner=%v, got %T", tt.wantGoGit, mock.cloner)
+			}
+			if gotCache != tt.wantCache {
+				t.Errorf("Expected cache enabled=%v, got %v", tt.wantCache, gotCache)
 			}
 		})
 	}
 }
 
-func TestExecuteRm(t *testing.T) {
+func TestExecuteRun_Offline(t *testing.T) {
 	tests := []struct {
-		name           string
-		cfg            *config.Config
+		name          string
+		cache         *config.CacheConfig
+		runOpts       *runOptions
+		wantOffline   bool
+		wantTTL       time.Duration
+		expectedError string
+	}{
+		{name: "online with TTL", cache: &config.CacheConfig{Enabled: true, TTL: "10m"}, runOpts: &runOptions{days: 1}, wantTTL: 10 * time.Minute},
+		{name: "TTL in days", cache: &config.CacheConfig{Enabled: true, TTL: "1d"}, runOpts: &runOptions{days: 1}, wantTTL: 24 * time.Hour},
+		{name: "invalid TTL", cache: &config.CacheConfig{Enabled: true, TTL: "soon"}, runOpts: &runOptions{days: 1}, expectedError: "invalid cache ttl"},
+		{name: "offline", cache: &config.CacheConfig{Enabled: true}, runOpts: &runOptions{days: 1, offline: true}, wantOffline: true},
+		{name: "offline without cache", cache: &config.CacheConfig{Enabled: true}, runOpts: &runOptions{days: 1, offline: true, noCache: true}, expectedError: "--offline reports from the clone cache"},
+		{name: "TTL ignored without cache", cache: &config.CacheConfig{Enabled: false, TTL: "1h"}, runOpts: &runOptions{days: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days:  1,
+					Cache: tt.cache,
+					Clone: &config.CloneConfig{Backend: "git"},
+					Groups: map[string]*config.Group{
+						"default": {Repos: []string{"https://github.com/plars/repomon"}},
+					},
+				}, nil
+			}
+			mock := &mockGitMonitor{}
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				return mock
+			}
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
+				return &mockFormatter{}
+			}
+
+			err := runner.executeRun(context.Background(), nil, tt.runOpts, &rootOptions{group: "default"})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if mock.offline != tt.wantOffline {
+				t.Errorf("Expected offline=%v, got %v", tt.wantOffline, mock.offline)
+			}
+			if mock.cacheTTL != tt.wantTTL {
+				t.Errorf("Expected cache TTL %v, got %v", tt.wantTTL, mock.cacheTTL)
+			}
+		})
+	}
+}
+
+func TestExecuteRun_CacheLimits(t *testing.T) {
+	tests := []struct {
+		name          string
+		cache         *config.CacheConfig
+		wantSize      int64
+		wantAge       time.Duration
+		expectedError string
+	}{
+		{name: "limits", cache: &config.CacheConfig{Enabled: true, MaxSize: "2GB", MaxAge: "30d"}, wantSize: 2 << 30, wantAge: 30 * 24 * time.Hour},
+		{name: "no limits", cache: &config.CacheConfig{Enabled: true}},
+		{name: "invalid size", cache: &config.CacheConfig{Enabled: true, MaxSize: "huge"}, expectedError: "invalid cache max_size"},
+		{name: "invalid age", cache: &config.CacheConfig{Enabled: true, MaxAge: "forever"}, expectedError: "invalid cache max_age"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days:  1,
+					Cache: tt.cache,
+					Clone: &config.CloneConfig{Backend: "git"},
+					Groups: map[string]*config.Group{
+						"default": {Repos: []string{"https://github.com/plars/repomon"}},
+					},
+				}, nil
+			}
+			mock := &mockGitMonitor{}
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				return mock
+			}
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
+				return &mockFormatter{}
+			}
+
+			err := runner.executeRun(context.Background(), nil, &runOptions{days: 1}, &rootOptions{group: "default"})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if mock.maxSize != tt.wantSize || mock.maxAge != tt.wantAge {
+				t.Errorf("Expected limits %d bytes and %v, got %d bytes and %v", tt.wantSize, tt.wantAge, mock.maxSize, mock.maxAge)
+			}
+		})
+	}
+}
+
+func TestExecuteRun_Concurrency(t *testing.T) {
+	tests := []struct {
+		name          string
+		concurrency   *config.ConcurrencyConfig
+		jobs          int
+		wantJobs      int
+		wantHosts     map[string]int
+		expectedError string
+	}{
+		{name: "default", wantJobs: git.DefaultJobs},
+		{name: "config", concurrency: &config.ConcurrencyConfig{Jobs: 20, Hosts: map[string]int{"gitlab.example.com": 4}}, wantJobs: 20, wantHosts: map[string]int{"gitlab.example.com": 4}},
+		{name: "flag overrides config", concurrency: &config.ConcurrencyConfig{Jobs: 20}, jobs: 2, wantJobs: 2},
+		{name: "invalid jobs", jobs: -1, expectedError: "invalid jobs -1"},
+		{name: "invalid host limit", concurrency: &config.ConcurrencyConfig{Hosts: map[string]int{"gitlab.example.com": 0}}, expectedError: `invalid concurrency limit 0 for host "gitlab.example.com"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days:        1,
+					Concurrency: tt.concurrency,
+					Groups: map[string]*config.Group{
+						"default": {Repos: []string{"https://github.com/plars/repomon"}},
+					},
+				}, nil
+			}
+			mock := &mockGitMonitor{}
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				return mock
+			}
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
+				return &mockFormatter{}
+			}
+
+			err := runner.executeRun(context.Background(), nil, &runOptions{days: 1, jobs: tt.jobs}, &rootOptions{group: "default"})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if mock.jobs != tt.wantJobs {
+				t.Errorf("Expected %d jobs, got %d", tt.wantJobs, mock.jobs)
+			}
+			if len(mock.hosts) != len(tt.wantHosts) {
+				t.Errorf("Expected host limits %v, got %v", tt.wantHosts, mock.hosts)
+			}
+			for host, limit := range tt.wantHosts {
+				if mock.hosts[host] != limit {
+					t.Errorf("Expected limit %d for %s, got %d", limit, host, mock.hosts[host])
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteRun_DateField(t *testing.T) {
+	skew, zero := 3*time.Hour, time.Duration(0)
+	tests := []struct {
+		name      string
+		cfgField  string
+		flagField string
+		cfgSkew   *time.Duration
+		wantField git.DateField
+		wantSkew  time.Duration
+	}{
+		{name: "defaults to author date", wantField: git.DateAuthor, wantSkew: git.DefaultClockSkew},
+		{name: "config selects committer date", cfgField: "committer", wantField: git.DateCommitter, wantSkew: git.DefaultClockSkew},
+		{name: "flag overrides config", cfgField: "committer", flagField: "author", wantField: git.DateAuthor, wantSkew: git.DefaultClockSkew},
+		{name: "config sets clock skew", cfgSkew: &skew, wantField: git.DateAuthor, wantSkew: 3 * time.Hour},
+		{name: "config disables clock skew", cfgSkew: &zero, wantField: git.DateAuthor, wantSkew: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days:      1,
+					DateField: tt.cfgField,
+					ClockSkew: tt.cfgSkew,
+					Groups: map[string]*config.Group{
+						"default": {Repos: []string{"/path/to/repo"}},
+					},
+				}, nil
+			}
+			mock := &mockGitMonitor{clockSkew: git.DefaultClockSkew}
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				return mock
+			}
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
+				return &mockFormatter{}
+			}
+
+			err := runner.executeRun(context.Background(), nil, &runOptions{days: 1, dateField: tt.flagField}, &rootOptions{group: "default"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if mock.dateField != tt.wantField {
+				t.Errorf("Expected date field %q, got %q", tt.wantField, mock.dateField)
+			}
+			if mock.clockSkew != tt.wantSkew {
+				t.Errorf("Expected clock skew %v, got %v", tt.wantSkew, mock.clockSkew)
+			}
+		})
+	}
+}
+
+func TestExecuteAdd(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            *config.Config
+		loadErr        error
+		addRepoErr     error
+		saveErr        error
+		args           []string
+		rootOpts       *rootOptions
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			name: "Add repository successfully",
+			cfg: &config.Config{
+				Days: 1,
+				Groups: map[string]*config.Group{
+					"default": {Repos: []string{}},
+				},
+			},
+			args:           []string{"/path/to/new-repo"},
+			rootOpts:       &rootOptions{group: "default"},
+			expectedOutput: "Added '/path/to/new-repo' to group 'default'",
+		},
+		{
+			name: "Add to non-existent group creates new group",
+			cfg: &config.Config{
+				Days: 1,
+				Groups: map[string]*config.Group{
+					"default": {Repos: []string{"/existing/repo"}},
+				},
+			},
+			args:           []string{"/path/to/work-repo"},
+			rootOpts:       &rootOptions{group: "work"},
+			expectedOutput: "Added '/path/to/work-repo' to group 'work'",
+		},
+		{
+			name:          "Add with no args fails",
+			cfg:           &config.Config{Groups: make(map[string]*config.Group)},
+			args:          []string{},
+			rootOpts:      &rootOptions{group: "default"},
+			expectedError: "repository argument is required",
+		},
+		{
+			name:    "Config not found creates new config",
+			loadErr: fmt.Errorf("failed to read config file: %w", os.ErrNotExist),
+			args:    []string{"/path/to/repo"},
+			rootOpts: &rootOptions{
+				configFile: "missing.yaml",
+				group:      "default",
+			},
+			expectedOutput: "Added '/path/to/repo' to group 'default'",
+		},
+		{
+			name:    "Other config load failure returns error",
+			loadErr: fmt.Errorf("permission denied"),
+			args:    []string{"/path/to/repo"},
+			rootOpts: &rootOptions{
+				configFile: "missing.yaml",
+				group:      "default",
+			},
+			expectedError: "failed to load configuration",
+		},
+		{
+			name: "Add duplicate repository fails",
+			cfg: &config.Config{
+				Days: 1,
+				Groups: map[string]*config.Group{
+					"default": {Repos: []string{"/existing/repo"}},
+				},
+			},
+			args:          []string{"/existing/repo"},
+			rootOpts:      &rootOptions{group: "default"},
+			expectedError: "already exists",
+		},
+		{
+			name: "Add to group with temp config path",
+			cfg: &config.Config{
+				Days: 1,
+				Groups: map[string]*config.Group{
+					"default": {Repos: []string{}},
+				},
+			},
+			args:           []string{"/path/to/repo"},
+			rootOpts:       &rootOptions{configFile: "", group: "default"},
+			expectedOutput: "Added '/path/to/repo' to group 'default'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outBuf := new(bytes.Buffer)
+			errBuf := new(bytes.Buffer)
+			runner := newDefaultRunner(outBuf, errBuf, nil)
+
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				if tt.loadErr != nil {
+					return nil, tt.loadErr
+				}
+				return tt.cfg, nil
+			}
+
+			// Adding saves the config, which must stay out of the source tree and the
+			// user's own config directory
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			if tt.rootOpts.configFile != "" {
+				tt.rootOpts.configFile = filepath.Join(t.TempDir(), tt.rootOpts.configFile)
+			}
+
+			err := runner.executeAdd(tt.args, tt.rootOpts)
+
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				if !strings.Contains(outBuf.String(), tt.expectedOutput) {
+					t.Errorf("Expected output containing %q, got %q", tt.expectedOutput, outBuf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteRm(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            *config.Config
 		loadErr        error
 		args           []string
 		rootOpts       *rootOptions
@@ -737,32 +1388,253 @@ func TestExecuteRmWithConfirmation(t *testing.T) {
 	}
 }
 
-func TestExecuteRunFormatterError(t *testing.T) {
-	outBuf := new(bytes.Buffer)
-	errBuf := new(bytes.Buffer)
-	runner := newDefaultRunner(outBuf, errBuf, nil)
-
-	runner.loadConfig = func(path string) (*config.Config, error) {
-		return &config.Config{
-			Days: 1,
-			Groups: map[string]*config.Group{
-				"default": {Repos: []string{"/path/to/repo"}},
-			},
-		}, nil
-	}
-	runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
-		return &mockGitMonitor{results: []git.RepoResult{
-			{Repo: config.Repo{Name: "repo", Path: "/path/to/repo"}},
-		}, err: nil}
+// writeCacheEntry creates a fake cache entry with a metadata file
+func writeCacheEntry(t *testing.T, cacheDir, name, url, branch string) {
+	t.Helper()
+	entryPath := filepath.Join(cacheDir, name)
+	if err := os.MkdirAll(entryPath, 0755); err != nil {
+		t.Fatal(err)
 	}
-	runner.newFormatter = func() ReportFormatter {
-		return &mockFormatter{output: "", err: fmt.Errorf("formatting failed")}
+	meta := fmt.Sprintf(`{"url": %q, "branch": %q, "fetched_at": "2024-10-16T15:30:00Z"}`, url, branch)
+	if err := os.WriteFile(filepath.Join(entryPath, "repomon.json"), []byte(meta), 0644); err != nil {
+		t.Fatal(err)
 	}
+}
 
-	err := runner.executeRun(context.Background(), nil, &runOptions{days: 1}, &rootOptions{group: "default"})
-
-	if err == nil || !strings.Contains(err.Error(), "failed to format report") {
-		t.Errorf("Expected formatter error, got %v", err)
+func TestExecuteCache(t *testing.T) {
+	newRunner := func(t *testing.T, stdin string) (*repomonRunner, *bytes.Buffer, string) {
+		cacheDir := t.TempDir()
+		writeCacheEntry(t, cacheDir, "repomon-0123456789abcdef", "https://github.com/plars/repomon", "main")
+		writeCacheEntry(t, cacheDir, "old-0123456789abcdef", "https://github.com/plars/old", "")
+
+		outBuf := new(bytes.Buffer)
+		runner := newDefaultRunner(outBuf, new(bytes.Buffer), strings.NewReader(stdin))
+		runner.loadConfig = func(path string) (*config.Config, error) {
+			return &config.Config{
+				Cache:  &config.CacheConfig{Dir: cacheDir},
+				Groups: map[string]*config.Group{"default": {Repos: []string{"/path/to/local"}}},
+			}, nil
+		}
+		return runner, outBuf, cacheDir
+	}
+	remaining := func(t *testing.T, cacheDir string) int {
+		t.Helper()
+		dirEntries, err := os.ReadDir(cacheDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for _, d := range dirEntries {
+			if d.IsDir() {
+				n++
+			}
+		}
+		return n
+	}
+
+	t.Run("list", func(t *testing.T) {
+		runner, outBuf, cacheDir := newRunner(t, "")
+		if err := runner.executeCacheList(&rootOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, want := range []string{
+			"Cached repositories in " + cacheDir,
+			"  - old: https://github.com/plars/old (",
+			"  - repomon#main: https://github.com/plars/repomon (",
+			"fetched ",
+			"Total: 2 repositories",
+		} {
+			if !strings.Contains(outBuf.String(), want) {
+				t.Errorf("Expected output containing %q, got %q", want, outBuf.String())
+			}
+		}
+	})
+
+	t.Run("list empty cache", func(t *testing.T) {
+		runner, outBuf, _ := newRunner(t, "")
+		runner.loadConfig = func(path string) (*config.Config, error) {
+			return &config.Config{Cache: &config.CacheConfig{Dir: filepath.Join(t.TempDir(), "missing")}}, nil
+		}
+		if err := runner.executeCacheList(&rootOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(outBuf.String(), "No cached repositories") {
+			t.Errorf("Expected an empty cache message, got %q", outBuf.String())
+		}
+	})
+
+	t.Run("prune removes repos no longer in any group", func(t *testing.T) {
+		runner, outBuf, cacheDir := newRunner(t, "")
+		if err := runner.executeCachePrune(context.Background(), &rootOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(outBuf.String(), "Removed 2 cached repositories") {
+			t.Errorf("Expected both entries to be pruned, got %q", outBuf.String())
+		}
+		if n := remaining(t, cacheDir); n != 0 {
+			t.Errorf("Expected an empty cache, %d entries left", n)
+		}
+	})
+
+	t.Run("clear one repo by name", func(t *testing.T) {
+		runner, outBuf, cacheDir := newRunner(t, "")
+		if err := runner.executeCacheClear(context.Background(), []string{"old"}, &rootOptions{}, &cacheOptions{force: true}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(outBuf.String(), "  - old: https://github.com/plars/old") {
+			t.Errorf("Expected the removed entry to be listed, got %q", outBuf.String())
+		}
+		if n := remaining(t, cacheDir); n != 1 {
+			t.Errorf("Expected 1 entry left, got %d", n)
+		}
+	})
+
+	t.Run("clear one repo by URL and branch", func(t *testing.T) {
+		runner, _, cacheDir := newRunner(t, "")
+		if err := runner.executeCacheClear(context.Background(), []string{"https://github.com/plars/repomon#main"}, &rootOptions{}, &cacheOptions{force: true}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if n := remaining(t, cacheDir); n != 1 {
+			t.Errorf("Expected 1 entry left, got %d", n)
+		}
+	})
+
+	t.Run("clear unknown repo", func(t *testing.T) {
+		runner, _, cacheDir := newRunner(t, "")
+		err := runner.executeCacheClear(context.Background(), []string{"missing"}, &rootOptions{}, &cacheOptions{force: true})
+		if err == nil || !strings.Contains(err.Error(), "no cached repository matches 'missing'") {
+			t.Errorf("Expected a no match error, got %v", err)
+		}
+		if n := remaining(t, cacheDir); n != 2 {
+			t.Errorf("Expected 2 entries left, got %d", n)
+		}
+	})
+
+	t.Run("ack", func(t *testing.T) {
+		runner, outBuf, cacheDir := newRunner(t, "")
+		meta := `{"url": "https://github.com/plars/repomon", "branch": "main", "rewrite": {"old_tip": "a1", "new_tip": "b1", "detected_at": "2024-10-16T15:30:00Z"}}`
+		metaPath := filepath.Join(cacheDir, "repomon-0123456789abcdef", "repomon.json")
+		if err := os.WriteFile(metaPath, []byte(meta), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := runner.executeCacheAck(context.Background(), nil, &rootOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := "Acknowledged the history rewrites of 1 cached repositories:\n  - repomon#main: https://github.com/plars/repomon\n"
+		if outBuf.String() != want {
+			t.Errorf("Expected %q, got %q", want, outBuf.String())
+		}
+		data, err := os.ReadFile(metaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "rewrite") {
+			t.Errorf("Expected the rewrite to be cleared, got %s", data)
+		}
+
+		outBuf.Reset()
+		if err := runner.executeCacheAck(context.Background(), []string{"repomon#main"}, &rootOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(outBuf.String(), "No history rewrites to acknowledge") {
+			t.Errorf("Expected nothing to acknowledge, got %q", outBuf.String())
+		}
+	})
+
+	t.Run("clear all confirmed", func(t *testing.T) {
+		runner, _, cacheDir := newRunner(t, "y\n")
+		if err := runner.executeCacheClear(context.Background(), nil, &rootOptions{}, &cacheOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if n := remaining(t, cacheDir); n != 0 {
+			t.Errorf("Expected an empty cache, %d entries left", n)
+		}
+	})
+
+	t.Run("clear all leaves directories repomon did not create", func(t *testing.T) {
+		runner, outBuf, cacheDir := newRunner(t, "")
+		if err := os.MkdirAll(filepath.Join(cacheDir, "notes"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := runner.executeCacheClear(context.Background(), nil, &rootOptions{}, &cacheOptions{force: true}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, want := range []string{
+			"Leaving 1 directories repomon did not create; name one to remove it:\n  - notes: notes\n",
+			"Removed 2 cached repositories",
+		} {
+			if !strings.Contains(outBuf.String(), want) {
+				t.Errorf("Expected output containing %q, got %q", want, outBuf.String())
+			}
+		}
+		if n := remaining(t, cacheDir); n != 1 {
+			t.Fatalf("Expected the foreign directory to be left, %d entries left", n)
+		}
+
+		// Named, it is removed
+		if err := runner.executeCacheClear(context.Background(), []string{"notes"}, &rootOptions{}, &cacheOptions{force: true}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if n := remaining(t, cacheDir); n != 0 {
+			t.Errorf("Expected an empty cache, %d entries left", n)
+		}
+	})
+
+	t.Run("clear all cancelled", func(t *testing.T) {
+		runner, outBuf, cacheDir := newRunner(t, "n\n")
+		if err := runner.executeCacheClear(context.Background(), nil, &rootOptions{}, &cacheOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(outBuf.String(), "Cancelled.") {
+			t.Errorf("Expected cancellation, got %q", outBuf.String())
+		}
+		if n := remaining(t, cacheDir); n != 2 {
+			t.Errorf("Expected 2 entries left, got %d", n)
+		}
+	})
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		0:                      "0 B",
+		1023:                   "1023 B",
+		1536:                   "1.5 KB",
+		5 * 1024 * 1024:        "5.0 MB",
+		3 * 1024 * 1024 * 1024: "3.0 GB",
+	}
+	for size, want := range tests {
+		if got := formatSize(size); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestExecuteRunFormatterError(t *testing.T) {
+	outBuf := new(bytes.Buffer)
+	errBuf := new(bytes.Buffer)
+	runner := newDefaultRunner(outBuf, errBuf, nil)
+
+	runner.loadConfig = func(path string) (*config.Config, error) {
+		return &config.Config{
+			Days: 1,
+			Groups: map[string]*config.Group{
+				"default": {Repos: []string{"/path/to/repo"}},
+			},
+		}, nil
+	}
+	runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+		return &mockGitMonitor{results: []git.RepoResult{
+			{Repo: config.Repo{Name: "repo", Path: "/path/to/repo"}},
+		}, err: nil}
+	}
+	runner.newFormatter = func(opts report.Options) ReportFormatter {
+		return &mockFormatter{output: "", err: fmt.Errorf("formatting failed")}
+	}
+
+	err := runner.executeRun(context.Background(), nil, &runOptions{days: 1}, &rootOptions{group: "default"})
+
+	if err == nil || !strings.Contains(err.Error(), "failed to format report") {
+		t.Errorf("Expected formatter error, got %v", err)
 	}
 }
 
@@ -932,3 +1804,628 @@ default:
 		t.Errorf("Expected commit message, got: %s", outBuf.String())
 	}
 }
+
+func TestExecuteRun_Timeouts(t *testing.T) {
+	retries := 5
+	zero := 0
+	negative := -1
+	tests := []struct {
+		name          string
+		clone         *config.CloneConfig
+		wantTimeout   time.Duration
+		wantRetries   int
+		expectedError string
+	}{
+		{name: "default", wantTimeout: git.DefaultRepoTimeout, wantRetries: git.DefaultRetries},
+		{name: "config", clone: &config.CloneConfig{Timeout: "1m", Retries: &retries}, wantTimeout: time.Minute, wantRetries: 5},
+		{name: "timeout in days", clone: &config.CloneConfig{Timeout: "1d"}, wantTimeout: 24 * time.Hour, wantRetries: git.DefaultRetries},
+		{name: "invalid timeout", clone: &config.CloneConfig{Timeout: "-1m"}, expectedError: "invalid clone timeout"},
+		{name: "retries disabled", clone: &config.CloneConfig{Retries: &zero}, wantTimeout: git.DefaultRepoTimeout, wantRetries: 0},
+		{name: "negative retries", clone: &config.CloneConfig{Retries: &negative}, expectedError: "must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days:  1,
+					Clone: tt.clone,
+					Groups: map[string]*config.Group{
+						"default": {Repos: []string{"https://github.com/plars/repomon"}},
+					},
+				}, nil
+			}
+			mock := &mockGitMonitor{}
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				return mock
+			}
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
+				return &mockFormatter{}
+			}
+
+			err := runner.executeRun(context.Background(), nil, &runOptions{days: 1}, &rootOptions{group: "default"})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if mock.timeout != tt.wantTimeout || mock.retries != tt.wantRetries {
+				t.Errorf("Expected timeout %v and %d retries, got %v and %d", tt.wantTimeout, tt.wantRetries, mock.timeout, mock.retries)
+			}
+		})
+	}
+}
+
+func TestExecuteRun_Status(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     bool
+		flag       bool
+		wantStatus bool
+	}{
+		{name: "off"},
+		{name: "config", config: true, wantStatus: true},
+		{name: "flag", flag: true, wantStatus: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days:   1,
+					Status: tt.config,
+					Groups: map[string]*config.Group{
+						"default": {Repos: []string{"/path/to/repo"}},
+					},
+				}, nil
+			}
+			mock := &mockGitMonitor{}
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				return mock
+			}
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
+				return &mockFormatter{}
+			}
+
+			if err := runner.executeRun(context.Background(), nil, &runOptions{days: 1, status: tt.flag}, &rootOptions{group: "default"}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if mock.status != tt.wantStatus {
+				t.Errorf("Expected status %v, got %v", tt.wantStatus, mock.status)
+			}
+		})
+	}
+}
+
+func TestExecuteRun_Fetch(t *testing.T) {
+	for _, fetch := range []bool{false, true} {
+		t.Run(fmt.Sprintf("fetch=%v", fetch), func(t *testing.T) {
+			runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days: 1,
+					Groups: map[string]*config.Group{
+						"default": {Repos: []string{"/path/to/repo", "https://github.com/plars/repomon"}},
+					},
+				}, nil
+			}
+			var got []config.Repo
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				got = repos
+				return &mockGitMonitor{}
+			}
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
+				return &mockFormatter{}
+			}
+
+			if err := runner.executeRun(context.Background(), nil, &runOptions{days: 1, fetch: fetch}, &rootOptions{group: "default"}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			// Only local repositories are fetched: remote ones are cloned or fetched anyway
+			if len(got) != 2 || got[0].Fetch != fetch || got[1].Fetch {
+				t.Errorf("Expected fetch %v for the local repository only, got %+v", fetch, got)
+			}
+		})
+	}
+}
+
+func TestExecuteRun_FailOn(t *testing.T) {
+	now := time.Now()
+	ok := git.RepoResult{Repo: config.Repo{Name: "lib"}, Commits: []git.Commit{{Hash: "a", Timestamp: now}}, LastCommit: now}
+	stale := git.RepoResult{Repo: config.Repo{Name: "lib"}, LastCommit: now.AddDate(0, 0, -30)}
+	failed := git.RepoResult{Repo: config.Repo{Name: "tool"}, Error: errors.New("boom")}
+	cancelled := git.RepoResult{Repo: config.Repo{Name: "tool"}, Error: &git.RepoError{Kind: git.KindCancelled, Err: context.Canceled}}
+
+	tests := []struct {
+		name        string
+		results     []git.RepoResult
+		expect      *config.Expect
+		failOn      string
+		deadline    string
+		interrupted bool
+		wantCode    int
+		wantErr     string
+	}{
+		{name: "all good", results: []git.RepoResult{ok, ok}},
+		{name: "some failed", results: []git.RepoResult{ok, failed}, wantCode: exitSomeFailed},
+		{name: "all failed", results: []git.RepoResult{failed, failed}, wantCode: exitAllFailed},
+		{name: "failures ignored", results: []git.RepoResult{failed, failed}, failOn: failOnNone},
+		{name: "inactive not failing by default", results: []git.RepoResult{stale, ok}, expect: &config.Expect{MaxAge: "14d"}},
+		{name: "inactive", results: []git.RepoResult{stale, ok}, expect: &config.Expect{MaxAge: "14d"}, failOn: failOnInactive, wantCode: exitInactive},
+		{name: "errors before inactivity", results: []git.RepoResult{stale, failed}, expect: &config.Expect{MinCommits: 1}, failOn: failOnInactive, wantCode: exitSomeFailed},
+		{name: "invalid max_age", results: []git.RepoResult{ok, ok}, expect: &config.Expect{MaxAge: "soon"}, wantCode: exitFailure, wantErr: "invalid expect max_age"},
+		{name: "invalid fail-on", results: []git.RepoResult{ok, ok}, failOn: "sometimes", wantCode: exitFailure, wantErr: "invalid --fail-on"},
+		{name: "interrupted", results: []git.RepoResult{ok, cancelled}, failOn: failOnNone, interrupted: true, wantCode: exitCancelled, wantErr: "1 of 2 repositories"},
+		{name: "cancelled before failures", results: []git.RepoResult{failed, cancelled}, wantCode: exitCancelled},
+		{name: "interrupted once every repository was done", results: []git.RepoResult{ok, ok}, interrupted: true},
+		{name: "interrupted once every repository was done, with failures", results: []git.RepoResult{ok, failed}, interrupted: true, wantCode: exitSomeFailed},
+		{name: "deadline", results: []git.RepoResult{ok, ok}, deadline: "10m"},
+		{name: "invalid deadline", results: []git.RepoResult{ok, ok}, deadline: "0s", wantCode: exitFailure, wantErr: "invalid --deadline"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days: 1,
+					Groups: map[string]*config.Group{
+						"default": {
+							Repos:  []string{"https://github.com/example/lib", "https://github.com/example/tool"},
+							Expect: tt.expect,
+						},
+					},
+				}, nil
+			}
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				return &mockGitMonitor{results: tt.results}
+			}
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
+				return &mockFormatter{}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.interrupted {
+				cancel()
+			}
+			err := runner.executeRun(ctx, nil, &runOptions{days: 1, failOn: tt.failOn, deadline: tt.deadline}, &rootOptions{group: "default"})
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected exit code %d, got no error", tt.wantCode)
+			}
+			if code := exitCode(err); code != tt.wantCode {
+				t.Errorf("Expected exit code %d, got %d (%v)", tt.wantCode, code, err)
+			}
+			if tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExecuteRun_Streaming(t *testing.T) {
+	now := time.Now()
+	results := []git.RepoResult{
+		{Repo: config.Repo{Name: "alpha"}, Commits: []git.Commit{{Hash: "a1", Message: "Add alpha", Author: "Alice", Timestamp: now}}},
+		{Repo: config.Repo{Name: "beta"}},
+		{Repo: config.Repo{Name: "gamma"}, Commits: []git.Commit{{Hash: "c1", Message: "Fix gamma", Author: "Bob", Timestamp: now}}},
+	}
+
+	for _, format := range []string{report.FormatText, report.FormatJSONLines} {
+		t.Run(format, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			runner := newDefaultRunner(out, new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days: 1,
+					Groups: map[string]*config.Group{
+						"default": {Repos: []string{"/path/to/alpha", "/path/to/beta", "/path/to/gamma"}},
+					},
+				}, nil
+			}
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				return &mockGitMonitor{results: results}
+			}
+
+			if err := runner.executeRun(context.Background(), nil, &runOptions{days: 1, format: format}, &rootOptions{group: "default"}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// The mock finishes the repositories last to first. The text report keeps their
+			// order, while JSON lines are written as they finish, with their index.
+			want, err := runner.newFormatter(report.Options{Format: format}).Format(results)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format == report.FormatJSONLines {
+				lines := strings.SplitAfter(want, "\n")
+				slices.Reverse(lines)
+				want = strings.Join(lines, "")
+			}
+			if out.String() != want {
+				t.Errorf("Expected the streamed report to match the batch one.\nGot:\n%s\nWant:\n%s", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestExecuteRun_Progress(t *testing.T) {
+	tests := []struct {
+		mode          string
+		wantType      string
+		expectedError string
+	}{
+		{mode: "", wantType: "git.NoProgress"}, // the test's stderr is not a terminal
+		{mode: "auto", wantType: "git.NoProgress"},
+		{mode: "bar", wantType: "*git.BarProgress"},
+		{mode: "none", wantType: "git.NoProgress"},
+		{mode: "json", wantType: "*git.EventProgress"},
+		{mode: "fancy", expectedError: "invalid --progress"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days: 1,
+					Groups: map[string]*config.Group{
+						"default": {Repos: []string{"/path/to/repo"}},
+					},
+				}, nil
+			}
+			mock := &mockGitMonitor{}
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				return mock
+			}
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
+				return &mockFormatter{}
+			}
+
+			err := runner.executeRun(context.Background(), nil, &runOptions{days: 1, progress: tt.mode}, &rootOptions{group: "default"})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := fmt.Sprintf("%T", mock.progress); got != tt.wantType {
+				t.Errorf("Expected %s, got %s", tt.wantType, got)
+			}
+		})
+	}
+}
+
+func TestExecuteRun_ProgressJSONLogs(t *testing.T) {
+	errBuf := new(bytes.Buffer)
+	runner := newDefaultRunner(new(bytes.Buffer), errBuf, nil)
+	runner.loadConfig = func(path string) (*config.Config, error) {
+		return &config.Config{
+			Days: 1,
+			Groups: map[string]*config.Group{
+				"default": {Repos: []string{"/path/to/repo"}},
+			},
+		}, nil
+	}
+	previous := slog.Default()
+
+	err := runner.executeRun(context.Background(), nil, &runOptions{days: 1, progress: "json", failOn: "sometimes"}, &rootOptions{group: "default"})
+	if err == nil {
+		t.Fatal("Expected an invalid --fail-on error")
+	}
+	if slog.Default() != previous {
+		t.Error("Expected the default logger to be restored")
+	}
+
+	// The failure is logged as an event, and nothing else is written
+	lines := strings.Split(strings.TrimSpace(errBuf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected a single log event, got %q", errBuf.String())
+	}
+	var event map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &event); err != nil {
+		t.Fatalf("Expected a JSON event, got %q: %v", lines[0], err)
+	}
+	if event["event"] != git.EventLog || event["level"] != "ERROR" || event["message"] != "Run command failed" {
+		t.Errorf("Unexpected event: %v", event)
+	}
+}
+
+// initCommittedRepo creates a git repository with a single commit at path
+func initCommittedRepo(t *testing.T, path string) {
+	t.Helper()
+	for _, args := range [][]string{
+		{"init", "-q", path},
+		{"-C", path, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "first"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+}
+
+func TestExecuteRun_DebugLogs(t *testing.T) {
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	initCommittedRepo(t, repoPath)
+
+	for _, debug := range []bool{false, true} {
+		t.Run(fmt.Sprintf("debug=%v", debug), func(t *testing.T) {
+			errBuf := new(bytes.Buffer)
+			runner := newDefaultRunner(new(bytes.Buffer), errBuf, nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days:   1,
+					Groups: map[string]*config.Group{"default": {Repos: []string{repoPath}}},
+				}, nil
+			}
+			previous := slog.Default()
+
+			if err := runner.executeRun(context.Background(), nil, &runOptions{days: 1, debug: debug}, &rootOptions{group: "default"}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if slog.Default() != previous {
+				t.Error("Expected the default logger to be restored")
+			}
+			// The monitor's own records, with the attempts and duration of each repository
+			logged := strings.Contains(errBuf.String(), `msg="Retrieved commits for repository" repo=repo`) &&
+				strings.Contains(errBuf.String(), "attempts=") && strings.Contains(errBuf.String(), "duration=")
+			if logged != debug {
+				t.Errorf("Expected the repository to be logged=%v, got %q", debug, errBuf.String())
+			}
+		})
+	}
+}
+
+func TestExecuteRun_DebugLogsEviction(t *testing.T) {
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	initCommittedRepo(t, repoPath)
+	cacheDir := t.TempDir()
+	writeCacheEntry(t, cacheDir, "old-0123456789abcdef", "https://github.com/plars/old", "")
+
+	errBuf := new(bytes.Buffer)
+	runner := newDefaultRunner(new(bytes.Buffer), errBuf, nil)
+	runner.loadConfig = func(path string) (*config.Config, error) {
+		return &config.Config{
+			Days:   1,
+			Cache:  &config.CacheConfig{Enabled: true, Dir: cacheDir, MaxAge: "30d"},
+			Groups: map[string]*config.Group{"default": {Repos: []string{repoPath}}},
+		}, nil
+	}
+
+	if err := runner.executeRun(context.Background(), nil, &runOptions{days: 1, debug: true}, &rootOptions{group: "default"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		`msg="Evicting cache entry" name=old-0123456789abcdef url=https://github.com/plars/old reason="unused for longer than max_age"`,
+		`msg="Evicted cache entries" count=1`,
+	} {
+		if !strings.Contains(errBuf.String(), want) {
+			t.Errorf("Expected the debug log to contain %q, got %q", want, errBuf.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "old-0123456789abcdef")); !os.IsNotExist(err) {
+		t.Errorf("Expected the stale entry to be evicted, got %v", err)
+	}
+}
+
+func TestExecuteBranches(t *testing.T) {
+	lastCommit := time.Now().AddDate(0, -6, 0)
+	results := []git.RepoResult{
+		{
+			Repo:          config.Repo{Name: "repomon", URL: "https://github.com/plars/repomon"},
+			FetchedAt:     time.Now().Add(-2 * time.Hour),
+			DefaultBranch: "main",
+			Branches: []git.Branch{
+				{Name: "old-feature", Hash: "abc123", LastCommit: lastCommit, LastAuthor: "Alice", Merged: true},
+			},
+		},
+		{Repo: config.Repo{Name: "tool", URL: "https://github.com/plars/tool"}, Error: errors.New("boom")},
+	}
+
+	tests := []struct {
+		name      string
+		opts      branchesOptions
+		wantCode  int
+		wantErr   string
+		wantOut   []string
+		olderThan time.Duration
+		cache     *config.CacheConfig
+	}{
+		{
+			name:      "text",
+			opts:      branchesOptions{olderThan: "30d", format: report.FormatText},
+			wantCode:  exitSomeFailed,
+			wantOut:   []string{"repomon (default branch: main)", "old-feature - Alice", "[merged]", "❌ Error: boom"},
+			olderThan: 30 * 24 * time.Hour,
+		},
+		{
+			name:      "offline",
+			opts:      branchesOptions{olderThan: "30d", format: report.FormatText, offline: true},
+			wantCode:  exitSomeFailed,
+			wantOut:   []string{"repomon (default branch: main)", "Cached data, fetched 2 hours ago"},
+			olderThan: 30 * 24 * time.Hour,
+			cache:     &config.CacheConfig{Enabled: true},
+		},
+		{name: "offline without cache", opts: branchesOptions{olderThan: "30d", format: report.FormatText, offline: true}, wantCode: exitFailure, wantErr: "clone cache"},
+		{
+			name:      "json",
+			opts:      branchesOptions{olderThan: "12w", format: report.FormatJSON},
+			wantCode:  exitSomeFailed,
+			wantOut:   []string{`"default_branch": "main"`, `"name": "old-feature"`, `"merged": true`, `"error": "boom"`},
+			olderThan: 12 * 7 * 24 * time.Hour,
+		},
+		{name: "invalid older-than", opts: branchesOptions{olderThan: "ages", format: report.FormatText}, wantCode: exitFailure, wantErr: "invalid --older-than"},
+		{name: "non-positive older-than", opts: branchesOptions{olderThan: "0d", format: report.FormatText}, wantCode: exitFailure, wantErr: "must be positive"},
+		{name: "invalid format", opts: branchesOptions{olderThan: "30d", format: "xml"}, wantCode: exitFailure, wantErr: "invalid format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			runner := newDefaultRunner(out, new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Cache: tt.cache,
+					Groups: map[string]*config.Group{
+						"default": {Repos: []string{"https://github.com/plars/repomon", "https://github.com/plars/tool"}},
+					},
+				}, nil
+			}
+			mock := &mockGitMonitor{results: results}
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				return mock
+			}
+
+			start := time.Now()
+			err := runner.executeBranches(context.Background(), &tt.opts, &rootOptions{})
+			if code := exitCode(err); err != nil && code != tt.wantCode || err == nil && tt.wantCode != 0 {
+				t.Fatalf("Expected exit code %d, got %v", tt.wantCode, err)
+			}
+			if tt.wantErr != "" {
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+				}
+			}
+			if mock.offline != tt.opts.offline {
+				t.Errorf("Expected offline %v, got %v", tt.opts.offline, mock.offline)
+			}
+			if cutoff := start.Add(-tt.olderThan); mock.olderThan.Sub(cutoff).Abs() > time.Minute {
+				t.Errorf("Expected branches older than %s, got %s", cutoff, mock.olderThan)
+			}
+		})
+	}
+}
+
+func TestExecuteRun_DormantAfter(t *testing.T) {
+	now := time.Now()
+	results := []git.RepoResult{
+		{Repo: config.Repo{Name: "active"}, LastCommit: now.AddDate(0, 0, -10)},
+		{Repo: config.Repo{Name: "abandoned"}, LastCommit: now.AddDate(-1, 0, 0)},
+		{Repo: config.Repo{Name: "empty"}},
+	}
+
+	tests := []struct {
+		name        string
+		configured  string
+		flag        string
+		wantDormant []bool
+		wantUnknown bool
+		wantErr     string
+	}{
+		{name: "not configured", wantDormant: []bool{false, false, false}},
+		{name: "configured", configured: "90d", wantDormant: []bool{false, true, false}, wantUnknown: true},
+		{name: "flag overrides config", configured: "2y", flag: "7d", wantDormant: []bool{true, true, false}, wantUnknown: true},
+		{name: "invalid", flag: "forever", wantErr: "invalid dormant_after"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
+			runner.loadConfig = func(path string) (*config.Config, error) {
+				return &config.Config{
+					Days:         1,
+					DormantAfter: tt.configured,
+					Groups: map[string]*config.Group{
+						"default": {Repos: []string{"/path/to/active", "/path/to/abandoned", "/path/to/empty"}},
+					},
+				}, nil
+			}
+			runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+				return &mockGitMonitor{results: results}
+			}
+			formatter := &mockFormatter{}
+			runner.newFormatter = func(opts report.Options) ReportFormatter {
+				return formatter
+			}
+
+			err := runner.executeRun(context.Background(), nil, &runOptions{days: 1, dormantAfter: tt.flag}, &rootOptions{group: "default"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for i, want := range tt.wantDormant {
+				if got := formatter.results[i].Dormant; got != want {
+					t.Errorf("Expected %s dormant=%v, got %v", results[i].Repo.Name, want, got)
+				}
+			}
+			// The idle time is taken when checked, for the report not to age it further
+			if abandoned := formatter.results[1]; abandoned.Dormant && abandoned.Idle < 364*24*time.Hour {
+				t.Errorf("Expected abandoned to be idle for a year, got %v", abandoned.Idle)
+			}
+			if active := formatter.results[0]; tt.wantUnknown && (active.Idle < 10*24*time.Hour || active.Idle > 11*24*time.Hour) {
+				t.Errorf("Expected active to be idle for 10 days, got %v", active.Idle)
+			}
+			if got := formatter.results[2].DormancyUnknown; got != tt.wantUnknown {
+				t.Errorf("Expected empty dormancy unknown=%v, got %v", tt.wantUnknown, got)
+			}
+		})
+	}
+}
+
+func TestExecuteRun_Author(t *testing.T) {
+	now := time.Now()
+	results := []git.RepoResult{{
+		Repo: config.Repo{Name: "repo"},
+		Commits: []git.Commit{
+			{Hash: "a", Author: "Alice", Timestamp: now},
+			{Hash: "b", Author: "Bob", CoAuthors: []string{"Alice"}, Timestamp: now},
+			{Hash: "c", Author: "Carol", Timestamp: now},
+		},
+		LastCommit: now,
+	}}
+
+	runner := newDefaultRunner(new(bytes.Buffer), new(bytes.Buffer), nil)
+	runner.loadConfig = func(path string) (*config.Config, error) {
+		return &config.Config{
+			Days: 1,
+			Groups: map[string]*config.Group{
+				"default": {Repos: []string{"/path/to/repo"}, Expect: &config.Expect{MinCommits: 3}},
+			},
+		}, nil
+	}
+	runner.newGitMonitor = func(repos []config.Repo, cacheEnabled bool, cacheDir string) GitMonitor {
+		return &mockGitMonitor{results: results}
+	}
+	formatter := &mockFormatter{}
+	runner.newFormatter = func(opts report.Options) ReportFormatter {
+		return formatter
+	}
+
+	if err := runner.executeRun(context.Background(), nil, &runOptions{days: 1, authors: []string{"alice"}}, &rootOptions{group: "default"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := formatter.results[0]
+	if len(got.Commits) != 2 || got.Commits[0].Hash != "a" || got.Commits[1].Hash != "b" {
+		t.Errorf("Expected the commits authored or co-authored by Alice, got %+v", got.Commits)
+	}
+	// Expectations are checked against every commit, not only the shown ones
+	if len(got.Violations) != 0 {
+		t.Errorf("Expected the expectation to be met by all commits, got %v", got.Violations)
+	}
+}
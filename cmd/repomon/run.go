@@ -4,23 +4,124 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"time"
+
+	"github.com/plars/repomon/internal/config"
+	"github.com/plars/repomon/internal/git"
+	"github.com/plars/repomon/internal/report"
+	"github.com/plars/repomon/internal/timespec"
 )
 
 // runOptions holds the flags specific to the run command.
 type runOptions struct {
 	days              int
 	daysExplicitlySet bool
+	since             string
+	until             string
 	debug             bool
 	noCache           bool
+	offline           bool
+	jobs              int
+	layout            string
+	stat              bool
+	status            bool
+	fetch             bool
+	long              bool
+	format            string
+	verifySignatures  bool
+	dateField         string
+	failOn            string
+	progress          string
+	deadline          string
+	dormantAfter      string
+	authors           []string
+}
+
+// Values of --progress
+const (
+	// progressAuto draws a progress bar when stderr is a terminal, and reports nothing otherwise
+	progressAuto = "auto"
+	progressBar  = "bar"
+	progressNone = "none"
+	// progressJSON writes JSON lines events to stderr, for programs that wrap repomon.
+	// Log records are written as events too.
+	progressJSON = "json"
+)
+
+// Values of --fail-on
+const (
+	// failOnErrors fails the run when any repository could not be read
+	failOnErrors = "errors"
+	// failOnInactive also fails it when a repository does not meet its expectations
+	failOnInactive = "inactive"
+	// failOnNone never fails the run because of its results
+	failOnNone = "none"
+)
+
+// Exit codes of the run command, checked in this order when several apply
+const (
+	// exitFailure means repomon itself failed, e.g. on an invalid configuration
+	exitFailure = 1
+	// exitSomeFailed means some repositories could not be read
+	exitSomeFailed = 2
+	// exitAllFailed means no repository could be read
+	exitAllFailed = 3
+	// exitInactive means a repository did not meet its expectations
+	exitInactive = 4
+	// exitCancelled means the run was interrupted or hit its deadline, so the report is partial
+	exitCancelled = 5
+)
+
+// exitCodeError is returned when the report was written but its results should fail
+// the run with the given exit code
+type exitCodeError struct {
+	code int
+	msg  string
+}
+
+func (e *exitCodeError) Error() string {
+	return e.msg
+}
+
+// exitCode returns the exit code for an error returned by a command
+func exitCode(err error) int {
+	var exitErr *exitCodeError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return exitFailure
 }
 
 // executeRun contains the core logic for the default run command.
-func (r *repomonRunner) executeRun(ctx context.Context, args []string, runOpts *runOptions, rootOpts *rootOptions) error {
+func (r *repomonRunner) executeRun(ctx context.Context, args []string, runOpts *runOptions, rootOpts *rootOptions) (err error) {
 	// Set up a logger that writes to errorWriter for this function's scope.
 	logger := slog.New(slog.NewTextHandler(r.err, nil))
 
+	progress, err := r.progressReporter(runOpts.progress)
+	if err != nil {
+		return err
+	}
+	events, jsonEvents := progress.(*git.EventProgress)
+	if jsonEvents {
+		// Log records become events too, so that stderr holds nothing but JSON lines
+		level := slog.LevelInfo
+		if runOpts.debug {
+			level = slog.LevelDebug
+		}
+		logger = slog.New(events.LogHandler(level))
+		previous := slog.Default()
+		slog.SetDefault(logger)
+		defer func() {
+			if err != nil {
+				logger.Error("Run command failed", "error", err)
+			}
+			slog.SetDefault(previous)
+		}()
+	}
+
 	cfg, err := r.loadConfig(rootOpts.configFile)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -30,14 +131,106 @@ func (r *repomonRunner) executeRun(ctx context.Context, args []string, runOpts *
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	if runOpts.daysExplicitlySet && runOpts.since != "" {
+		return fmt.Errorf("--days and --since cannot be used together")
+	}
+
+	// Any window flag overrides the whole window from the config
+	since, until := cfg.Since, cfg.Until
+	if runOpts.daysExplicitlySet || runOpts.since != "" || runOpts.until != "" {
+		since, until = runOpts.since, runOpts.until
+	}
+
 	if runOpts.daysExplicitlySet {
 		cfg.Days = runOpts.days
 	} else if cfg.Days == 0 {
 		cfg.Days = 1
 	}
 
-	if runOpts.debug {
+	now := time.Now()
+	var sinceTime, untilTime time.Time
+	if since != "" {
+		if sinceTime, err = timespec.Parse(since, now); err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+	if until != "" {
+		if untilTime, err = timespec.ParseEnd(until, now); err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+		start := sinceTime
+		if start.IsZero() {
+			start = now.AddDate(0, 0, -cfg.Days)
+		}
+		if !untilTime.After(start) {
+			return fmt.Errorf("invalid window: --until %q is not after the start of the window", until)
+		}
+	}
+
+	if runOpts.layout != "" && !report.ValidLayout(runOpts.layout) {
+		return fmt.Errorf("invalid layout %q: must be 'list' or 'conventional'", runOpts.layout)
+	}
+	if runOpts.format != "" && !report.ValidFormat(runOpts.format) {
+		return fmt.Errorf("invalid format %q: must be 'text', 'json' or 'jsonl'", runOpts.format)
+	}
+	if runOpts.deadline != "" {
+		deadline, err := timespec.ParseDuration(runOpts.deadline)
+		if err != nil {
+			return fmt.Errorf("invalid --deadline: %w", err)
+		}
+		if deadline <= 0 {
+			return fmt.Errorf("invalid --deadline %q: must be positive", runOpts.deadline)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, deadline)
+		defer cancel()
+	}
+	failOn := runOpts.failOn
+	if failOn == "" {
+		failOn = failOnErrors
+	}
+	if failOn != failOnErrors && failOn != failOnInactive && failOn != failOnNone {
+		return fmt.Errorf("invalid --fail-on %q: must be 'errors', 'inactive' or 'none'", failOn)
+	}
+
+	// CLI flag overrides config
+	dateField := cfg.DateField
+	if runOpts.dateField != "" {
+		dateField = runOpts.dateField
+	}
+	if dateField == "" {
+		dateField = string(git.DateAuthor)
+	}
+	if !git.ValidDateField(dateField) {
+		return fmt.Errorf("invalid date field %q: must be 'author' or 'committer'", dateField)
+	}
+
+	// CLI flag overrides config
+	dormantSpec := cfg.DormantAfter
+	if runOpts.dormantAfter != "" {
+		dormantSpec = runOpts.dormantAfter
+	}
+	var dormantAfter time.Duration
+	if dormantSpec != "" {
+		if dormantAfter, err = timespec.ParseDuration(dormantSpec); err != nil {
+			return fmt.Errorf("invalid dormant_after: %w", err)
+		}
+		if dormantAfter <= 0 {
+			return fmt.Errorf("invalid dormant_after %q: must be positive", dormantSpec)
+		}
+	}
+
+	settings, err := loadMonitorSettings(cfg, runOpts.jobs)
+	if err != nil {
+		return err
+	}
+
+	if runOpts.debug && !jsonEvents {
+		// The monitor logs through the default logger, so it must log debug records too
 		logger = slog.New(slog.NewTextHandler(r.err, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		previous := slog.Default()
+		slog.SetDefault(logger)
+		defer slog.SetDefault(previous)
 	}
 
 	requestedGroupName := rootOpts.group
@@ -45,31 +238,108 @@ func (r *repomonRunner) executeRun(ctx context.Context, args []string, runOpts *
 		requestedGroupName = "default"
 	}
 
-	repos, _, err := cfg.GetRepos(requestedGroupName)
+	repos, groupName, err := cfg.GetRepos(requestedGroupName)
 	if err != nil {
 		logger.Error("Failed to get repositories", "error", err)
 		return fmt.Errorf("failed to get repositories: %w", err)
 	}
-
-	cacheEnabled := cfg.Cache != nil && cfg.Cache.Enabled
-	cacheDir := ""
-	if cfg.Cache != nil && cfg.Cache.Dir != "" {
-		cacheDir = cfg.Cache.Dir
+	if runOpts.fetch {
+		for i := range repos {
+			repos[i].Fetch = repos[i].Path != ""
+		}
 	}
-	// CLI flag overrides config
-	if runOpts.noCache {
-		cacheEnabled = false
+	expectations, err := repoExpectations(cfg, groupName, repos)
+	if err != nil {
+		return err
 	}
 
-	monitor := r.newGitMonitor(repos, cacheEnabled, cacheDir)
+	monitor, err := r.newConfiguredMonitor(cfg, settings, repos, runOpts.noCache, runOpts.offline, logger)
+	if err != nil {
+		return err
+	}
 	monitor.SetDays(cfg.Days)
+	if !sinceTime.IsZero() || !untilTime.IsZero() {
+		monitor.SetWindow(sinceTime, untilTime)
+	}
+	monitor.SetProgress(progress)
+	monitor.SetStat(runOpts.stat)
+	monitor.SetStatus(runOpts.status || cfg.Status)
+	monitor.SetDateField(git.DateField(dateField))
+	if cfg.ClockSkew != nil {
+		if *cfg.ClockSkew < 0 {
+			return fmt.Errorf("invalid clock_skew %v: must not be negative", *cfg.ClockSkew)
+		}
+		monitor.SetClockSkew(*cfg.ClockSkew)
+	}
+
+	if runOpts.verifySignatures || (cfg.Signatures != nil && cfg.Signatures.Verify) {
+		keyring := &git.Keyring{}
+		if cfg.Signatures != nil {
+			keyring, err = git.LoadKeyring(cfg.Signatures.Keyring, cfg.Signatures.AllowedSigners)
+			if err != nil {
+				logger.Error("Failed to load signing keys", "error", err)
+				return fmt.Errorf("failed to load signing keys: %w", err)
+			}
+		}
+		monitor.SetKeyring(keyring)
+	}
+
+	// Results are checked against their expectations, and for dormancy, as they arrive
+	check := func(index int, result git.RepoResult) git.RepoResult {
+		if index < len(expectations) && expectations[index] != nil {
+			result.Violations = expectations[index].Check(result, now)
+		}
+		result.Dormant = git.Dormant(result, now, dormantAfter)
+		result.DormancyUnknown = git.DormancyUnknown(result, dormantAfter)
+		if dormantAfter > 0 && !result.DormancyUnknown {
+			result.Idle = now.Sub(result.LastCommit)
+		}
+		// Expectations are about the repository, so only the report is filtered
+		result.Commits = git.FilterByAuthor(result.Commits, runOpts.authors)
+		result.Incoming = git.FilterByAuthor(result.Incoming, runOpts.authors)
+		return result
+	}
+
+	reporter := r.newFormatter(report.Options{
+		Format: runOpts.format,
+		Layout: runOpts.layout,
+		Long:   runOpts.long,
+	})
+
+	// Formatters that can write the report piece by piece do so as results arrive
+	if stream, ok := reporter.(report.StreamFormatter); ok {
+		writer := report.NewStreamWriter(r.output, stream, len(repos))
+		if err := writer.Begin(); err != nil {
+			return err
+		}
+		var writeErr error
+		err := monitor.StreamRecentCommits(ctx, func(index int, result git.RepoResult) {
+			if writeErr == nil {
+				writeErr = writer.Add(index, check(index, result))
+			}
+		})
+		if err != nil {
+			logger.Error("Failed to get recent commits", "error", err)
+			return fmt.Errorf("failed to get recent commits: %w", err)
+		}
+		if writeErr != nil {
+			return writeErr
+		}
+		if err := writer.End(); err != nil {
+			return err
+		}
+		return checkResults(ctx, writer.Results(), failOn)
+	}
+
 	results, err := monitor.GetRecentCommits(ctx)
 	if err != nil {
 		logger.Error("Failed to get recent commits", "error", err)
 		return fmt.Errorf("failed to get recent commits: %w", err)
 	}
+	for i := range results {
+		results[i] = check(i, results[i])
+	}
 
-	reporter := r.newFormatter()
 	output, err := reporter.Format(results)
 	if err != nil {
 		logger.Error("Failed to format report", "error", err)
@@ -77,5 +347,240 @@ func (r *repomonRunner) executeRun(ctx context.Context, args []string, runOpts *
 	}
 
 	fmt.Fprint(r.output, output)
+	return checkResults(ctx, results, failOn)
+}
+
+// progressReporter returns the progress reporter selected by --progress, reporting on
+// the runner's error writer
+func (r *repomonRunner) progressReporter(mode string) (git.ProgressReporter, error) {
+	switch mode {
+	case "", progressAuto:
+		if isTerminal(r.err) {
+			return git.NewBarProgress(r.err), nil
+		}
+		return git.NoProgress{}, nil
+	case progressBar:
+		return git.NewBarProgress(r.err), nil
+	case progressNone:
+		return git.NoProgress{}, nil
+	case progressJSON:
+		return git.NewEventProgress(r.err), nil
+	}
+	return nil, fmt.Errorf("invalid --progress %q: must be 'auto', 'bar', 'none' or 'json'", mode)
+}
+
+// isTerminal reports whether w is a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// monitorSettings are the clone, concurrency and cache settings of the config, which
+// every command collecting repositories applies to its monitor
+type monitorSettings struct {
+	clone      config.CloneConfig
+	backend    string
+	timeout    time.Duration
+	retries    int
+	jobs       int
+	hostLimits map[string]int
+	cacheTTL   time.Duration
+	maxSize    int64
+	maxAge     time.Duration
+}
+
+// loadMonitorSettings validates the clone, concurrency and cache settings of cfg.
+// A non-zero jobs, from --jobs, overrides the configured concurrency.
+func loadMonitorSettings(cfg *config.Config, jobsFlag int) (*monitorSettings, error) {
+	var err error
+	var cloneCfg config.CloneConfig
+	if cfg.Clone != nil {
+		cloneCfg = *cfg.Clone
+	}
+	backend := cloneCfg.Backend
+	if backend == "" {
+		backend = git.DefaultBackend()
+	}
+	if !git.ValidBackend(backend) {
+		return nil, fmt.Errorf("invalid clone backend %q: must be 'git' or 'go-git'", backend)
+	}
+	if cloneCfg.Storage != "" && !git.ValidStorage(cloneCfg.Storage) {
+		return nil, fmt.Errorf("invalid clone storage %q: must be 'memory' or 'disk'", cloneCfg.Storage)
+	}
+	timeout := git.DefaultRepoTimeout
+	if cloneCfg.Timeout != "" {
+		if timeout, err = timespec.ParseDuration(cloneCfg.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid clone timeout: %w", err)
+		}
+	}
+	retries := git.DefaultRetries
+	if cloneCfg.Retries != nil {
+		retries = *cloneCfg.Retries
+	}
+	if retries < 0 {
+		return nil, fmt.Errorf("invalid clone retries %d: must not be negative", retries)
+	}
+
+	// CLI flag overrides config
+	jobs := git.DefaultJobs
+	var hostLimits map[string]int
+	if cfg.Concurrency != nil {
+		if cfg.Concurrency.Jobs != 0 {
+			jobs = cfg.Concurrency.Jobs
+		}
+		hostLimits = cfg.Concurrency.Hosts
+	}
+	if jobsFlag != 0 {
+		jobs = jobsFlag
+	}
+	if jobs < 1 {
+		return nil, fmt.Errorf("invalid jobs %d: must be at least 1", jobs)
+	}
+	for host, limit := range hostLimits {
+		if limit < 1 {
+			return nil, fmt.Errorf("invalid concurrency limit %d for host %q: must be at least 1", limit, host)
+		}
+	}
+
+	var maxSize int64
+	var maxAge, cacheTTL time.Duration
+	if cfg.Cache != nil && cfg.Cache.TTL != "" {
+		if cacheTTL, err = timespec.ParseDuration(cfg.Cache.TTL); err != nil {
+			return nil, fmt.Errorf("invalid cache ttl: %w", err)
+		}
+	}
+	if cfg.Cache != nil && cfg.Cache.MaxSize != "" {
+		if maxSize, err = config.ParseSize(cfg.Cache.MaxSize); err != nil {
+			return nil, fmt.Errorf("invalid cache max_size: %w", err)
+		}
+	}
+	if cfg.Cache != nil && cfg.Cache.MaxAge != "" {
+		if maxAge, err = timespec.ParseDuration(cfg.Cache.MaxAge); err != nil {
+			return nil, fmt.Errorf("invalid cache max_age: %w", err)
+		}
+	}
+
+	return &monitorSettings{
+		clone:      cloneCfg,
+		backend:    backend,
+		timeout:    timeout,
+		retries:    retries,
+		jobs:       jobs,
+		hostLimits: hostLimits,
+		cacheTTL:   cacheTTL,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+	}, nil
+}
+
+// newConfiguredMonitor creates a monitor for repos with the cloner, cache, concurrency,
+// timeout and retries of settings. noCache and offline are the --no-cache and
+// --offline flags.
+func (r *repomonRunner) newConfiguredMonitor(cfg *config.Config, settings *monitorSettings, repos []config.Repo, noCache, offline bool, logger *slog.Logger) (GitMonitor, error) {
+	cacheEnabled := cfg.Cache != nil && cfg.Cache.Enabled
+	cacheDir := ""
+	if cfg.Cache != nil && cfg.Cache.Dir != "" {
+		cacheDir = cfg.Cache.Dir
+	}
+	// CLI flag overrides config
+	if noCache {
+		cacheEnabled = false
+	}
+
+	if settings.backend == git.BackendGoGit && cacheEnabled {
+		// The cache is maintained with the git binary
+		logger.Debug("Clone cache is not used with the go-git backend")
+		cacheEnabled = false
+	}
+	if offline && !cacheEnabled {
+		return nil, fmt.Errorf("--offline reports from the clone cache, which is disabled (by --no-cache, the config or the go-git backend)")
+	}
+
+	monitor := r.newGitMonitor(repos, cacheEnabled, cacheDir)
+	if settings.backend == git.BackendGoGit {
+		auth := git.GoGitAuth{
+			SSHKey:        settings.clone.SSHKey,
+			HTTPSUsername: settings.clone.HTTPSUsername,
+		}
+		if settings.clone.HTTPSTokenEnv != "" {
+			auth.HTTPSToken = os.Getenv(settings.clone.HTTPSTokenEnv)
+		}
+		monitor.SetCloner(git.NewGoGitCloner(settings.clone.Storage, auth))
+	}
+	if cacheEnabled && settings.cacheTTL > 0 {
+		monitor.SetCacheTTL(settings.cacheTTL)
+	}
+	if cacheEnabled {
+		monitor.SetCacheLimits(settings.maxSize, settings.maxAge)
+	}
+	monitor.SetOffline(offline)
+	monitor.SetConcurrency(settings.jobs, settings.hostLimits)
+	monitor.SetTimeout(settings.timeout)
+	monitor.SetRetries(settings.retries)
+	return monitor, nil
+}
+
+// repoExpectations parses the expectations configured for each repository, in order
+func repoExpectations(cfg *config.Config, groupName string, repos []config.Repo) ([]*git.Expectation, error) {
+	expectations := make([]*git.Expectation, len(repos))
+	for i, repo := range repos {
+		expect := cfg.Expectation(groupName, repo)
+		if expect == nil {
+			continue
+		}
+		if expect.MinCommits < 0 {
+			return nil, fmt.Errorf("invalid expect min_commits %d for %s: must not be negative", expect.MinCommits, repo.Name)
+		}
+		expectation := &git.Expectation{MinCommits: expect.MinCommits}
+		if expect.MaxAge != "" {
+			maxAge, err := timespec.ParseDuration(expect.MaxAge)
+			if err != nil {
+				return nil, fmt.Errorf("invalid expect max_age for %s: %w", repo.Name, err)
+			}
+			expectation.MaxAge = maxAge
+		}
+		expectations[i] = expectation
+	}
+	return expectations, nil
+}
+
+// checkResults returns an exitCodeError if the run was cancelled before every
+// repository was done, or if the results should fail it. A run cancelled once every
+// repository was done has a complete report, so it is judged on its results.
+func checkResults(ctx context.Context, results []git.RepoResult, failOn string) error {
+	failed, inactive, cancelled := 0, 0, 0
+	for _, result := range results {
+		if git.KindOf(result.Error) == git.KindCancelled {
+			cancelled++
+		} else if result.Error != nil {
+			failed++
+		} else if len(result.Violations) > 0 {
+			inactive++
+		}
+	}
+
+	if cancelled > 0 {
+		reason := "interrupted"
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			reason = "stopped by --deadline"
+		}
+		return &exitCodeError{code: exitCancelled, msg: fmt.Sprintf("run %s: %d of %d repositories were not done", reason, cancelled, len(results))}
+	}
+	if failOn == failOnNone {
+		return nil
+	}
+
+	switch {
+	case failed > 0 && failed == len(results):
+		return &exitCodeError{code: exitAllFailed, msg: fmt.Sprintf("all %d repositories failed", failed)}
+	case failed > 0:
+		return &exitCodeError{code: exitSomeFailed, msg: fmt.Sprintf("%d of %d repositories failed", failed, len(results))}
+	case inactive > 0 && failOn == failOnInactive:
+		return &exitCodeError{code: exitInactive, msg: fmt.Sprintf("%d of %d repositories did not meet their expectations", inactive, len(results))}
+	}
 	return nil
 }
@@ -7,7 +7,10 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -19,18 +22,117 @@ var Version = "dev"
 // Config represents the application configuration
 // Uses flat YAML structure: days at top-level, groups as sections
 type Config struct {
-	Days   int               `yaml:"days"`
-	Cache  *CacheConfig      `yaml:"cache,omitempty"`
-	Groups map[string]*Group `yaml:",inline"`
+	// Days is shorthand for a window starting that many days ago; Since takes precedence
+	Days int `yaml:"days"`
+	// Since and Until bound the window with dates, durations or day names (see --since)
+	Since string `yaml:"since,omitempty"`
+	Until string `yaml:"until,omitempty"`
+	// DateField selects the commit date compared against the window: "author" (default) or "committer"
+	DateField string `yaml:"date_field,omitempty"`
+	// ClockSkew is how far out of order committer dates may be before the history walk
+	// stops; nil uses the default, and 0 stops at the first commit out of order
+	ClockSkew *time.Duration `yaml:"clock_skew,omitempty"`
+	// DormantAfter flags repositories with no commit for this long, e.g. "90d"
+	DormantAfter string `yaml:"dormant_after,omitempty"`
+	// Status reports the working tree status of local repositories (see --status)
+	Status      bool               `yaml:"status,omitempty"`
+	Cache       *CacheConfig       `yaml:"cache,omitempty"`
+	Clone       *CloneConfig       `yaml:"clone,omitempty"`
+	Concurrency *ConcurrencyConfig `yaml:"concurrency,omitempty"`
+	Signatures  *SignatureConfig   `yaml:"signatures,omitempty"`
+	Groups      map[string]*Group  `yaml:",inline"`
 }
 
 type CacheConfig struct {
 	Enabled bool   `yaml:"enabled"`
 	Dir     string `yaml:"dir,omitempty"`
+	// TTL is how long a cached clone is used without fetching again, e.g. "15m" or "1d"
+	TTL string `yaml:"ttl,omitempty"`
+	// MaxSize caps the disk space of the cache, e.g. "2GB" (see ParseSize)
+	MaxSize string `yaml:"max_size,omitempty"`
+	// MaxAge evicts cached clones unused for this long, e.g. "30d"
+	MaxAge string `yaml:"max_age,omitempty"`
+}
+
+// ParseSize parses a size in bytes with an optional unit: B, KB, MB, GB or TB.
+// Units are powers of 1024, and the KiB/MiB/GiB/TiB spellings are accepted too.
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"TIB", 1 << 40}, {"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+		{"B", 1},
+	}
+	multiplier := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(str, u.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, u.suffix))
+			multiplier = u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q: expected a number with an optional unit such as MB or GB", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// CloneConfig selects how remote repositories are cloned
+type CloneConfig struct {
+	// Backend is "git" or "go-git"; empty uses git when it is installed
+	Backend string `yaml:"backend,omitempty"`
+	// Storage is where the go-git backend keeps clones: "memory" (default) or "disk"
+	Storage string `yaml:"storage,omitempty"`
+	// SSHKey is a private key file the go-git backend uses for SSH URLs instead of the SSH agent
+	SSHKey        string `yaml:"ssh_key,omitempty"`
+	HTTPSUsername string `yaml:"https_username,omitempty"`
+	// HTTPSTokenEnv names the environment variable holding an access token for HTTPS URLs
+	HTTPSTokenEnv string `yaml:"https_token_env,omitempty"`
+	// Timeout bounds the time spent on each repository, retries included, e.g. "2m"
+	Timeout string `yaml:"timeout,omitempty"`
+	// Retries is how many times a transient clone or fetch failure is retried; nil uses the default
+	Retries *int `yaml:"retries,omitempty"`
+}
+
+// ConcurrencyConfig limits how many remote repositories are fetched at once
+type ConcurrencyConfig struct {
+	// Jobs is the overall limit; zero uses the default
+	Jobs int `yaml:"jobs,omitempty"`
+	// Hosts limits individual hosts by name, e.g. a self-hosted server that rate-limits clients
+	Hosts map[string]int `yaml:"hosts,omitempty"`
+}
+
+// SignatureConfig controls commit signature verification
+type SignatureConfig struct {
+	Verify bool `yaml:"verify"`
+	// Keyring is a file of armored OpenPGP public keys
+	Keyring string `yaml:"keyring,omitempty"`
+	// AllowedSigners is an SSH allowed_signers file (see ssh-keygen(1))
+	AllowedSigners string `yaml:"allowed_signers,omitempty"`
 }
 
 type Group struct {
 	Repos []string `yaml:"repos"`
+	// Fetch fetches every local repository of the group before reporting it (see --fetch)
+	Fetch bool `yaml:"fetch,omitempty"`
+	// FetchRepos fetches only these local repositories, identified as in 'repomon rm'
+	FetchRepos []string `yaml:"fetch_repos,omitempty"`
+	Expect     *Expect  `yaml:"expect,omitempty"`
+}
+
+// Expect holds activity assertions checked for each repository of a group
+type Expect struct {
+	// MinCommits is the fewest commits expected in the window
+	MinCommits int `yaml:"min_commits,omitempty"`
+	// MaxAge is the longest a repository may go without a commit, e.g. "14d"
+	MaxAge string `yaml:"max_age,omitempty"`
+	// Repos overrides these for individual repositories, identified as in 'repomon rm'
+	Repos map[string]*Expect `yaml:"repos,omitempty"`
 }
 
 type Repo struct {
@@ -38,6 +140,8 @@ type Repo struct {
 	Path   string `yaml:"path,omitempty"`
 	URL    string `yaml:"url,omitempty"`
 	Branch string `yaml:"branch,omitempty"`
+	// Fetch is set for local repositories fetched from their upstream before reporting
+	Fetch bool `yaml:"fetch,omitempty"`
 }
 
 // parseRepoString parses a repository string and extracts name, path, URL, and optional branch
@@ -185,12 +289,99 @@ func (c *Config) GetRepos(requestedGroupName string) ([]Repo, string, error) { /
 			slog.Warn("Failed to parse repository string", "string", repoStr, "error", err)
 			continue
 		}
+		repo.Fetch = repo.Path != "" && group.fetches(repo)
 		repos = append(repos, repo)
 	}
 
 	return repos, effectiveGroupName, nil // Return nil error on success
 }
 
+// fetches reports whether repo is to be fetched before reporting, per Fetch and FetchRepos
+func (g *Group) fetches(repo Repo) bool {
+	if g.Fetch {
+		return true
+	}
+	for _, identifier := range g.FetchRepos {
+		if repoMatches(repo, identifier) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllRepos returns the repositories of every group, in group name order and without duplicates
+func (c *Config) AllRepos() []Repo {
+	names := make([]string, 0, len(c.Groups))
+	for name := range c.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var repos []Repo
+	seen := make(map[Repo]bool)
+	for _, name := range names {
+		group := c.Groups[name]
+		if group == nil {
+			continue
+		}
+		for _, repoStr := range group.Repos {
+			repo, err := parseRepoString(repoStr)
+			if err != nil {
+				slog.Warn("Failed to parse repository string", "string", repoStr, "error", err)
+				continue
+			}
+			if seen[repo] {
+				continue
+			}
+			seen[repo] = true
+			repos = append(repos, repo)
+		}
+	}
+	return repos
+}
+
+// Expectation returns the assertions for a repository of the given group: those of the
+// group, overridden field by field by any given for the repository itself. It returns nil
+// if there are none.
+func (c *Config) Expectation(groupName string, repo Repo) *Expect {
+	group := c.Groups[groupName]
+	if group == nil || group.Expect == nil {
+		return nil
+	}
+	expect := &Expect{MinCommits: group.Expect.MinCommits, MaxAge: group.Expect.MaxAge}
+	for identifier, override := range group.Expect.Repos {
+		if override == nil || !repoMatches(repo, identifier) {
+			continue
+		}
+		if override.MinCommits != 0 {
+			expect.MinCommits = override.MinCommits
+		}
+		if override.MaxAge != "" {
+			expect.MaxAge = override.MaxAge
+		}
+	}
+	if expect.MinCommits == 0 && expect.MaxAge == "" {
+		return nil
+	}
+	return expect
+}
+
+// repoMatches reports whether identifier names repo: by its path or URL string, its
+// short name, or its display name (name#branch)
+func repoMatches(repo Repo, identifier string) bool {
+	displayName := repo.Name
+	if repo.Branch != "" {
+		displayName = fmt.Sprintf("%s#%s", repo.Name, repo.Branch)
+	}
+	if identifier == repo.Name || identifier == displayName {
+		return true
+	}
+	parsed, err := parseRepoString(identifier)
+	// Fetch is a setting of the repository, not part of what identifies it
+	parsed.Fetch = repo.Fetch
+	return err == nil && parsed == repo
+}
+
 // AddRepo adds a repository to the specified group
 func (c *Config) AddRepo(repoStr, groupName string) error {
 	if c.Groups == nil {
@@ -332,6 +523,13 @@ func Load(configFile string) (*Config, error) {
 	if cfg.Cache == nil {
 		cfg.Cache = &CacheConfig{Enabled: true}
 	}
+	if cfg.Clone != nil {
+		cfg.Clone.SSHKey = expandTilde(cfg.Clone.SSHKey)
+	}
+	if cfg.Signatures != nil {
+		cfg.Signatures.Keyring = expandTilde(cfg.Signatures.Keyring)
+		cfg.Signatures.AllowedSigners = expandTilde(cfg.Signatures.AllowedSigners)
+	}
 	if cfg.Groups == nil {
 		cfg.Groups = make(map[string]*Group)
 	}
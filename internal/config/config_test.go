@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -88,6 +89,258 @@ default:
 	}
 }
 
+func TestLoad_Signatures(t *testing.T) {
+	original := getHomeDir
+	defer func() { getHomeDir = original }()
+	getHomeDir = func() (string, error) { return "/home/tester", nil }
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+signatures:
+  verify: true
+  keyring: ~/keys.asc
+  allowed_signers: /etc/ssh/allowed_signers
+default:
+  repos:
+    - /path/to/repo
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Signatures == nil || !cfg.Signatures.Verify {
+		t.Fatalf("Expected signature verification to be enabled, got %+v", cfg.Signatures)
+	}
+	if cfg.Signatures.Keyring != "/home/tester/keys.asc" {
+		t.Errorf("Expected keyring path to be expanded, got %q", cfg.Signatures.Keyring)
+	}
+	if cfg.Signatures.AllowedSigners != "/etc/ssh/allowed_signers" {
+		t.Errorf("Expected allowed signers path unchanged, got %q", cfg.Signatures.AllowedSigners)
+	}
+	if _, ok := cfg.Groups["signatures"]; ok {
+		t.Error("signatures section should not be parsed as a group")
+	}
+}
+
+func TestLoad_Clone(t *testing.T) {
+	original := getHomeDir
+	defer func() { getHomeDir = original }()
+	getHomeDir = func() (string, error) { return "/home/tester", nil }
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+clone:
+  backend: go-git
+  storage: disk
+  ssh_key: ~/.ssh/id_ed25519
+  https_username: oauth2
+  https_token_env: GITLAB_TOKEN
+  timeout: 2m
+  retries: 0
+default:
+  repos:
+    - /path/to/repo
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	want := CloneConfig{
+		Backend:       "go-git",
+		Storage:       "disk",
+		SSHKey:        "/home/tester/.ssh/id_ed25519",
+		HTTPSUsername: "oauth2",
+		HTTPSTokenEnv: "GITLAB_TOKEN",
+		Timeout:       "2m",
+	}
+	if cfg.Clone == nil || cfg.Clone.Retries == nil || *cfg.Clone.Retries != 0 {
+		t.Fatalf("Expected retries to be set to 0, got %+v", cfg.Clone)
+	}
+	got := *cfg.Clone
+	got.Retries = nil
+	if got != want {
+		t.Errorf("Expected clone config %+v, got %+v", want, got)
+	}
+	if _, ok := cfg.Groups["clone"]; ok {
+		t.Error("clone section should not be parsed as a group")
+	}
+}
+
+func TestLoad_Cache(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+cache:
+  enabled: true
+  ttl: 1d
+  max_size: 2GB
+  max_age: 30d
+default:
+  repos:
+    - /path/to/repo
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	// Durations are kept as written, days included, and parsed with the other settings
+	want := CacheConfig{Enabled: true, TTL: "1d", MaxSize: "2GB", MaxAge: "30d"}
+	if cfg.Cache == nil || *cfg.Cache != want {
+		t.Errorf("Expected cache config %+v, got %+v", want, cfg.Cache)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "512", want: 512},
+		{input: "512B", want: 512},
+		{input: "100KB", want: 100 << 10},
+		{input: "1.5 GB", want: 3 << 29},
+		{input: "2gb", want: 2 << 30},
+		{input: "500MiB", want: 500 << 20},
+		{input: "1T", want: 1 << 40},
+		{input: "", wantErr: true},
+		{input: "-1GB", wantErr: true},
+		{input: "lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSize(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %d", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSize(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_Concurrency(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+concurrency:
+  jobs: 20
+  hosts:
+    gitlab.example.com: 4
+default:
+  repos:
+    - /path/to/repo
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Concurrency == nil || cfg.Concurrency.Jobs != 20 || cfg.Concurrency.Hosts["gitlab.example.com"] != 4 {
+		t.Errorf("Unexpected concurrency config: %+v", cfg.Concurrency)
+	}
+	if _, ok := cfg.Groups["concurrency"]; ok {
+		t.Error("concurrency section should not be parsed as a group")
+	}
+}
+
+func TestExpectation(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+deps:
+  repos:
+    - https://github.com/example/lib
+    - https://github.com/example/slow
+    - https://github.com/example/tool#stable
+  expect:
+    min_commits: 1
+    max_age: 14d
+    repos:
+      slow:
+        max_age: 90d
+      https://github.com/example/tool#stable:
+        min_commits: 3
+default:
+  repos:
+    - /path/to/repo
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	repos, _, err := cfg.GetRepos("deps")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Expect{
+		{MinCommits: 1, MaxAge: "14d"},
+		{MinCommits: 1, MaxAge: "90d"},
+		{MinCommits: 3, MaxAge: "14d"},
+	}
+	for i, repo := range repos {
+		got := cfg.Expectation("deps", repo)
+		if got == nil || got.MinCommits != want[i].MinCommits || got.MaxAge != want[i].MaxAge || got.Repos != nil {
+			t.Errorf("%s: expected %+v, got %+v", repo.Name, want[i], got)
+		}
+	}
+
+	defaultRepos, _, _ := cfg.GetRepos("default")
+	if got := cfg.Expectation("default", defaultRepos[0]); got != nil {
+		t.Errorf("Expected no expectations without an expect section, got %+v", got)
+	}
+}
+
+func TestLoad_DateWindowing(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+date_field: committer
+clock_skew: 2h30m
+default:
+  repos:
+    - /path/to/repo
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.DateField != "committer" {
+		t.Errorf("Expected date_field 'committer', got %q", cfg.DateField)
+	}
+	if cfg.ClockSkew == nil || *cfg.ClockSkew != 2*time.Hour+30*time.Minute {
+		t.Errorf("Expected clock_skew 2h30m, got %v", cfg.ClockSkew)
+	}
+}
+
 func TestLoadDefaultPath(t *testing.T) {
 	cfg, err := Load("")
 	if err == nil {
@@ -298,6 +551,34 @@ func TestGetReposNoDefaultGroup(t *testing.T) {
 	}
 }
 
+func TestAllRepos(t *testing.T) {
+	cfg := &Config{
+		Groups: map[string]*Group{
+			"work": {Repos: []string{"https://github.com/company/backend", "https://github.com/plars/repomon#main"}},
+			"default": {Repos: []string{
+				"https://github.com/plars/repomon#main",
+				"/path/to/local",
+			}},
+			"empty": nil,
+		},
+	}
+
+	repos := cfg.AllRepos()
+	want := []Repo{
+		{Name: "repomon", URL: "https://github.com/plars/repomon", Branch: "main"},
+		{Name: "local", Path: "/path/to/local"},
+		{Name: "backend", URL: "https://github.com/company/backend"},
+	}
+	if len(repos) != len(want) {
+		t.Fatalf("Expected %d repos, got %+v", len(want), repos)
+	}
+	for i := range want {
+		if repos[i] != want[i] {
+			t.Errorf("Repo %d: expected %+v, got %+v", i, want[i], repos[i])
+		}
+	}
+}
+
 func TestExpandTilde(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -850,3 +1131,38 @@ func TestConfigSave_CreateFileError(t *testing.T) {
 		t.Errorf("Expected 'failed to create config file' in error, got: %v", err)
 	}
 }
+
+func TestGetRepos_Fetch(t *testing.T) {
+	cfg := &Config{
+		Groups: map[string]*Group{
+			"all": {
+				Repos: []string{"/src/app", "https://github.com/example/lib"},
+				Fetch: true,
+			},
+			"some": {
+				Repos:      []string{"/src/app", "/src/tool#main", "/src/docs"},
+				FetchRepos: []string{"app", "/src/tool#main"},
+			},
+		},
+	}
+
+	tests := []struct {
+		group string
+		want  []bool
+	}{
+		// Remote repositories are fetched anyway
+		{group: "all", want: []bool{true, false}},
+		{group: "some", want: []bool{true, true, false}},
+	}
+	for _, tt := range tests {
+		repos, _, err := cfg.GetRepos(tt.group)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, repo := range repos {
+			if repo.Fetch != tt.want[i] {
+				t.Errorf("%s/%s: expected fetch %v, got %v", tt.group, repo.Name, tt.want[i], repo.Fetch)
+			}
+		}
+	}
+}
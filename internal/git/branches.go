@@ -0,0 +1,184 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/plars/repomon/internal/config"
+)
+
+// Branch is a branch listed by the stale branch report
+type Branch struct {
+	// Name is the branch name. Local repositories list their remote-tracking branches,
+	// named after their remote, e.g. "origin/feature".
+	Name string
+	Hash string
+	// LastCommit is the date of the commit at its tip
+	LastCommit time.Time
+	// LastAuthor is the author of the commit at its tip
+	LastAuthor string
+	// Merged is set when its tip is in the history of the default branch; for local
+	// repositories, that of origin
+	Merged bool
+}
+
+// GetStaleBranches lists, for every repository in repository order, the branches with
+// no commit since olderThan. The default branch is left out; the repository's branch
+// when configured, or else the one HEAD points to.
+func (m *Monitor) GetStaleBranches(ctx context.Context, olderThan time.Time) ([]RepoResult, error) {
+	results := make([]RepoResult, len(m.repos))
+	collect := func(ctx context.Context, repo config.Repo, result *RepoResult) error {
+		return m.collectBranches(ctx, repo, olderThan, result)
+	}
+	err := m.streamRepos(ctx, collect, func(index int, result RepoResult) {
+		results[index] = result
+	})
+	return results, err
+}
+
+// collectBranches fills in the stale branches of a single repository, oldest first
+func (m *Monitor) collectBranches(ctx context.Context, repo config.Repo, olderThan time.Time, result *RepoResult) error {
+	opts := m.cloneOptions(repo)
+	opts.Blobs = false
+	opts.AllBranches = true
+	gitRepo, cleanup, err := m.openRepo(ctx, repo, opts, result)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	defaultRef, err := resolveRef(gitRepo, repo.Branch)
+	if err != nil {
+		return err
+	}
+	result.DefaultBranch = defaultRef.Name().Short()
+
+	heads, err := branchHeads(gitRepo, repo.URL != "", result.DefaultBranch)
+	if err != nil {
+		return err
+	}
+	for name, hash := range heads {
+		tip, err := gitRepo.CommitObject(hash)
+		if err != nil {
+			return fmt.Errorf("failed to get tip of branch %s: %w", name, err)
+		}
+		when := commitDate(tip, m.dateField)
+		if !when.Before(olderThan) {
+			continue
+		}
+		result.Branches = append(result.Branches, Branch{
+			Name:       name,
+			Hash:       hash.String(),
+			LastCommit: when,
+			LastAuthor: tip.Author.Name,
+		})
+	}
+	if len(result.Branches) == 0 {
+		return nil
+	}
+
+	mergedInto := defaultRef.Hash()
+	if repo.URL == "" {
+		// Remote-tracking branches are merged when upstream has them, whatever the
+		// local default branch holds that was not pushed, or lacks that was not pulled
+		upstream, err := gitRepo.Reference(plumbing.NewRemoteReferenceName("origin", result.DefaultBranch), true)
+		if err == nil {
+			mergedInto = upstream.Hash()
+		} else {
+			slog.Debug("No upstream default branch, checking merges into the local one", "repo", repo.Name, "branch", result.DefaultBranch, "error", err)
+		}
+	}
+	merged, err := reachableFrom(ctx, gitRepo, mergedInto)
+	if err != nil {
+		return err
+	}
+	for i := range result.Branches {
+		result.Branches[i].Merged = merged[plumbing.NewHash(result.Branches[i].Hash)]
+	}
+	sort.Slice(result.Branches, func(i, j int) bool {
+		a, b := result.Branches[i], result.Branches[j]
+		if !a.LastCommit.Equal(b.LastCommit) {
+			return a.LastCommit.Before(b.LastCommit)
+		}
+		return a.Name < b.Name
+	})
+	return nil
+}
+
+// branchHeads returns the tip of each branch but the default one, by name. Clones of
+// remote repositories hold the branches as local branches, or as remote-tracking
+// branches of origin depending on the cloner; local repositories list their
+// remote-tracking branches, since those are the ones shared with others.
+func branchHeads(gitRepo *git.Repository, remote bool, defaultBranch string) (map[string]plumbing.Hash, error) {
+	refs, err := gitRepo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	heads := make(map[string]plumbing.Hash)
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		// Symbolic references, such as origin/HEAD, are not branches of their own
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		var name string
+		switch {
+		case remote && ref.Name().IsBranch():
+			name = ref.Name().Short()
+		case remote && strings.HasPrefix(ref.Name().String(), "refs/remotes/origin/"):
+			name = strings.TrimPrefix(ref.Name().String(), "refs/remotes/origin/")
+		case !remote && ref.Name().IsRemote():
+			name = ref.Name().Short()
+		default:
+			return nil
+		}
+
+		short := name
+		if !remote {
+			_, short, _ = strings.Cut(name, "/")
+		}
+		if short == defaultBranch || short == "HEAD" {
+			return nil
+		}
+		heads[name] = ref.Hash()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	return heads, nil
+}
+
+// reachableFrom returns the commits in the history of tip. Commits missing from a
+// shallow repository end their line of history.
+func reachableFrom(ctx context.Context, gitRepo *git.Repository, tip plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	seen := make(map[plumbing.Hash]bool)
+	pending := []plumbing.Hash{tip}
+	for len(pending) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hash := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		c, err := gitRepo.CommitObject(hash)
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commit %s: %w", hash, err)
+		}
+		pending = append(pending, c.ParentHashes...)
+	}
+	return seen, nil
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/plars/repomon/internal/config"
+)
+
+func TestMonitor_GetStaleBranches(t *testing.T) {
+	sourcePath := filepath.Join(t.TempDir(), "source")
+	if err := os.MkdirAll(sourcePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	gitIn := func(dir, date string, args ...string) {
+		t.Helper()
+		args = append([]string{"-C", dir, "-c", "user.name=Test User", "-c", "user.email=test@example.com"}, args...)
+		cmd := exec.Command("git", args...)
+		if date != "" {
+			cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		}
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+	}
+
+	// merged-old is in the history of main, unmerged-old is not, and recent is too
+	// young to be stale
+	gitIn(sourcePath, "", "init", "-q", "-b", "main")
+	gitIn(sourcePath, "2020-01-01T00:00:00Z", "commit", "-q", "--allow-empty", "-m", "Old work")
+	gitIn(sourcePath, "", "branch", "merged-old")
+	gitIn(sourcePath, "", "checkout", "-q", "-b", "unmerged-old")
+	gitIn(sourcePath, "2020-06-01T00:00:00Z", "commit", "-q", "--allow-empty", "-m", "Abandoned work")
+	gitIn(sourcePath, "", "checkout", "-q", "-b", "recent", "main")
+	gitIn(sourcePath, "", "commit", "-q", "--allow-empty", "-m", "Recent work")
+	gitIn(sourcePath, "", "checkout", "-q", "main")
+	gitIn(sourcePath, "", "commit", "-q", "--allow-empty", "-m", "Current work")
+
+	workPath := filepath.Join(t.TempDir(), "work")
+	if output, err := exec.Command("git", "clone", "-q", sourcePath, workPath).CombinedOutput(); err != nil {
+		t.Fatalf("git clone failed: %v: %s", err, output)
+	}
+	// Merged locally but not pushed, so still unmerged upstream
+	gitIn(workPath, "", "merge", "-q", "--no-edit", "origin/unmerged-old")
+
+	tests := []struct {
+		name   string
+		repo   config.Repo
+		cloner GitCloner
+		prefix string
+	}{
+		{name: "remote", repo: config.Repo{Name: "remote", URL: "file://" + sourcePath}},
+		{name: "go-git", repo: config.Repo{Name: "remote", URL: "file://" + sourcePath}, cloner: NewGoGitCloner(StorageMemory, GoGitAuth{})},
+		{name: "cached", repo: config.Repo{Name: "remote", URL: "file://" + sourcePath}, cloner: NewCachingGitCloner(t.TempDir())},
+		// Local repositories report their remote-tracking branches
+		{name: "local", repo: config.Repo{Name: "local", Path: workPath}, prefix: "origin/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := NewMonitorWithRepos([]config.Repo{tt.repo})
+			if tt.cloner != nil {
+				monitor.SetCloner(tt.cloner)
+			}
+			results, err := monitor.GetStaleBranches(context.Background(), time.Now().AddDate(0, -1, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := results[0]
+			if result.Error != nil {
+				t.Fatalf("Unexpected error: %v", result.Error)
+			}
+			if result.DefaultBranch != "main" {
+				t.Errorf("Expected default branch main, got %q", result.DefaultBranch)
+			}
+			if len(result.Branches) != 2 {
+				t.Fatalf("Expected 2 stale branches, got %+v", result.Branches)
+			}
+			merged, unmerged := result.Branches[0], result.Branches[1]
+			if merged.Name != tt.prefix+"merged-old" || !merged.Merged || merged.LastAuthor != "Test User" ||
+				!merged.LastCommit.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("Unexpected merged branch %+v", merged)
+			}
+			if unmerged.Name != tt.prefix+"unmerged-old" || unmerged.Merged {
+				t.Errorf("Unexpected unmerged branch %+v", unmerged)
+			}
+		})
+	}
+}
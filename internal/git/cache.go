@@ -0,0 +1,830 @@
+package git
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/plars/repomon/internal/config"
+)
+
+// cacheMetadataFile is the name of the metadata file kept in each cache entry
+const cacheMetadataFile = "repomon.json"
+
+// rewriteRetention is how long a history rewrite keeps being reported after the fetch
+// that found it, unless acknowledged, so that runs which did not fetch it see it too
+const rewriteRetention = 7 * 24 * time.Hour
+
+// allBranches stands for the branch of the cache entries holding every branch of a
+// repository (see CloneOptions.AllBranches), as in a git refspec
+const allBranches = "*"
+
+// cacheMetadata records what a cache entry holds, since entry names are hashed
+type cacheMetadata struct {
+	URL       string    `json:"url"`
+	Branch    string    `json:"branch,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	FetchedAt time.Time `json:"fetched_at"`
+	LastUsed  time.Time `json:"last_used"`
+	// Tip is the commit the branch pointed to when last fetched
+	Tip string `json:"tip,omitempty"`
+	// Rewrite is set when a fetch found the branch's history rewritten, until it is
+	// acknowledged or older than rewriteRetention
+	Rewrite *cacheRewrite `json:"rewrite,omitempty"`
+}
+
+// cacheRewrite records a rewrite of the cached branch's history (see Rewrite)
+type cacheRewrite struct {
+	OldTip     string    `json:"old_tip"`
+	NewTip     string    `json:"new_tip"`
+	DetectedAt time.Time `json:"detected_at"`
+	Dropped    []string  `json:"dropped,omitempty"`
+}
+
+// CacheEntry describes a cached repository
+type CacheEntry struct {
+	// Name is the entry's directory name in the cache
+	Name   string
+	Path   string
+	URL    string
+	Branch string
+	// CreatedAt and FetchedAt are zero if unknown, e.g. for entries made by older versions
+	CreatedAt time.Time
+	FetchedAt time.Time
+	// LastUsed is when a run last used the entry, or its best estimate for older entries
+	LastUsed time.Time
+	// Size is the disk space used by the entry, in bytes
+	Size int64
+}
+
+// DisplayName returns the repository name with its branch, as used by 'repomon list'
+func (e CacheEntry) DisplayName() string {
+	name := e.Name
+	if e.URL != "" {
+		name = strings.TrimSuffix(filepath.Base(e.URL), ".git")
+	}
+	if e.Branch != "" {
+		name = fmt.Sprintf("%s#%s", name, e.Branch)
+	}
+	return name
+}
+
+// CacheProblem is a cache entry that failed verification
+type CacheProblem struct {
+	Entry CacheEntry
+	Err   error
+}
+
+// DefaultCacheDir returns the default cache directory, ~/.cache/repomon
+func DefaultCacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".cache", "repomon"), nil
+}
+
+// CachingGitCloner implements GitCloner with a local cache of bare, blobless clones.
+// Only commits and trees are fetched; file contents are fetched on demand when a
+// feature needs to diff commits (see CloneOptions.Blobs).
+type CachingGitCloner struct {
+	cacheDir string
+
+	// used records the entries served by this cloner, which eviction must keep
+	mu   sync.Mutex
+	used map[string]bool
+}
+
+// NewCachingGitCloner creates a CachingGitCloner
+func NewCachingGitCloner(cacheDir string) *CachingGitCloner {
+	return &CachingGitCloner{
+		cacheDir: cacheDir,
+		used:     make(map[string]bool),
+	}
+}
+
+// Clone brings the cache entry for repoURL up to date and returns its path. The entry
+// stays locked against changes by other processes until cleanup is called.
+func (c *CachingGitCloner) Clone(ctx context.Context, repoURL string, opts CloneOptions) (string, func(), error) {
+	branch := opts.Branch
+	if opts.AllBranches {
+		// Every branch is kept in an entry of its own, commits only, shared by all the
+		// branches configured for the repository. Its HEAD is the remote's default.
+		branch = allBranches
+		opts.Branch = ""
+	}
+	cacheName := sanitizeRepoName(repoURL, branch)
+	cachePath := filepath.Join(c.cacheDir, cacheName)
+
+	lock, err := c.lockEntry(ctx, cacheName, true)
+	if err != nil {
+		return "", func() {}, err
+	}
+	var lastTip string
+	if meta, err := readCacheMetadata(cachePath); err == nil {
+		lastTip = meta.Tip
+	}
+	fetched, err := c.update(ctx, repoURL, cachePath, opts)
+	if err != nil {
+		lock.unlock()
+		return "", func() {}, err
+	}
+	var tip string
+	var rewrite *cacheRewrite
+	if fetched && !opts.AllBranches {
+		tip, rewrite = checkHistory(ctx, cachePath, lastTip, opts.ClockSkew)
+	}
+	recordUse(cachePath, repoURL, branch, fetched, tip, rewrite)
+	c.mu.Lock()
+	c.used[cacheName] = true
+	c.mu.Unlock()
+
+	// Other runs may read the entry too, but not change it while it is in use
+	if err := lock.share(ctx); err != nil {
+		lock.unlock()
+		return "", func() {}, err
+	}
+	return cachePath, lock.unlock, nil
+}
+
+// update clones or fetches the cache entry at cachePath as needed, and reports whether
+// it fetched from the remote. The caller must hold its exclusive lock.
+func (c *CachingGitCloner) update(ctx context.Context, repoURL, cachePath string, opts CloneOptions) (bool, error) {
+	if _, err := os.Stat(cachePath); err == nil {
+		slog.Debug("Using cached repository", "path", cachePath)
+		err := migrateCache(ctx, cachePath)
+		if err == nil && opts.Offline {
+			slog.Debug("Offline, using cached repository without fetching", "path", cachePath)
+			return false, nil
+		}
+		if err == nil && isFresh(cachePath, opts) {
+			slog.Debug("Cached repository is within its TTL, skipping fetch", "path", cachePath, "ttl", opts.MaxAge)
+			return false, c.ready(ctx, cachePath, opts)
+		}
+		if err == nil {
+			err = c.fetchUpdates(ctx, cachePath, opts)
+		}
+		if err == nil {
+			return true, c.ready(ctx, cachePath, opts)
+		}
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		// A server that cannot be reached says nothing about the entry, which is kept
+		// for the next attempt; only a corrupt entry is worth cloning again
+		if kind := errorKind(err); kind == KindNetwork || kind == KindTimeout {
+			return false, &RepoError{Kind: kind, Err: err}
+		}
+		verr := verifyCacheEntry(ctx, cachePath)
+		if verr == nil {
+			return false, classifyError(err)
+		}
+		slog.Warn("Fetch failed on a corrupt cache entry, re-cloning", "error", err, "corruption", verr)
+		if err := os.RemoveAll(cachePath); err != nil {
+			slog.Warn("Failed to remove broken cache", "error", err)
+		}
+	}
+
+	if opts.Offline {
+		return false, ErrNotCached
+	}
+
+	if err := c.cloneToCache(ctx, repoURL, cachePath, opts); err != nil {
+		return false, err
+	}
+	return true, c.ready(ctx, cachePath, opts)
+}
+
+// lockEntry locks the named cache entry. Lock files live beside the entries rather
+// than inside them, so they survive the entry being removed and re-cloned; they are
+// never deleted, since a process may be waiting on one.
+func (c *CachingGitCloner) lockEntry(ctx context.Context, name string, exclusive bool) (*fileLock, error) {
+	if err := os.MkdirAll(c.cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return lockFile(ctx, filepath.Join(c.cacheDir, name+".lock"), exclusive)
+}
+
+// LastFetched returns when the cache entry at repoPath was last fetched, or zero if unknown
+func (c *CachingGitCloner) LastFetched(repoPath string) time.Time {
+	meta, err := readCacheMetadata(repoPath)
+	if err != nil {
+		return time.Time{}
+	}
+	return meta.FetchedAt
+}
+
+// LastRewrite returns the history rewrite still reported for the cache entry at repoPath,
+// or nil if there is none
+func (c *CachingGitCloner) LastRewrite(repoPath string) *Rewrite {
+	meta, err := readCacheMetadata(repoPath)
+	if err != nil || meta.Rewrite == nil {
+		return nil
+	}
+	rewrite := &Rewrite{
+		OldTip:     meta.Rewrite.OldTip,
+		NewTip:     meta.Rewrite.NewTip,
+		DetectedAt: meta.Rewrite.DetectedAt,
+	}
+	for _, hash := range meta.Rewrite.Dropped {
+		rewrite.Dropped = append(rewrite.Dropped, Commit{Hash: hash})
+	}
+	return rewrite
+}
+
+// checkHistory returns the tip of a freshly fetched cache entry, and the rewrite of its
+// history if lastTip, the tip it had when last fetched, is no longer in it. Failing to
+// check is not fatal: the entry is still up to date.
+func checkHistory(ctx context.Context, cachePath, lastTip string, clockSkew time.Duration) (string, *cacheRewrite) {
+	gitRepo, err := git.PlainOpen(cachePath)
+	if err != nil {
+		slog.Debug("Failed to open cache entry", "path", cachePath, "error", err)
+		return "", nil
+	}
+	head, err := gitRepo.Head()
+	if err != nil {
+		slog.Debug("Failed to read cached branch tip", "path", cachePath, "error", err)
+		return "", nil
+	}
+	tip := head.Hash()
+	if !plumbing.IsHash(lastTip) {
+		return tip.String(), nil
+	}
+
+	rewrite, err := findRewrite(ctx, gitRepo, cachePath, plumbing.NewHash(lastTip), tip, clockSkew)
+	if err != nil {
+		slog.Warn("Failed to check cached branch for a history rewrite", "path", cachePath, "error", err)
+	}
+	return tip.String(), rewrite
+}
+
+// findRewrite returns the rewrite of the cached branch if lastTip is no longer in the
+// history of tip, or nil
+func findRewrite(ctx context.Context, gitRepo *git.Repository, cachePath string, lastTip, tip plumbing.Hash, clockSkew time.Duration) (*cacheRewrite, error) {
+	rewritten, err := isRewritten(gitRepo, lastTip, tip, clockSkew)
+	if err != nil || !rewritten {
+		return nil, err
+	}
+	slog.Debug("Branch history was rewritten, e.g. by a force push", "path", cachePath, "old_tip", lastTip, "new_tip", tip)
+
+	rewrite := &cacheRewrite{OldTip: lastTip.String(), NewTip: tip.String(), DetectedAt: time.Now()}
+	dropped, err := droppedHashes(ctx, cachePath, lastTip, tip)
+	if err != nil {
+		// The rewrite is reported regardless
+		slog.Debug("Failed to list dropped commits", "path", cachePath, "error", err)
+	}
+	for _, hash := range dropped {
+		rewrite.Dropped = append(rewrite.Dropped, hash.String())
+	}
+	return rewrite, nil
+}
+
+// isFresh reports whether a cache entry was fetched within opts.MaxAge and its
+// history reaches back to opts.Since, so it can be used without fetching
+func isFresh(cachePath string, opts CloneOptions) bool {
+	if opts.MaxAge <= 0 {
+		return false
+	}
+	meta, err := readCacheMetadata(cachePath)
+	if err != nil || time.Since(meta.FetchedAt) > opts.MaxAge {
+		return false
+	}
+	if opts.Since.IsZero() {
+		return true
+	}
+	repo, err := git.PlainOpen(cachePath)
+	if err != nil {
+		return false
+	}
+	covered, err := historyCovers(repo, opts.Since)
+	return err == nil && covered
+}
+
+// ready prepares a cache entry for the caller, fetching file contents if requested
+func (c *CachingGitCloner) ready(ctx context.Context, cachePath string, opts CloneOptions) error {
+	if opts.Blobs {
+		return fetchBlobs(ctx, cachePath, opts)
+	}
+	return nil
+}
+
+// fetchUpdates fetches new commits into a cached clone, deepening (or shortening)
+// its history to the window in opts
+func (c *CachingGitCloner) fetchUpdates(ctx context.Context, repoPath string, opts CloneOptions) error {
+	fetch := func(history []string) ([]byte, error) {
+		args := append([]string{"fetch"}, history...)
+		if opts.AllBranches {
+			// Drop the branches deleted upstream
+			args = append(args, "--prune")
+		}
+		return runGit(ctx, repoPath, append(args, "origin")...)
+	}
+
+	output, err := fetch(historyArgs(opts))
+	if err != nil && noShallowCommits(output) {
+		slog.Debug("No commits since window start, fetching tip only", "path", repoPath, "since", opts.Since)
+		output, err = fetch([]string{"--depth", "1"})
+	}
+	if err != nil {
+		return fmt.Errorf("git fetch failed: %w: %s", err, output)
+	}
+	return nil
+}
+
+func (c *CachingGitCloner) cloneToCache(ctx context.Context, repoURL, cachePath string, opts CloneOptions) error {
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	var err error
+	if opts.AllBranches {
+		// Every branch, but only its commits
+		err = gitClone(ctx, repoURL, cachePath, opts, "--bare", "--filter=tree:0")
+		if err == nil {
+			err = setCacheFetch(ctx, cachePath, "+refs/heads/*:refs/heads/*")
+		}
+	} else {
+		err = gitClone(ctx, repoURL, cachePath, opts, "--bare", "--filter=blob:none", "--single-branch")
+		if err == nil {
+			err = configureCacheFetch(ctx, cachePath)
+		}
+	}
+	if err != nil {
+		// Don't leave a partial clone behind, e.g. when git was killed on cancellation
+		if rmErr := os.RemoveAll(cachePath); rmErr != nil {
+			slog.Warn("Failed to remove partial cache entry", "path", cachePath, "error", rmErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// configureCacheFetch makes "git fetch origin" update the cached branch in place.
+// Bare clones have no fetch refspec of their own.
+func configureCacheFetch(ctx context.Context, cachePath string) error {
+	output, err := runGit(ctx, cachePath, "symbolic-ref", "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to read cached branch: %w: %s", err, output)
+	}
+	ref := strings.TrimSpace(string(output))
+	return setCacheFetch(ctx, cachePath, "+"+ref+":"+ref)
+}
+
+// setCacheFetch sets the refspec "git fetch origin" updates a cache entry with
+func setCacheFetch(ctx context.Context, cachePath, refspec string) error {
+	if output, err := runGit(ctx, cachePath, "config", "remote.origin.fetch", refspec); err != nil {
+		return fmt.Errorf("failed to configure cache fetch: %w: %s", err, output)
+	}
+	return nil
+}
+
+// migrateCache converts a cache entry made by earlier versions, a clone with a
+// working tree, into a bare blobless repository. The objects already fetched are kept.
+func migrateCache(ctx context.Context, cachePath string) error {
+	gitDir := filepath.Join(cachePath, ".git")
+	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+		return nil
+	}
+	slog.Debug("Migrating cache entry to a bare repository", "path", cachePath)
+
+	tmp := cachePath + ".migrate"
+	if err := os.RemoveAll(tmp); err != nil {
+		return fmt.Errorf("failed to migrate cache: %w", err)
+	}
+	if err := os.Rename(gitDir, tmp); err != nil {
+		return fmt.Errorf("failed to migrate cache: %w", err)
+	}
+	if err := os.RemoveAll(cachePath); err != nil {
+		return fmt.Errorf("failed to migrate cache: %w", err)
+	}
+	if err := os.Rename(tmp, cachePath); err != nil {
+		return fmt.Errorf("failed to migrate cache: %w", err)
+	}
+	if err := os.Remove(filepath.Join(cachePath, "index")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to migrate cache: %w", err)
+	}
+
+	settings := [][2]string{
+		{"core.bare", "true"},
+		// Later fetches skip file contents, like a fresh --filter=blob:none clone
+		{"remote.origin.promisor", "true"},
+		{"remote.origin.partialclonefilter", "blob:none"},
+	}
+	for _, kv := range settings {
+		if output, err := runGit(ctx, cachePath, "config", kv[0], kv[1]); err != nil {
+			return fmt.Errorf("failed to migrate cache: %w: %s", err, output)
+		}
+	}
+	return configureCacheFetch(ctx, cachePath)
+}
+
+// fetchBlobs fetches the file contents needed to diff the commits since opts.Since.
+// Diffing in a blobless clone makes git fetch the missing blobs from the remote.
+func fetchBlobs(ctx context.Context, cachePath string, opts CloneOptions) error {
+	args := []string{"log", "--format=", "--shortstat", "--no-renames", "--diff-merges=first-parent"}
+	if !opts.Since.IsZero() {
+		args = append(args, "--since="+opts.Since.UTC().Format(time.RFC3339))
+	}
+	if output, err := runGit(ctx, cachePath, args...); err != nil {
+		return fmt.Errorf("failed to fetch file contents: %w: %s", err, output)
+	}
+	return nil
+}
+
+// recordUse updates the metadata of a cache entry each time it is used, and whether it
+// was fetched. A fetch also records the branch tip, if known, and the rewrite it found,
+// if any, which is kept until acknowledged or past rewriteRetention. Failing to write it
+// is not fatal: the entry still works, it is just harder to inspect and evict.
+func recordUse(cachePath, repoURL, branch string, fetched bool, tip string, rewrite *cacheRewrite) {
+	now := time.Now()
+	meta, err := readCacheMetadata(cachePath)
+	if err != nil {
+		meta = &cacheMetadata{CreatedAt: now}
+	}
+	meta.URL = repoURL
+	meta.Branch = branch
+	meta.LastUsed = now
+	if meta.Rewrite != nil && now.Sub(meta.Rewrite.DetectedAt) > rewriteRetention {
+		meta.Rewrite = nil
+	}
+	if fetched {
+		meta.FetchedAt = now
+		meta.Rewrite = mergeRewrites(meta.Rewrite, rewrite)
+		if tip != "" {
+			meta.Tip = tip
+		}
+	}
+
+	if err := writeCacheMetadata(cachePath, meta); err != nil {
+		slog.Debug("Failed to write cache metadata", "path", cachePath, "error", err)
+	}
+}
+
+// mergeRewrites returns the rewrite still reported, kept, updated with the one a fetch
+// just found, next, either of which may be nil. A branch rewritten again before the
+// first rewrite was acknowledged is reported as rewritten from its older tip.
+func mergeRewrites(kept, next *cacheRewrite) *cacheRewrite {
+	if kept == nil || next == nil {
+		if next != nil {
+			return next
+		}
+		return kept
+	}
+	merged := &cacheRewrite{OldTip: kept.OldTip, NewTip: next.NewTip, DetectedAt: next.DetectedAt}
+	seen := make(map[string]bool)
+	for _, hash := range append(append([]string{}, next.Dropped...), kept.Dropped...) {
+		if !seen[hash] && len(merged.Dropped) < maxDroppedCommits {
+			seen[hash] = true
+			merged.Dropped = append(merged.Dropped, hash)
+		}
+	}
+	return merged
+}
+
+// writeCacheMetadata writes the metadata file of a cache entry
+func writeCacheMetadata(cachePath string, meta *cacheMetadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cachePath, cacheMetadataFile), append(data, '\n'), 0644)
+}
+
+// AcknowledgeRewrite stops reporting the history rewrite recorded for a cache entry, and
+// reports whether there was one
+func (c *CachingGitCloner) AcknowledgeRewrite(ctx context.Context, entry CacheEntry) (bool, error) {
+	lock, err := c.lockEntry(ctx, entry.Name, true)
+	if err != nil {
+		return false, err
+	}
+	defer lock.unlock()
+
+	meta, err := readCacheMetadata(entry.Path)
+	if err != nil || meta.Rewrite == nil {
+		return false, nil
+	}
+	meta.Rewrite = nil
+	if err := writeCacheMetadata(entry.Path, meta); err != nil {
+		return false, fmt.Errorf("failed to write cache metadata of %s: %w", entry.Name, err)
+	}
+	return true, nil
+}
+
+// readCacheMetadata reads the metadata file of a cache entry
+func readCacheMetadata(cachePath string) (*cacheMetadata, error) {
+	data, err := os.ReadFile(filepath.Join(cachePath, cacheMetadataFile))
+	if err != nil {
+		return nil, err
+	}
+	var meta cacheMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("invalid cache metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// Entries lists the cached repositories, sorted by name
+func (c *CachingGitCloner) Entries() ([]CacheEntry, error) {
+	dirEntries, err := os.ReadDir(c.cacheDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var entries []CacheEntry
+	for _, d := range dirEntries {
+		if !d.IsDir() {
+			continue
+		}
+		entries = append(entries, c.entry(d.Name()))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// entry describes the cache entry in the named directory
+func (c *CachingGitCloner) entry(name string) CacheEntry {
+	entry := CacheEntry{Name: name, Path: filepath.Join(c.cacheDir, name)}
+	entry.Size = dirSize(entry.Path)
+
+	if meta, err := readCacheMetadata(entry.Path); err == nil {
+		entry.URL = meta.URL
+		entry.Branch = meta.Branch
+		entry.CreatedAt = meta.CreatedAt
+		entry.FetchedAt = meta.FetchedAt
+		entry.LastUsed = meta.LastUsed
+		if entry.LastUsed.IsZero() {
+			entry.LastUsed = entry.FetchedAt
+		}
+		return entry
+	}
+
+	// Entries made before metadata was recorded: recover what the repository knows
+	repo, err := git.PlainOpen(entry.Path)
+	if err != nil {
+		return entry
+	}
+	if remote, err := repo.Remote("origin"); err == nil && len(remote.Config().URLs) > 0 {
+		entry.URL = remote.Config().URLs[0]
+	}
+	if entry.URL != "" && sanitizeRepoName(entry.URL, "") != name {
+		// The entry was cached for a specific branch, which is the one checked out
+		if head, err := repo.Storer.Reference(plumbing.HEAD); err == nil && head.Type() == plumbing.SymbolicReference {
+			entry.Branch = head.Target().Short()
+		}
+	}
+	for _, f := range []string{"FETCH_HEAD", ".git/FETCH_HEAD"} {
+		if info, err := os.Stat(filepath.Join(entry.Path, f)); err == nil {
+			entry.FetchedAt = info.ModTime()
+			break
+		}
+	}
+	entry.LastUsed = entry.FetchedAt
+	if entry.LastUsed.IsZero() {
+		if info, err := os.Stat(entry.Path); err == nil {
+			entry.LastUsed = info.ModTime()
+		}
+	}
+	return entry
+}
+
+// dirSize returns the total size of the files under path
+func dirSize(path string) int64 {
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// Remove deletes a cache entry, waiting for other processes to finish using it
+func (c *CachingGitCloner) Remove(ctx context.Context, entry CacheEntry) error {
+	lock, err := c.lockEntry(ctx, entry.Name, true)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+	return c.remove(entry)
+}
+
+// remove deletes a cache entry. The caller must hold its exclusive lock.
+func (c *CachingGitCloner) remove(entry CacheEntry) error {
+	if err := os.RemoveAll(filepath.Join(c.cacheDir, entry.Name)); err != nil {
+		return fmt.Errorf("failed to remove cache entry %s: %w", entry.Name, err)
+	}
+	slog.Debug("Removed cache entry", "name", entry.Name, "url", entry.URL)
+	return nil
+}
+
+// Prune removes the cache entries that do not belong to any of the given repositories
+// and returns them. Directories that are not recognisably repomon's are left alone.
+func (c *CachingGitCloner) Prune(ctx context.Context, keep []config.Repo) ([]CacheEntry, error) {
+	wanted := make(map[string]bool, len(keep))
+	for _, repo := range keep {
+		if repo.URL != "" {
+			wanted[sanitizeRepoName(repo.URL, repo.Branch)] = true
+			wanted[sanitizeRepoName(repo.URL, allBranches)] = true
+		}
+	}
+
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var removed []CacheEntry
+	for _, entry := range entries {
+		if wanted[entry.Name] {
+			continue
+		}
+		if !c.Owned(entry) {
+			slog.Warn("Not pruning a cache directory repomon did not create", "path", entry.Path)
+			continue
+		}
+		if err := c.Remove(ctx, entry); err != nil {
+			return removed, err
+		}
+		removed = append(removed, entry)
+	}
+	return removed, nil
+}
+
+// Owned reports whether a cache entry was made by repomon: it holds repomon's metadata,
+// or it is a clone made before metadata was recorded, named after its origin. Other
+// directories in the cache are only removed when asked for by name.
+func (c *CachingGitCloner) Owned(entry CacheEntry) bool {
+	if _, err := os.Stat(filepath.Join(entry.Path, cacheMetadataFile)); err == nil {
+		return true
+	}
+	if entry.URL == "" {
+		return false
+	}
+	if _, err := git.PlainOpen(entry.Path); err != nil {
+		return false
+	}
+	return sanitizeRepoName(entry.URL, "") == entry.Name || sanitizeRepoName(entry.URL, entry.Branch) == entry.Name
+}
+
+// Verify checks the integrity of every cache entry repomon created and removes the ones
+// that are corrupt, returning them with the reason. They are cloned again on the next run.
+func (c *CachingGitCloner) Verify(ctx context.Context) ([]CacheProblem, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var problems []CacheProblem
+	for _, entry := range entries {
+		if !c.Owned(entry) {
+			slog.Warn("Not verifying a cache directory repomon did not create", "path", entry.Path)
+			continue
+		}
+		problem, err := c.verify(ctx, entry)
+		if err != nil {
+			return problems, err
+		}
+		if problem != nil {
+			problems = append(problems, *problem)
+		}
+	}
+	return problems, nil
+}
+
+// verify checks one cache entry under its lock, removing it if it is corrupt
+func (c *CachingGitCloner) verify(ctx context.Context, entry CacheEntry) (*CacheProblem, error) {
+	lock, err := c.lockEntry(ctx, entry.Name, true)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.unlock()
+
+	if err := verifyCacheEntry(ctx, entry.Path); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		slog.Debug("Cache entry failed verification", "name", entry.Name, "error", err)
+		return &CacheProblem{Entry: entry, Err: err}, c.remove(entry)
+	}
+	return nil, nil
+}
+
+// Evict removes least recently used cache entries: first those unused for longer than
+// maxAge, then the oldest until the cache fits in maxSize bytes. A zero limit is not
+// enforced. Entries used by this cloner or locked by another process are never removed,
+// nor are directories repomon did not create.
+func (c *CachingGitCloner) Evict(ctx context.Context, maxSize int64, maxAge time.Duration) ([]CacheEntry, error) {
+	all, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var entries []CacheEntry
+	for _, entry := range all {
+		if c.Owned(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].LastUsed.Before(entries[j].LastUsed) })
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+
+	var evicted []CacheEntry
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return evicted, ctx.Err()
+		}
+
+		var reason string
+		switch {
+		case maxAge > 0 && time.Since(entry.LastUsed) > maxAge:
+			reason = "unused for longer than max_age"
+		case maxSize > 0 && total > maxSize:
+			reason = "cache larger than max_size"
+		default:
+			continue
+		}
+
+		c.mu.Lock()
+		inUse := c.used[entry.Name]
+		c.mu.Unlock()
+		if inUse {
+			slog.Debug("Not evicting cache entry used by this run", "name", entry.Name, "reason", reason)
+			continue
+		}
+
+		lock, ok, err := tryLockFile(filepath.Join(c.cacheDir, entry.Name+".lock"), true)
+		if err != nil {
+			return evicted, err
+		}
+		if !ok {
+			slog.Debug("Not evicting cache entry in use by another process", "name", entry.Name, "reason", reason)
+			continue
+		}
+		if meta, err := readCacheMetadata(entry.Path); err == nil && meta.LastUsed.After(entry.LastUsed) {
+			// Another process used it since it was listed
+			lock.unlock()
+			continue
+		}
+		slog.Debug("Evicting cache entry", "name", entry.Name, "url", entry.URL, "reason", reason,
+			"last_used", entry.LastUsed, "size", entry.Size, "cache_size", total)
+		err = c.remove(entry)
+		lock.unlock()
+		if err != nil {
+			return evicted, err
+		}
+		total -= entry.Size
+		evicted = append(evicted, entry)
+	}
+	return evicted, nil
+}
+
+// verifyCacheEntry checks that a cache entry is a repository whose cached branch
+// and the objects it references are intact
+func verifyCacheEntry(ctx context.Context, cachePath string) error {
+	if _, err := git.PlainOpen(cachePath); err != nil {
+		return fmt.Errorf("not a git repository: %w", err)
+	}
+	output, err := runGit(ctx, cachePath, "fsck", "--connectivity-only", "--no-progress")
+	if err != nil {
+		return fmt.Errorf("git fsck failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// runGit runs a git command in dir and returns its combined output
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := gitCommand(ctx, args...)
+	cmd.Dir = dir
+	cmd.Env = append(cmd.Env, "GIT_LFS_SKIP_SMUDGE=1")
+	return cmd.CombinedOutput()
+}
+
+func sanitizeRepoName(repoURL, branch string) string {
+	key := repoURL
+	if branch != "" {
+		key = key + "#" + branch
+	}
+	h := sha256.Sum256([]byte(key))
+	// Use repo basename for readability + hash prefix for uniqueness
+	base := strings.TrimSuffix(filepath.Base(repoURL), ".git")
+	return fmt.Sprintf("%s-%x", base, h[:8])
+}
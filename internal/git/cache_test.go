@@ -0,0 +1,862 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/plars/repomon/internal/config"
+)
+
+func TestSanitizeRepoName(t *testing.T) {
+	tests := []struct {
+		name     string
+		repoURL  string
+		branch   string
+		wantSame bool // true if two entries should produce the same result
+		other    [2]string
+	}{
+		{
+			name:    "HTTPS URL",
+			repoURL: "https://github.com/user/repo.git",
+			branch:  "",
+		},
+		{
+			name:    "SSH URL",
+			repoURL: "git@github.com:user/repo.git",
+			branch:  "",
+		},
+		{
+			name:    "with branch",
+			repoURL: "https://github.com/user/repo.git",
+			branch:  "main",
+		},
+		{
+			name:    "same repo different branch produces different name",
+			repoURL: "https://github.com/user/repo.git",
+			branch:  "develop",
+		},
+	}
+
+	seen := make(map[string]string) // sanitized name -> description
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := sanitizeRepoName(tt.repoURL, tt.branch)
+			if result == "" {
+				t.Error("sanitizeRepoName returned empty string")
+			}
+			// Check no collisions with previous test cases
+			key := tt.repoURL + "#" + tt.branch
+			for prevResult, prevDesc := range seen {
+				if result == prevResult {
+					t.Errorf("collision: %q produced same name as %q: %s", key, prevDesc, result)
+				}
+			}
+			seen[result] = key
+		})
+	}
+
+	// Verify URLs that previously would have collided now don't
+	t.Run("no collision for similar URLs", func(t *testing.T) {
+		a := sanitizeRepoName("https://github.com/foo/bar", "")
+		b := sanitizeRepoName("https://github.com/foo_bar", "")
+		if a == b {
+			t.Errorf("expected different names, both got %q", a)
+		}
+	})
+}
+
+func TestCachingGitCloner_Clone(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Create a source repo to clone from
+	sourceRepoPath := filepath.Join(tempDir, "source-repo")
+	if err := os.MkdirAll(sourceRepoPath, 0755); err != nil {
+		t.Fatalf("Failed to create source repo dir: %v", err)
+	}
+	if err := initTestRepo(sourceRepoPath); err != nil {
+		t.Fatalf("Failed to initialize source repo: %v", err)
+	}
+
+	cacheDir := filepath.Join(tempDir, "cache")
+	cloner := NewCachingGitCloner(cacheDir)
+
+	t.Run("first call populates cache and returns repo path", func(t *testing.T) {
+		repoPath, cleanup, err := cloner.Clone(context.Background(), sourceRepoPath, CloneOptions{})
+		if err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		defer cleanup()
+
+		// Verify returned path has the repo content (the cache is bare, so read it from HEAD)
+		content, err := exec.Command("git", "-C", repoPath, "show", "HEAD:test.txt").Output()
+		if err != nil {
+			t.Fatalf("Failed to read from repo path: %v", err)
+		}
+		if string(content) != "test content" {
+			t.Errorf("Expected 'test content', got %q", content)
+		}
+
+		// Verify cache directory was created
+		entries, err := os.ReadDir(cacheDir)
+		if err != nil {
+			t.Fatalf("Failed to read cache dir: %v", err)
+		}
+		if len(entries) == 0 {
+			t.Error("Expected cache directory to have entries")
+		}
+	})
+
+	t.Run("second call returns same cache path", func(t *testing.T) {
+		repoPath, cleanup, err := cloner.Clone(context.Background(), sourceRepoPath, CloneOptions{})
+		if err != nil {
+			t.Fatalf("Cached clone failed: %v", err)
+		}
+		defer cleanup()
+
+		// Verify the returned path still has correct content
+		content, err := exec.Command("git", "-C", repoPath, "show", "HEAD:test.txt").Output()
+		if err != nil {
+			t.Fatalf("Failed to read from repo path: %v", err)
+		}
+		if string(content) != "test content" {
+			t.Errorf("Expected 'test content', got %q", content)
+		}
+	})
+}
+
+func TestCachingGitCloner_Interface(t *testing.T) {
+	var _ GitCloner = &CachingGitCloner{}
+}
+
+func TestCachingGitCloner_Clone_DeepensCache(t *testing.T) {
+	now := time.Now()
+	sourcePath, _ := initRepoWithCommits(t, datedHistory(now, 30))
+	cloner := NewCachingGitCloner(t.TempDir())
+	sourceURL := "file://" + sourcePath
+
+	repoPath := cloneCached(t, cloner, sourceURL, CloneOptions{Since: now.AddDate(0, 0, -2).Add(-time.Hour)})
+	if got := countCommits(t, repoPath); got != 2 {
+		t.Fatalf("Expected 2 commits after the first clone, got %d", got)
+	}
+
+	repoPath = cloneCached(t, cloner, sourceURL, CloneOptions{Since: now.AddDate(0, 0, -20).Add(-time.Hour)})
+	if got := countCommits(t, repoPath); got != 20 {
+		t.Errorf("Expected the cache to deepen to 20 commits, got %d", got)
+	}
+}
+
+// allowFilter lets a test source repository serve partial clones, as hosting services do
+func allowFilter(t *testing.T, repoPath string) {
+	t.Helper()
+	if output, err := exec.Command("git", "-C", repoPath, "config", "uploadpack.allowFilter", "true").CombinedOutput(); err != nil {
+		t.Fatalf("git config failed: %v: %s", err, output)
+	}
+}
+
+// missingObjects returns the number of objects reachable from HEAD that are not present locally
+func missingObjects(t *testing.T, repoPath string) int {
+	t.Helper()
+	output, err := exec.Command("git", "-C", repoPath, "rev-list", "--objects", "--missing=print", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("git rev-list failed: %v", err)
+	}
+	missing := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "?") {
+			missing++
+		}
+	}
+	return missing
+}
+
+func TestCachingGitCloner_Clone_BareBlobless(t *testing.T) {
+	now := time.Now()
+	sourcePath, _ := initRepoWithCommits(t, datedHistory(now, 5))
+	allowFilter(t, sourcePath)
+	cloner := NewCachingGitCloner(t.TempDir())
+	sourceURL := "file://" + sourcePath
+
+	repoPath := cloneCached(t, cloner, sourceURL, CloneOptions{Since: now.AddDate(0, 0, -10)})
+	if _, err := os.Stat(filepath.Join(repoPath, ".git")); !os.IsNotExist(err) {
+		t.Error("Expected a bare repository without a working tree")
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "file.txt")); !os.IsNotExist(err) {
+		t.Error("Expected no checked out files")
+	}
+	if missingObjects(t, repoPath) == 0 {
+		t.Error("Expected file contents not to be fetched")
+	}
+
+	// Requesting blobs fetches the file contents of the window
+	cloneCached(t, cloner, sourceURL, CloneOptions{Since: now.AddDate(0, 0, -10), Blobs: true})
+	if n := missingObjects(t, repoPath); n != 0 {
+		t.Errorf("Expected all file contents to be fetched, %d objects missing", n)
+	}
+}
+
+func TestCachingGitCloner_Clone_FetchesNewCommits(t *testing.T) {
+	sourcePath := t.TempDir()
+	if err := initTestRepo(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize source repo: %v", err)
+	}
+	cloner := NewCachingGitCloner(t.TempDir())
+	sourceURL := "file://" + sourcePath
+
+	repoPath := cloneCached(t, cloner, sourceURL, CloneOptions{})
+	before := countCommits(t, repoPath)
+
+	commit := exec.Command("git", "-C", sourcePath, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "New commit")
+	if output, err := commit.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v: %s", err, output)
+	}
+
+	cloneCached(t, cloner, sourceURL, CloneOptions{})
+	if after := countCommits(t, repoPath); after != before+1 {
+		t.Errorf("Expected the fetch to add one commit, had %d and now %d", before, after)
+	}
+}
+
+func TestCachingGitCloner_Clone_FetchFailure(t *testing.T) {
+	sourcePath := t.TempDir()
+	if err := initTestRepo(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize source repo: %v", err)
+	}
+	sourceURL := "file://" + sourcePath
+	setOrigin := func(t *testing.T, repoPath, url string) {
+		t.Helper()
+		if output, err := exec.Command("git", "-C", repoPath, "remote", "set-url", "origin", url).CombinedOutput(); err != nil {
+			t.Fatalf("git remote set-url failed: %v: %s", err, output)
+		}
+	}
+
+	t.Run("unreachable server keeps the entry", func(t *testing.T) {
+		cloner := NewCachingGitCloner(t.TempDir())
+		repoPath := cloneCached(t, cloner, sourceURL, CloneOptions{})
+		setOrigin(t, repoPath, "http://127.0.0.1:1/repo.git")
+
+		_, cleanup, err := cloner.Clone(context.Background(), sourceURL, CloneOptions{})
+		cleanup()
+		if KindOf(err) != KindNetwork {
+			t.Fatalf("Expected a network error, got %v", err)
+		}
+		if !isTransient(err) {
+			t.Error("Expected the error to be retried")
+		}
+		// Not cloned again: the entry still points at the unreachable server
+		output, err := exec.Command("git", "-C", repoPath, "remote", "get-url", "origin").Output()
+		if err != nil || strings.TrimSpace(string(output)) != "http://127.0.0.1:1/repo.git" {
+			t.Errorf("Expected the cache entry to be kept, got origin %q (%v)", output, err)
+		}
+	})
+
+	t.Run("corrupt entry is cloned again", func(t *testing.T) {
+		cloner := NewCachingGitCloner(t.TempDir())
+		repoPath := cloneCached(t, cloner, sourceURL, CloneOptions{})
+		setOrigin(t, repoPath, "file:///nonexistent/repo")
+		if err := os.RemoveAll(filepath.Join(repoPath, "objects")); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(repoPath, "objects"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		cloneCached(t, cloner, sourceURL, CloneOptions{})
+		if err := verifyCacheEntry(context.Background(), repoPath); err != nil {
+			t.Errorf("Expected a fresh clone, got %v", err)
+		}
+	})
+}
+
+func TestCachingGitCloner_Clone_AllBranches(t *testing.T) {
+	sourcePath := t.TempDir()
+	if err := initTestRepo(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize source repo: %v", err)
+	}
+	gitIn := func(dir string, args ...string) string {
+		t.Helper()
+		output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	gitIn(sourcePath, "branch", "feature")
+	gitIn(sourcePath, "branch", "old")
+	sourceURL := "file://" + sourcePath
+	cloner := NewCachingGitCloner(t.TempDir())
+
+	branchPath := cloneCached(t, cloner, sourceURL, CloneOptions{Branch: "feature"})
+	allPath := cloneCached(t, cloner, sourceURL, CloneOptions{Branch: "feature", AllBranches: true})
+	if allPath == branchPath || allPath != cloneCached(t, cloner, sourceURL, CloneOptions{AllBranches: true}) {
+		t.Errorf("Expected one entry of every branch, apart from the branch entry, got %s and %s", allPath, branchPath)
+	}
+	if got := gitIn(allPath, "config", "remote.origin.partialclonefilter"); got != "tree:0" {
+		t.Errorf("Expected a commit-only clone, got filter %q", got)
+	}
+	if got := gitIn(allPath, "for-each-ref", "--format=%(refname:short)", "refs/heads"); got != "feature\nmain\nold" && got != "feature\nmaster\nold" {
+		t.Errorf("Expected every branch in the entry, got %q", got)
+	}
+	if meta, err := readCacheMetadata(allPath); err != nil || meta.Branch != allBranches || meta.Tip != "" {
+		t.Errorf("Unexpected metadata %+v (%v)", meta, err)
+	}
+
+	// Fetching drops the branches deleted upstream
+	gitIn(sourcePath, "branch", "-D", "old")
+	cloneCached(t, cloner, sourceURL, CloneOptions{AllBranches: true})
+	if got := gitIn(allPath, "for-each-ref", "--format=%(refname:short)", "refs/heads/old"); got != "" {
+		t.Errorf("Expected the deleted branch to be pruned, got %q", got)
+	}
+
+	// Both entries belong to the repository
+	removed, err := cloner.Prune(context.Background(), []config.Repo{{Name: "source", URL: sourceURL, Branch: "feature"}})
+	if err != nil || len(removed) != 0 {
+		t.Errorf("Expected nothing to be pruned, got %+v (%v)", removed, err)
+	}
+}
+
+func TestCachingGitCloner_Clone_MigratesWorkingTreeCache(t *testing.T) {
+	now := time.Now()
+	sourcePath, _ := initRepoWithCommits(t, datedHistory(now, 3))
+	allowFilter(t, sourcePath)
+	sourceURL := "file://" + sourcePath
+	cacheDir := t.TempDir()
+
+	// Earlier versions cached full clones with a working tree
+	cachePath := filepath.Join(cacheDir, sanitizeRepoName(sourceURL, ""))
+	if output, err := exec.Command("git", "clone", "-q", sourceURL, cachePath).CombinedOutput(); err != nil {
+		t.Fatalf("git clone failed: %v: %s", err, output)
+	}
+
+	repoPath := cloneCached(t, NewCachingGitCloner(cacheDir), sourceURL, CloneOptions{Since: now.AddDate(0, 0, -10)})
+	if repoPath != cachePath {
+		t.Errorf("Expected the existing cache entry %s to be reused, got %s", cachePath, repoPath)
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, ".git")); !os.IsNotExist(err) {
+		t.Error("Expected the cache entry to be converted to a bare repository")
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "file.txt")); !os.IsNotExist(err) {
+		t.Error("Expected the working tree to be removed")
+	}
+	if got := countCommits(t, repoPath); got != 3 {
+		t.Errorf("Expected 3 commits after migration, got %d", got)
+	}
+	output, err := exec.Command("git", "-C", repoPath, "config", "remote.origin.partialclonefilter").Output()
+	if err != nil || strings.TrimSpace(string(output)) != "blob:none" {
+		t.Errorf("Expected later fetches to be blobless, got %q (%v)", output, err)
+	}
+}
+
+func TestMonitor_getRepoCommits_CachedStat(t *testing.T) {
+	now := time.Now()
+	sourcePath, _ := initRepoWithCommits(t, datedHistory(now, 3))
+	allowFilter(t, sourcePath)
+
+	monitor := NewMonitorWithCloner([]config.Repo{}, NewCachingGitCloner(t.TempDir()))
+	monitor.SetDays(10)
+	monitor.SetStat(true)
+	repo := config.Repo{Name: "remote-repo", URL: "file://" + sourcePath}
+
+	commits, err := monitor.getRepoCommits(context.Background(), repo)
+	if err != nil {
+		t.Fatalf("Failed to get commits: %v", err)
+	}
+	if len(commits) != 3 {
+		t.Fatalf("Expected 3 commits, got %v", commitMessages(commits))
+	}
+	for _, c := range commits {
+		if c.Stat == nil || c.Stat.FilesChanged != 1 {
+			t.Errorf("Expected a diffstat for %q from the blobless cache, got %+v", c.Message, c.Stat)
+		}
+	}
+}
+
+func TestCachingGitCloner_Entries(t *testing.T) {
+	sourcePath := t.TempDir()
+	if err := initGitRepoWithBranch(sourcePath, "feature"); err != nil {
+		t.Fatalf("Failed to initialize source repo: %v", err)
+	}
+	sourceURL := "file://" + sourcePath
+	cacheDir := t.TempDir()
+	cloner := NewCachingGitCloner(cacheDir)
+
+	before := time.Now().Add(-time.Second)
+	cloneCached(t, cloner, sourceURL, CloneOptions{Branch: "feature"})
+
+	// An entry from an older version, without metadata
+	legacyPath := filepath.Join(cacheDir, sanitizeRepoName(sourceURL, ""))
+	if output, err := exec.Command("git", "clone", "-q", "--bare", sourceURL, legacyPath).CombinedOutput(); err != nil {
+		t.Fatalf("git clone failed: %v: %s", err, output)
+	}
+
+	entries, err := cloner.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %+v", entries)
+	}
+	for _, entry := range entries {
+		if entry.URL != sourceURL {
+			t.Errorf("Expected URL %s, got %s", sourceURL, entry.URL)
+		}
+		if entry.Size == 0 {
+			t.Errorf("Expected %s to have a size", entry.Name)
+		}
+		switch entry.Path {
+		case legacyPath:
+			if entry.Branch != "" || !entry.CreatedAt.IsZero() {
+				t.Errorf("Expected no branch or creation time for the legacy entry, got %+v", entry)
+			}
+		case filepath.Join(cacheDir, sanitizeRepoName(sourceURL, "feature")):
+			if entry.Branch != "feature" {
+				t.Errorf("Expected branch feature, got %q", entry.Branch)
+			}
+			if entry.CreatedAt.Before(before) || entry.FetchedAt.Before(entry.CreatedAt) {
+				t.Errorf("Expected recorded timestamps, got created %v and fetched %v", entry.CreatedAt, entry.FetchedAt)
+			}
+			if want := filepath.Base(sourcePath) + "#feature"; entry.DisplayName() != want {
+				t.Errorf("Expected display name %s, got %s", want, entry.DisplayName())
+			}
+		default:
+			t.Errorf("Unexpected entry %s", entry.Path)
+		}
+	}
+
+	missing, err := NewCachingGitCloner(filepath.Join(cacheDir, "missing")).Entries()
+	if err != nil || len(missing) != 0 {
+		t.Errorf("Expected no entries for a missing cache directory, got %v (%v)", missing, err)
+	}
+}
+
+func TestCachingGitCloner_Prune(t *testing.T) {
+	sourcePath := t.TempDir()
+	if err := initTestRepo(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize source repo: %v", err)
+	}
+	cacheDir := t.TempDir()
+	keep := config.Repo{Name: "repomon", URL: "https://github.com/plars/repomon", Branch: "main"}
+	keepName := sanitizeRepoName(keep.URL, keep.Branch)
+	staleName := sanitizeRepoName("https://github.com/plars/old", "")
+	for _, name := range []string{keepName, staleName} {
+		if err := os.MkdirAll(filepath.Join(cacheDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recordUse(filepath.Join(cacheDir, staleName), "https://github.com/plars/old", "", true, "", nil)
+
+	// A clone from before metadata was recorded is repomon's when named after its origin;
+	// other directories and clones are not, whatever their contents
+	legacyName := sanitizeRepoName("file://"+sourcePath, "")
+	for _, name := range []string{legacyName, "foreign-clone"} {
+		if output, err := exec.Command("git", "clone", "-q", "--bare", "file://"+sourcePath, filepath.Join(cacheDir, name)).CombinedOutput(); err != nil {
+			t.Fatalf("git clone failed: %v: %s", err, output)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(cacheDir, "notes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cloner := NewCachingGitCloner(cacheDir)
+	removed, err := cloner.Prune(context.Background(), []config.Repo{keep, {Name: "local", Path: "/path/to/local"}})
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	var names []string
+	for _, entry := range removed {
+		names = append(names, entry.Name)
+	}
+	want := []string{legacyName, staleName}
+	sort.Strings(want)
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v to be pruned, got %v", want, names)
+	}
+	for _, name := range []string{keepName, "foreign-clone", "notes"} {
+		if _, err := os.Stat(filepath.Join(cacheDir, name)); err != nil {
+			t.Errorf("Expected %s to be kept: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, staleName)); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", staleName)
+	}
+}
+
+func TestCachingGitCloner_Verify(t *testing.T) {
+	sourcePath := t.TempDir()
+	if err := initTestRepo(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize source repo: %v", err)
+	}
+	cacheDir := t.TempDir()
+	cloner := NewCachingGitCloner(cacheDir)
+	goodPath := cloneCached(t, cloner, "file://"+sourcePath, CloneOptions{})
+	corruptPath := filepath.Join(cacheDir, "corrupt-0123456789abcdef")
+	if err := os.MkdirAll(corruptPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	recordUse(corruptPath, "https://github.com/plars/corrupt", "", true, "", nil)
+	// Directories repomon did not create are not its to check or remove
+	foreignPath := filepath.Join(cacheDir, "notes")
+	if err := os.MkdirAll(foreignPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := cloner.Verify(context.Background())
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(problems) != 1 || problems[0].Entry.Path != corruptPath || problems[0].Err == nil {
+		t.Errorf("Expected only the corrupt entry to fail verification, got %+v", problems)
+	}
+	if _, err := os.Stat(corruptPath); !os.IsNotExist(err) {
+		t.Error("Expected the corrupt entry to be removed")
+	}
+	if _, err := os.Stat(goodPath); err != nil {
+		t.Errorf("Expected the intact entry to be kept: %v", err)
+	}
+	if _, err := os.Stat(foreignPath); err != nil {
+		t.Errorf("Expected the foreign directory to be kept: %v", err)
+	}
+}
+
+func TestCachingGitCloner_Clone_TTL(t *testing.T) {
+	sourcePath := t.TempDir()
+	if err := initTestRepo(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize source repo: %v", err)
+	}
+	cloner := NewCachingGitCloner(t.TempDir())
+	sourceURL := "file://" + sourcePath
+
+	repoPath := cloneCached(t, cloner, sourceURL, CloneOptions{})
+	fetched := cloner.LastFetched(repoPath)
+	if fetched.IsZero() {
+		t.Fatal("Expected the fetch time to be recorded")
+	}
+	before := countCommits(t, repoPath)
+
+	commit := exec.Command("git", "-C", sourcePath, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "New commit")
+	if output, err := commit.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v: %s", err, output)
+	}
+
+	// Within the TTL the cache is used as is
+	cloneCached(t, cloner, sourceURL, CloneOptions{MaxAge: time.Hour})
+	if got := countCommits(t, repoPath); got != before {
+		t.Errorf("Expected no fetch within the TTL, had %d commits and now %d", before, got)
+	}
+	if !cloner.LastFetched(repoPath).Equal(fetched) {
+		t.Error("Expected the fetch time to be unchanged")
+	}
+
+	// Past the TTL it is fetched again
+	cloneCached(t, cloner, sourceURL, CloneOptions{MaxAge: time.Nanosecond})
+	if got := countCommits(t, repoPath); got != before+1 {
+		t.Errorf("Expected a fetch past the TTL, had %d commits and now %d", before, got)
+	}
+}
+
+func TestCachingGitCloner_Clone_TTLDeepensWindow(t *testing.T) {
+	now := time.Now()
+	sourcePath, _ := initRepoWithCommits(t, datedHistory(now, 10))
+	cloner := NewCachingGitCloner(t.TempDir())
+	sourceURL := "file://" + sourcePath
+
+	repoPath := cloneCached(t, cloner, sourceURL, CloneOptions{Since: now.AddDate(0, 0, -2).Add(-time.Hour)})
+	before := countCommits(t, repoPath)
+
+	// A fresh entry that does not reach back far enough is still fetched
+	cloneCached(t, cloner, sourceURL, CloneOptions{Since: now.AddDate(0, 0, -6).Add(-time.Hour), MaxAge: time.Hour})
+	if got := countCommits(t, repoPath); got <= before {
+		t.Errorf("Expected the cache to deepen for a longer window, had %d commits and now %d", before, got)
+	}
+}
+
+func TestCachingGitCloner_Clone_Offline(t *testing.T) {
+	sourcePath := t.TempDir()
+	if err := initTestRepo(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize source repo: %v", err)
+	}
+	cloner := NewCachingGitCloner(t.TempDir())
+	sourceURL := "file://" + sourcePath
+
+	if _, _, err := cloner.Clone(context.Background(), sourceURL, CloneOptions{Offline: true}); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("Expected ErrNotCached for an uncached repository, got %v", err)
+	}
+
+	repoPath := cloneCached(t, cloner, sourceURL, CloneOptions{})
+
+	// The remote is gone, but the cache still serves it offline
+	if err := os.RemoveAll(sourcePath); err != nil {
+		t.Fatal(err)
+	}
+	offlinePath := cloneCached(t, cloner, sourceURL, CloneOptions{Offline: true})
+	if offlinePath != repoPath {
+		t.Errorf("Expected the cached entry %s, got %s", repoPath, offlinePath)
+	}
+}
+
+func TestMonitor_GetRecentCommits_Offline(t *testing.T) {
+	now := time.Now()
+	sourcePath, _ := initRepoWithCommits(t, datedHistory(now, 3))
+	cacheDir := t.TempDir()
+	cached := config.Repo{Name: "cached", URL: "file://" + sourcePath}
+	uncached := config.Repo{Name: "uncached", URL: "file:///nonexistent/repo"}
+
+	cloneCached(t, NewCachingGitCloner(cacheDir), cached.URL, CloneOptions{})
+
+	monitor := NewMonitorWithCloner([]config.Repo{cached, uncached}, NewCachingGitCloner(cacheDir))
+	monitor.SetDays(5)
+	monitor.SetOffline(true)
+	results, err := monitor.GetRecentCommits(context.Background())
+	if err != nil {
+		t.Fatalf("GetRecentCommits failed: %v", err)
+	}
+
+	if results[0].Error != nil || len(results[0].Commits) != 3 {
+		t.Errorf("Expected 3 cached commits, got %v (%v)", commitMessages(results[0].Commits), results[0].Error)
+	}
+	if results[0].FetchedAt.IsZero() {
+		t.Error("Expected the age of the cached data to be reported")
+	}
+	if !errors.Is(results[1].Error, ErrNotCached) {
+		t.Errorf("Expected the uncached repo to be unavailable offline, got %v", results[1].Error)
+	}
+}
+
+func TestCachingGitCloner_Clone_Locking(t *testing.T) {
+	sourcePath := t.TempDir()
+	if err := initTestRepo(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize source repo: %v", err)
+	}
+	sourceURL := "file://" + sourcePath
+	cloner := NewCachingGitCloner(t.TempDir())
+	name := sanitizeRepoName(sourceURL, "")
+
+	// Another process is updating the entry: cloning waits until the context is done
+	lock, err := cloner.lockEntry(context.Background(), name, true)
+	if err != nil {
+		t.Fatalf("Failed to lock entry: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*lockPollInterval)
+	defer cancel()
+	if _, _, err := cloner.Clone(ctx, sourceURL, CloneOptions{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the clone to wait for the lock, got %v", err)
+	}
+	lock.unlock()
+
+	repoPath, cleanup, err := cloner.Clone(context.Background(), sourceURL, CloneOptions{})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+
+	// While the entry is in use it can be read but not removed
+	reader, err := cloner.lockEntry(context.Background(), name, false)
+	if err != nil {
+		t.Fatalf("Expected other readers to be let in: %v", err)
+	}
+	reader.unlock()
+	entry := CacheEntry{Name: name, Path: repoPath}
+	ctx, cancel = context.WithTimeout(context.Background(), 3*lockPollInterval)
+	defer cancel()
+	if err := cloner.Remove(ctx, entry); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected removal to wait for the entry to be released, got %v", err)
+	}
+	if _, err := os.Stat(repoPath); err != nil {
+		t.Fatalf("Expected the entry in use to survive: %v", err)
+	}
+
+	cleanup()
+	if err := cloner.Remove(context.Background(), entry); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(repoPath); !os.IsNotExist(err) {
+		t.Error("Expected the entry to be removed once released")
+	}
+}
+
+func TestCachingGitCloner_cloneToCache_RemovesPartialClone(t *testing.T) {
+	sourcePath := filepath.Join(t.TempDir(), "source")
+	if err := os.MkdirAll(sourcePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initTestRepo(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize repo: %v", err)
+	}
+	cloner := NewCachingGitCloner(t.TempDir())
+	cachePath := filepath.Join(cloner.cacheDir, "partial")
+	// Left behind as if git had been killed halfway
+	if err := os.MkdirAll(filepath.Join(cachePath, "objects"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := cloner.cloneToCache(ctx, sourcePath, cachePath, CloneOptions{}); err == nil {
+		t.Fatal("Expected a cancelled clone to fail")
+	}
+	if _, err := os.Stat(cachePath); !os.IsNotExist(err) {
+		t.Errorf("Expected the partial clone to be removed, got %v", err)
+	}
+}
+
+// cloneCached clones through the cache and releases the entry right away
+func cloneCached(t *testing.T, cloner *CachingGitCloner, repoURL string, opts CloneOptions) string {
+	t.Helper()
+	repoPath, cleanup, err := cloner.Clone(context.Background(), repoURL, opts)
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	cleanup()
+	return repoPath
+}
+
+func TestCachingGitCloner_Evict(t *testing.T) {
+	now := time.Now()
+	cacheDir := t.TempDir()
+	// writeEntry creates a fake cache entry of about size bytes, last used age ago
+	writeEntry := func(name string, size int, age time.Duration) {
+		t.Helper()
+		entryPath := filepath.Join(cacheDir, name)
+		if err := os.MkdirAll(entryPath, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(entryPath, "pack"), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		meta := fmt.Sprintf(`{"url": "https://example.com/%s", "last_used": %q}`, name, now.Add(-age).Format(time.RFC3339))
+		if err := os.WriteFile(filepath.Join(entryPath, cacheMetadataFile), []byte(meta), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := func(entries []CacheEntry) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Name)
+		}
+		return out
+	}
+
+	t.Run("max age", func(t *testing.T) {
+		writeEntry("stale", 100, 40*24*time.Hour)
+		writeEntry("recent", 100, time.Hour)
+		cloner := NewCachingGitCloner(cacheDir)
+
+		evicted, err := cloner.Evict(context.Background(), 0, 30*24*time.Hour)
+		if err != nil {
+			t.Fatalf("Evict failed: %v", err)
+		}
+		if got := names(evicted); len(got) != 1 || got[0] != "stale" {
+			t.Errorf("Expected only the stale entry to be evicted, got %v", got)
+		}
+		if _, err := os.Stat(filepath.Join(cacheDir, "recent")); err != nil {
+			t.Errorf("Expected the recent entry to be kept: %v", err)
+		}
+	})
+
+	t.Run("directories repomon did not create are kept", func(t *testing.T) {
+		cacheDir = t.TempDir()
+		foreignPath := filepath.Join(cacheDir, "notes")
+		if err := os.MkdirAll(foreignPath, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(foreignPath, "todo.txt"), make([]byte, 1000), 0644); err != nil {
+			t.Fatal(err)
+		}
+		old := now.Add(-40 * 24 * time.Hour)
+		if err := os.Chtimes(foreignPath, old, old); err != nil {
+			t.Fatal(err)
+		}
+		cloner := NewCachingGitCloner(cacheDir)
+
+		evicted, err := cloner.Evict(context.Background(), 1, 30*24*time.Hour)
+		if err != nil {
+			t.Fatalf("Evict failed: %v", err)
+		}
+		if len(evicted) != 0 {
+			t.Errorf("Expected nothing to be evicted, got %v", names(evicted))
+		}
+		if _, err := os.Stat(foreignPath); err != nil {
+			t.Errorf("Expected the foreign directory to be kept: %v", err)
+		}
+	})
+
+	t.Run("max size evicts least recently used first", func(t *testing.T) {
+		cacheDir = t.TempDir()
+		writeEntry("oldest", 1000, 3*time.Hour)
+		writeEntry("older", 1000, 2*time.Hour)
+		writeEntry("newest", 1000, time.Hour)
+		cloner := NewCachingGitCloner(cacheDir)
+
+		evicted, err := cloner.Evict(context.Background(), 2500, 0)
+		if err != nil {
+			t.Fatalf("Evict failed: %v", err)
+		}
+		if got := names(evicted); len(got) != 1 || got[0] != "oldest" {
+			t.Errorf("Expected the least recently used entry to be evicted, got %v", got)
+		}
+	})
+
+	t.Run("entries in use are kept", func(t *testing.T) {
+		cacheDir = t.TempDir()
+		writeEntry("used-by-run", 1000, 3*time.Hour)
+		writeEntry("locked", 1000, 2*time.Hour)
+		writeEntry("free", 1000, time.Hour)
+		cloner := NewCachingGitCloner(cacheDir)
+		cloner.used["used-by-run"] = true
+		lock, err := cloner.lockEntry(context.Background(), "locked", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer lock.unlock()
+
+		evicted, err := cloner.Evict(context.Background(), 1, 0)
+		if err != nil {
+			t.Fatalf("Evict failed: %v", err)
+		}
+		if got := names(evicted); len(got) != 1 || got[0] != "free" {
+			t.Errorf("Expected only the free entry to be evicted, got %v", got)
+		}
+	})
+}
+
+func TestMonitor_GetRecentCommits_EvictsCache(t *testing.T) {
+	sourcePath := t.TempDir()
+	if err := initTestRepo(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize source repo: %v", err)
+	}
+	cacheDir := t.TempDir()
+	cloner := NewCachingGitCloner(cacheDir)
+	repo := config.Repo{Name: "repo", URL: "file://" + sourcePath}
+
+	// An entry for a repository dropped from the config, unused for a long time
+	dropped := filepath.Join(cacheDir, "dropped-0123456789abcdef")
+	if err := os.MkdirAll(dropped, 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().AddDate(0, -3, 0)
+	meta := fmt.Sprintf(`{"url": "https://example.com/dropped", "last_used": %q}`, old.Format(time.RFC3339))
+	if err := os.WriteFile(filepath.Join(dropped, cacheMetadataFile), []byte(meta), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor := NewMonitorWithCloner([]config.Repo{repo}, cloner)
+	// Even a tiny size limit keeps what this run used
+	monitor.SetCacheLimits(1, 30*24*time.Hour)
+	results, err := monitor.GetRecentCommits(context.Background())
+	if err != nil || results[0].Error != nil {
+		t.Fatalf("GetRecentCommits failed: %v %v", err, results[0].Error)
+	}
+
+	if _, err := os.Stat(dropped); !os.IsNotExist(err) {
+		t.Error("Expected the unused entry to be evicted")
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, sanitizeRepoName(repo.URL, ""))); err != nil {
+		t.Errorf("Expected the entry used by the run to be kept: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+)
+
+// conventionalHeader matches a Conventional Commits subject line,
+// e.g. "feat(api)!: add endpoint".
+var conventionalHeader = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*)(?:\(([^()]*)\))?(!)?: +(\S.*)$`)
+
+// ConventionalCommit holds the parts of a commit message that follows the
+// Conventional Commits specification (https://www.conventionalcommits.org).
+type ConventionalCommit struct {
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+}
+
+// parseConventionalCommit parses a full commit message as a Conventional Commit.
+// The subject provides the type, scope and description; a '!' after the type/scope
+// or a "BREAKING CHANGE:" footer in the body marks the commit as breaking.
+// It returns nil if the subject does not follow the convention.
+func parseConventionalCommit(message string) *ConventionalCommit {
+	subject := getOneLineCommitMessage(message)
+	m := conventionalHeader.FindStringSubmatch(subject)
+	if m == nil {
+		return nil
+	}
+
+	cc := &ConventionalCommit{
+		Type:        strings.ToLower(m[1]),
+		Scope:       strings.TrimSpace(m[2]),
+		Description: strings.TrimSpace(m[4]),
+		Breaking:    m[3] == "!",
+	}
+	if !cc.Breaking {
+		cc.Breaking = hasBreakingChangeFooter(message)
+	}
+	return cc
+}
+
+// hasBreakingChangeFooter reports whether the message body contains a
+// "BREAKING CHANGE:" (or "BREAKING-CHANGE:") footer.
+func hasBreakingChangeFooter(message string) bool {
+	lines := strings.Split(message, "\n")
+	// The first line is the subject; footers can only appear in the body
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package git
+
+import "testing"
+
+func TestParseConventionalCommit(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    *ConventionalCommit
+	}{
+		{
+			name:    "type only",
+			message: "feat: add login flow",
+			want:    &ConventionalCommit{Type: "feat", Description: "add login flow"},
+		},
+		{
+			name:    "type with scope",
+			message: "fix(parser): handle empty input",
+			want:    &ConventionalCommit{Type: "fix", Scope: "parser", Description: "handle empty input"},
+		},
+		{
+			name:    "breaking marker",
+			message: "refactor(api)!: drop v1 endpoints",
+			want:    &ConventionalCommit{Type: "refactor", Scope: "api", Description: "drop v1 endpoints", Breaking: true},
+		},
+		{
+			name:    "breaking change footer",
+			message: "feat: new config format\n\nMigrates to YAML.\n\nBREAKING CHANGE: the TOML loader was removed",
+			want:    &ConventionalCommit{Type: "feat", Description: "new config format", Breaking: true},
+		},
+		{
+			name:    "breaking change footer with hyphen",
+			message: "chore: bump deps\n\nBREAKING-CHANGE: requires Go 1.25",
+			want:    &ConventionalCommit{Type: "chore", Description: "bump deps", Breaking: true},
+		},
+		{
+			name:    "type is lowercased",
+			message: "Docs: fix typo",
+			want:    &ConventionalCommit{Type: "docs", Description: "fix typo"},
+		},
+		{
+			name:    "non-conforming subject",
+			message: "Add new feature",
+			want:    nil,
+		},
+		{
+			name:    "missing space after colon",
+			message: "feat:add thing",
+			want:    nil,
+		},
+		{
+			name:    "footer mentioned in subject only is not breaking",
+			message: "docs: explain BREAKING CHANGE: footers",
+			want:    &ConventionalCommit{Type: "docs", Description: "explain BREAKING CHANGE: footers"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseConventionalCommit(tt.message)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("parseConventionalCommit(%q) = %+v, want nil", tt.message, got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("parseConventionalCommit(%q) = nil, want %+v", tt.message, tt.want)
+			}
+			if *got != *tt.want {
+				t.Errorf("parseConventionalCommit(%q) = %+v, want %+v", tt.message, got, tt.want)
+			}
+		})
+	}
+}
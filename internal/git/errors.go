@@ -0,0 +1,279 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/plars/repomon/internal/config"
+)
+
+// ErrorKind classifies why a repository could not be read
+type ErrorKind string
+
+// Error kinds
+const (
+	// KindUnknown is any failure not covered by another kind
+	KindUnknown ErrorKind = "unknown"
+	// KindAuthRequired means the server rejected or asked for credentials
+	KindAuthRequired ErrorKind = "auth_required"
+	// KindRepoNotFound means the repository URL or path does not exist
+	KindRepoNotFound ErrorKind = "repo_not_found"
+	// KindBranchNotFound means the configured branch does not exist
+	KindBranchNotFound ErrorKind = "branch_not_found"
+	// KindNetwork means the server could not be reached or failed to respond
+	KindNetwork ErrorKind = "network_unreachable"
+	// KindGitMissing means the git binary is not installed
+	KindGitMissing ErrorKind = "git_missing"
+	// KindPermissionDenied means files of the repository, or of its clone, cannot be read
+	// or written
+	KindPermissionDenied ErrorKind = "permission_denied"
+	// KindNotRepository means a local path is not a git repository
+	KindNotRepository ErrorKind = "not_a_repository"
+	// KindHistoryTruncated means commits needed for the report are missing from a shallow
+	// or cached clone
+	KindHistoryTruncated ErrorKind = "history_truncated"
+	// KindTimeout means the repository took longer than its timeout
+	KindTimeout ErrorKind = "timeout"
+	// KindNotCached means the repository was needed offline but has never been cached
+	KindNotCached ErrorKind = "not_cached"
+	// KindCancelled means the run was interrupted, or hit its deadline, before the
+	// repository was done
+	KindCancelled ErrorKind = "cancelled"
+)
+
+// hints are short suggestions for fixing each kind of error
+var hints = map[ErrorKind]string{
+	KindAuthRequired:     "check your credentials: an SSH key or agent for SSH URLs, an access token (clone.https_token_env) for HTTPS",
+	KindRepoNotFound:     "check the repository URL or path, and that your account can access it",
+	KindBranchNotFound:   "check the branch name, or drop '#branch' to follow the default branch",
+	KindNetwork:          "check your network connection; the server may be down, so try again later or use --offline",
+	KindGitMissing:       "install git, or set 'backend: go-git' in the clone section of the config",
+	KindPermissionDenied: "check the permissions of the repository's files, or of the cache directory",
+	KindNotRepository:    "the path is not a git repository; fix it or remove it with 'repomon rm'",
+	KindHistoryTruncated: "the clone is missing history; clear a cached one with 'repomon cache clear <repo>', or deepen a local one with 'git fetch --unshallow'",
+	KindTimeout:          "the server is slow or hung; raise clone.timeout or try again later",
+	KindNotCached:        "run once without --offline to cache the repository",
+	KindCancelled:        "the run was interrupted or hit its --deadline; run again for a full report",
+}
+
+// Hint returns a short suggestion for fixing errors of this kind, or "" if there is none
+func (k ErrorKind) Hint() string {
+	return hints[k]
+}
+
+// RepoError is a failure to read a repository, classified by kind
+type RepoError struct {
+	Kind ErrorKind
+	Err  error
+}
+
+// Error returns the underlying error without the noise of raw git output: only its first
+// line is kept, along with the last line git reported as fatal
+func (e *RepoError) Error() string {
+	msg := strings.TrimSpace(e.Err.Error())
+	first, rest, ok := strings.Cut(msg, "\n")
+	if !ok {
+		return msg
+	}
+	if i := strings.Index(first, "Cloning into"); i >= 0 {
+		first = first[:i]
+	}
+	first = strings.TrimSuffix(strings.TrimSpace(first), ":")
+
+	var detail string
+	for _, line := range strings.Split(rest, "\n") {
+		line = strings.TrimSpace(line)
+		lower := strings.ToLower(line)
+		if strings.HasPrefix(lower, "fatal:") || strings.HasPrefix(lower, "error:") {
+			detail = line
+		} else if detail == "" && line != "" {
+			detail = line
+		}
+	}
+	if detail == "" {
+		return first
+	}
+	return first + ": " + detail
+}
+
+func (e *RepoError) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the kind of err: that of the RepoError it wraps, or else the kind it is
+// recognized as. It returns "" for a nil error.
+func KindOf(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+	var repoErr *RepoError
+	if errors.As(err, &repoErr) {
+		return repoErr.Kind
+	}
+	return errorKind(err)
+}
+
+// classifyError wraps err in a RepoError of the kind it is recognized as
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var repoErr *RepoError
+	if errors.As(err, &repoErr) {
+		return err
+	}
+	return &RepoError{Kind: errorKind(err), Err: err}
+}
+
+// classifyRepoError is classifyError for a failure to read repo. A local repository
+// needs no credentials, so "permission denied" is about its files, and objects are only
+// missing from it for truncated history if it is a shallow clone.
+func classifyRepoError(repo config.Repo, err error) error {
+	if err == nil {
+		return nil
+	}
+	var repoErr *RepoError
+	if errors.As(err, &repoErr) {
+		return err
+	}
+	kind := errorKind(err)
+	if repo.URL == "" {
+		switch {
+		case kind == KindAuthRequired:
+			kind = KindPermissionDenied
+		case kind == KindHistoryTruncated && !isShallow(repo.Path):
+			kind = KindUnknown
+		}
+	}
+	return &RepoError{Kind: kind, Err: err}
+}
+
+// isShallow reports whether the repository at path is a shallow clone
+func isShallow(path string) bool {
+	gitRepo, err := git.PlainOpen(path)
+	if err != nil {
+		return false
+	}
+	shallow, err := gitRepo.Storer.Shallow()
+	return err == nil && len(shallow) > 0
+}
+
+// kindMessages are fragments of git, go-git and network error messages for each kind,
+// checked in order so that the more specific kinds win
+var kindMessages = []struct {
+	kind      ErrorKind
+	fragments []string
+}{
+	{KindGitMissing, []string{
+		"executable file not found",
+	}},
+	{KindAuthRequired, []string{
+		"authentication failed",
+		"authentication required",
+		"authorization failed",
+		"permission denied",
+		"could not read username",
+		"could not read password",
+		"terminal prompts disabled",
+		"invalid username or password",
+		"host key verification failed",
+	}},
+	{KindBranchNotFound, []string{
+		"not found in upstream",
+		"couldn't find remote ref",
+		"failed to resolve branch",
+		"reference not found",
+	}},
+	{KindRepoNotFound, []string{
+		"repository not found",
+		"could not be found",
+		"repository path does not exist",
+		"does not appear to be a git repository",
+	}},
+	{KindNotRepository, []string{
+		"not a git repository",
+	}},
+	{KindHistoryTruncated, []string{
+		"object not found",
+		"error processing shallow info",
+	}},
+	{KindNetwork, []string{
+		"could not resolve host",
+		"temporary failure in name resolution",
+		"no such host",
+		"network is unreachable",
+		"failed to connect to",
+		"connection reset",
+		"connection refused",
+		"connection timed out",
+		"operation timed out",
+		"early eof",
+		"unexpected eof",
+		"the remote end hung up unexpectedly",
+		"rpc failed",
+		"returned error: 5", // HTTP 5xx from git over HTTPS
+		"http 5",
+		"internal server error",
+		"bad gateway",
+		"service unavailable",
+		"gateway timeout",
+		"tls handshake timeout",
+	}},
+}
+
+// errorKind recognizes the kind of err from the errors it wraps, or failing that from
+// the messages git and go-git print
+func errorKind(err error) ErrorKind {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return KindCancelled
+	case errors.Is(err, ErrNotCached):
+		return KindNotCached
+	case errors.Is(err, context.DeadlineExceeded):
+		return KindTimeout
+	case errors.Is(err, exec.ErrNotFound):
+		return KindGitMissing
+	case errors.Is(err, fs.ErrPermission):
+		return KindPermissionDenied
+	case errors.Is(err, transport.ErrAuthenticationRequired), errors.Is(err, transport.ErrAuthorizationFailed):
+		return KindAuthRequired
+	case errors.Is(err, transport.ErrRepositoryNotFound), errors.Is(err, transport.ErrEmptyRemoteRepository):
+		return KindRepoNotFound
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+		return KindBranchNotFound
+	case errors.Is(err, git.ErrRepositoryNotExists):
+		return KindNotRepository
+	case errors.Is(err, plumbing.ErrObjectNotFound):
+		return KindHistoryTruncated
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, km := range kindMessages {
+		for _, fragment := range km.fragments {
+			if strings.Contains(msg, fragment) {
+				return km.kind
+			}
+		}
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return KindNetwork
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return KindNetwork
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENETUNREACH) {
+		return KindNetwork
+	}
+	return KindUnknown
+}
@@ -0,0 +1,221 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/plars/repomon/internal/config"
+)
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{name: "git auth", err: errors.New("git clone failed: exit status 128: fatal: Authentication failed for 'https://github.com/x/y/'"), want: KindAuthRequired},
+		{name: "ssh key rejected", err: errors.New("git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository."), want: KindAuthRequired},
+		{name: "unknown host key", err: errors.New("Host key verification failed.\nfatal: Could not read from remote repository."), want: KindAuthRequired},
+		{name: "no prompt", err: errors.New("fatal: could not read Username for 'https://github.com': terminal prompts disabled"), want: KindAuthRequired},
+		{name: "go-git auth", err: fmt.Errorf("go-git clone failed: %w", transport.ErrAuthenticationRequired), want: KindAuthRequired},
+		{name: "github not found", err: errors.New("remote: Repository not found.\nfatal: repository 'https://github.com/x/y/' not found"), want: KindRepoNotFound},
+		{name: "local remote missing", err: errors.New("fatal: '/nonexistent/repo' does not appear to be a git repository"), want: KindRepoNotFound},
+		{name: "go-git not found", err: fmt.Errorf("go-git clone failed: %w", transport.ErrRepositoryNotFound), want: KindRepoNotFound},
+		{name: "missing path", err: errors.New("repository path does not exist: /nowhere"), want: KindRepoNotFound},
+		{name: "git branch", err: errors.New("warning: Could not find remote branch nope to clone.\nfatal: Remote branch nope not found in upstream origin"), want: KindBranchNotFound},
+		{name: "go-git branch", err: errors.New("go-git clone failed: couldn't find remote ref refs/heads/nope"), want: KindBranchNotFound},
+		{name: "dns", err: errors.New("fatal: unable to access 'https://git.example.com/x.git/': Could not resolve host: git.example.com"), want: KindNetwork},
+		{name: "http 503", err: errors.New("fatal: unable to access 'https://git.example.com/x.git/': The requested URL returned error: 503"), want: KindNetwork},
+		{name: "git missing", err: fmt.Errorf("git clone failed: %w", &exec.Error{Name: "git", Err: exec.ErrNotFound}), want: KindGitMissing},
+		{name: "unreadable path", err: fmt.Errorf("failed to open git repository: %w", &fs.PathError{Op: "open", Path: "/srv/repo", Err: syscall.EACCES}), want: KindPermissionDenied},
+		{name: "shallow history", err: errors.New("failed to iterate commits: failed to get parent commit abc: object not found"), want: KindHistoryTruncated},
+		{name: "deadline", err: fmt.Errorf("fetch: %w", context.DeadlineExceeded), want: KindTimeout},
+		{name: "not cached", err: ErrNotCached, want: KindNotCached},
+		{name: "cancelled", err: fmt.Errorf("git fetch failed: %w", context.Canceled), want: KindCancelled},
+		{name: "unknown", err: errors.New("something odd"), want: KindUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorKind(tt.err); got != tt.want {
+				t.Errorf("errorKind(%q) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepoError(t *testing.T) {
+	err := classifyError(fmt.Errorf("failed to clone remote repository: %w",
+		errors.New("git clone failed: exit status 128: Cloning into '/tmp/repomon-123'...\nremote: Repository not found.\nfatal: repository 'https://github.com/x/y/' not found\n")))
+
+	want := "failed to clone remote repository: git clone failed: exit status 128: fatal: repository 'https://github.com/x/y/' not found"
+	if err.Error() != want {
+		t.Errorf("Expected %q, got %q", want, err.Error())
+	}
+	if KindOf(err) != KindRepoNotFound || KindOf(err).Hint() == "" {
+		t.Errorf("Expected a repository not found error with a hint, got %s", KindOf(err))
+	}
+	if KindOf(fmt.Errorf("wrapped: %w", err)) != KindRepoNotFound {
+		t.Error("Expected the kind to survive wrapping")
+	}
+	if classifyError(err) != err {
+		t.Error("Expected a classified error to be kept as is")
+	}
+	if !errors.Is(classifyError(ErrNotCached), ErrNotCached) {
+		t.Error("Expected classified errors to unwrap")
+	}
+	if KindOf(errors.New("plain")) != KindUnknown || KindUnknown.Hint() != "" {
+		t.Error("Expected unclassified errors to be unknown, without a hint")
+	}
+}
+
+func TestClassifyRepoError(t *testing.T) {
+	localPath, _ := initRepoWithCommits(t, datedHistory(time.Now(), 3))
+	shallowPath := filepath.Join(t.TempDir(), "shallow")
+	if output, err := exec.Command("git", "clone", "-q", "--depth", "1", "file://"+localPath, shallowPath).CombinedOutput(); err != nil {
+		t.Fatalf("git clone failed: %v: %s", err, output)
+	}
+	denied := errors.New("fatal: could not open '.git/index': Permission denied")
+	missing := fmt.Errorf("failed to get parent commit: %w", plumbing.ErrObjectNotFound)
+
+	tests := []struct {
+		name string
+		repo config.Repo
+		err  error
+		want ErrorKind
+	}{
+		{name: "remote permission denied", repo: config.Repo{URL: "git@github.com:x/y.git"}, err: denied, want: KindAuthRequired},
+		{name: "local permission denied", repo: config.Repo{Path: localPath}, err: denied, want: KindPermissionDenied},
+		{name: "remote object missing", repo: config.Repo{URL: "https://github.com/x/y"}, err: missing, want: KindHistoryTruncated},
+		{name: "local object missing", repo: config.Repo{Path: localPath}, err: missing, want: KindUnknown},
+		{name: "shallow local object missing", repo: config.Repo{Path: shallowPath}, err: missing, want: KindHistoryTruncated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KindOf(classifyRepoError(tt.repo, tt.err)); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMonitor_GetRecentCommits_ErrorKinds(t *testing.T) {
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	if err := os.MkdirAll(repoPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initTestRepo(repoPath); err != nil {
+		t.Fatalf("Failed to initialize repo: %v", err)
+	}
+
+	repos := []config.Repo{
+		{Name: "missing", Path: filepath.Join(t.TempDir(), "missing")},
+		{Name: "plain-dir", Path: t.TempDir()},
+		{Name: "bad-branch", Path: repoPath, Branch: "nope"},
+		{Name: "private", URL: "https://github.com/x/private"},
+	}
+	monitor := NewMonitorWithCloner(repos, &mockGitCloner{cloneErr: errors.New("fatal: Authentication failed")})
+	results, err := monitor.GetRecentCommits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ErrorKind{KindRepoNotFound, KindNotRepository, KindBranchNotFound, KindAuthRequired}
+	for i, kind := range want {
+		if got := KindOf(results[i].Error); got != kind {
+			t.Errorf("%s: expected %s, got %s (%v)", results[i].Repo.Name, kind, got, results[i].Error)
+		}
+	}
+	// The cloner's error is reported as it is, without repeating what failed
+	if got, want := results[3].Error.Error(), "failed to clone remote repository: fatal: Authentication failed"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestMonitor_GetRecentCommits_Cancelled(t *testing.T) {
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	if err := os.MkdirAll(repoPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initTestRepo(repoPath); err != nil {
+		t.Fatalf("Failed to initialize repo: %v", err)
+	}
+
+	repos := []config.Repo{
+		{Name: "local", Path: repoPath},
+		{Name: "hung", URL: "https://github.com/x/hung"},
+	}
+	monitor := NewMonitorWithCloner(repos, &mockGitCloner{hang: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	results, err := monitor.GetRecentCommits(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Error != nil || len(results[0].Commits) == 0 {
+		t.Errorf("Expected the local repository to finish, got %+v", results[0])
+	}
+	if KindOf(results[1].Error) != KindCancelled {
+		t.Errorf("Expected the hung repository to be cancelled, got %v", results[1].Error)
+	}
+
+	// Repositories not started before cancellation are cancelled too
+	results, err = monitor.GetRecentCommits(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if KindOf(result.Error) != KindCancelled {
+			t.Errorf("%s: expected cancelled, got %v", result.Repo.Name, result.Error)
+		}
+	}
+}
+
+func TestMonitor_GetRecentCommits_CancelledWaiting(t *testing.T) {
+	// With one fetch slot, the second repository waits behind the hung first one
+	repos := []config.Repo{
+		{Name: "hung", URL: "https://github.com/x/hung"},
+		{Name: "waiting", URL: "https://github.com/x/waiting"},
+	}
+	monitor := NewMonitorWithCloner(repos, &mockGitCloner{hang: true})
+	monitor.SetConcurrency(1, nil)
+	progress := &recordingProgress{}
+	monitor.SetProgress(progress)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	results, err := monitor.GetRecentCommits(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if KindOf(result.Error) != KindCancelled {
+			t.Errorf("%s: expected cancelled, got %v", result.Repo.Name, result.Error)
+		}
+	}
+	// Only one of them got a slot; the other gave up waiting without starting
+	started := 0
+	for _, call := range progress.calls {
+		if strings.HasPrefix(call, "started ") {
+			started++
+		}
+	}
+	if started != 1 {
+		t.Errorf("Expected one repository to start, got %v", progress.calls)
+	}
+}
@@ -0,0 +1,40 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// gitWaitDelay bounds how long a cancelled git command may hold on to its output
+const gitWaitDelay = 5 * time.Second
+
+// gitCommand returns a command running git with args. When ctx is done, git is killed
+// along with the processes it started, such as git-remote-https. As those may run away
+// from the terminal (see killGroupOnCancel), git and ssh are told never to prompt: a
+// missing passphrase, password or host key fails at once instead of hanging.
+func gitCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if ssh := batchSSHCommand(); ssh != "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND="+ssh)
+	}
+	killGroupOnCancel(cmd)
+	cmd.WaitDelay = gitWaitDelay
+	return cmd
+}
+
+// batchSSHCommand returns the SSH command for git to run in batch mode: the user's
+// GIT_SSH_COMMAND, or ssh. It returns "" when GIT_SSH names a program instead, whose
+// options are unknown.
+func batchSSHCommand() string {
+	command := os.Getenv("GIT_SSH_COMMAND")
+	if command == "" {
+		if os.Getenv("GIT_SSH") != "" {
+			return ""
+		}
+		command = "ssh"
+	}
+	return command + " -o BatchMode=yes"
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package git
+
+import "os/exec"
+
+// killGroupOnCancel leaves cmd as is: only git itself is killed on this platform
+func killGroupOnCancel(cmd *exec.Cmd) {}
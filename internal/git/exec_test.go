@@ -0,0 +1,46 @@
+package git
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGitCommand_NoPrompts(t *testing.T) {
+	tests := []struct {
+		name       string
+		sshCommand string
+		ssh        string
+		want       string
+	}{
+		{name: "default", want: "GIT_SSH_COMMAND=ssh -o BatchMode=yes"},
+		{name: "user command kept", sshCommand: "ssh -i ~/.ssh/deploy", want: "GIT_SSH_COMMAND=ssh -i ~/.ssh/deploy -o BatchMode=yes"},
+		{name: "GIT_SSH program left alone", ssh: "/usr/local/bin/my-ssh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GIT_SSH_COMMAND", tt.sshCommand)
+			t.Setenv("GIT_SSH", tt.ssh)
+
+			env := gitCommand(context.Background(), "version").Env
+			if !slices.Contains(env, "GIT_TERMINAL_PROMPT=0") {
+				t.Errorf("Expected terminal prompts to be disabled, got %v", env)
+			}
+			// The last value of a variable is the one the command gets
+			var got string
+			for _, kv := range env {
+				if strings.HasPrefix(kv, "GIT_SSH_COMMAND=") {
+					got = kv
+				}
+			}
+			if tt.want == "" {
+				tt.want = "GIT_SSH_COMMAND=" + tt.sshCommand
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
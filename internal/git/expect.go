@@ -0,0 +1,62 @@
+package git
+
+import (
+	"fmt"
+	"time"
+)
+
+// Expectation is an activity assertion for a repository, such as one a CI job uses to
+// flag abandoned dependencies
+type Expectation struct {
+	// MinCommits is the fewest commits expected in the window
+	MinCommits int
+	// MaxAge is the longest the repository may go without a commit
+	MaxAge time.Duration
+}
+
+// Check returns how result falls short of the expectation, or nil if it does not.
+// Repositories that could not be read are not checked.
+func (e Expectation) Check(result RepoResult, now time.Time) []string {
+	if result.Error != nil {
+		return nil
+	}
+	var violations []string
+	if len(result.Commits) < e.MinCommits {
+		violations = append(violations, fmt.Sprintf("expected at least %d commits in the window, got %d", e.MinCommits, len(result.Commits)))
+	}
+	if e.MaxAge > 0 {
+		if result.LastCommit.IsZero() {
+			violations = append(violations, "expected a commit within "+formatAge(e.MaxAge)+", found none")
+		} else if age := now.Sub(result.LastCommit); age > e.MaxAge {
+			violations = append(violations, fmt.Sprintf("expected a commit within %s, last was %s ago", formatAge(e.MaxAge), formatAge(age)))
+		}
+	}
+	return violations
+}
+
+// Dormant reports whether the repository of result has gone without a commit for longer
+// than after. Repositories that could not be read, or have no commits, are not dormant.
+func Dormant(result RepoResult, now time.Time, after time.Duration) bool {
+	if after <= 0 || DormancyUnknown(result, after) {
+		return false
+	}
+	return now.Sub(result.LastCommit) > after
+}
+
+// DormancyUnknown reports whether dormancy is checked for, with after, but the repository
+// of result could not be read or has no commits to tell it by
+func DormancyUnknown(result RepoResult, after time.Duration) bool {
+	return after > 0 && (result.Error != nil || result.LastCommit.IsZero())
+}
+
+// formatAge formats a duration in whole days, or in hours and minutes below a day
+func formatAge(d time.Duration) string {
+	if d >= 24*time.Hour {
+		days := int(d / (24 * time.Hour))
+		if days == 1 {
+			return "1 day"
+		}
+		return fmt.Sprintf("%d days", days)
+	}
+	return d.Round(time.Minute).String()
+}
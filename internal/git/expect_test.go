@@ -0,0 +1,117 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/plars/repomon/internal/config"
+)
+
+func TestExpectation_Check(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	commits := []Commit{{Hash: "a"}, {Hash: "b"}}
+
+	tests := []struct {
+		name   string
+		expect Expectation
+		result RepoResult
+		want   []string
+	}{
+		{name: "met", expect: Expectation{MinCommits: 2, MaxAge: 14 * 24 * time.Hour}, result: RepoResult{Commits: commits, LastCommit: now.Add(-time.Hour)}},
+		{name: "too few commits", expect: Expectation{MinCommits: 3}, result: RepoResult{Commits: commits},
+			want: []string{"expected at least 3 commits in the window, got 2"}},
+		{name: "stale", expect: Expectation{MaxAge: 14 * 24 * time.Hour}, result: RepoResult{LastCommit: now.AddDate(0, 0, -30)},
+			want: []string{"expected a commit within 14 days, last was 30 days ago"}},
+		{name: "no commits at all", expect: Expectation{MaxAge: 12 * time.Hour}, result: RepoResult{},
+			want: []string{"expected a commit within 12h0m0s, found none"}},
+		{name: "both", expect: Expectation{MinCommits: 1, MaxAge: 24 * time.Hour}, result: RepoResult{LastCommit: now.AddDate(0, 0, -2)},
+			want: []string{"expected at least 1 commits in the window, got 0", "expected a commit within 1 day, last was 2 days ago"}},
+		{name: "errors are not checked", expect: Expectation{MinCommits: 1}, result: RepoResult{Error: errors.New("boom")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.expect.Check(tt.result, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDormant(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	after := 90 * 24 * time.Hour
+
+	tests := []struct {
+		name   string
+		result RepoResult
+		after  time.Duration
+		want   bool
+	}{
+		{name: "active", result: RepoResult{LastCommit: now.AddDate(0, 0, -30)}, after: after},
+		{name: "dormant", result: RepoResult{LastCommit: now.AddDate(-2, 0, 0)}, after: after, want: true},
+		{name: "not configured", result: RepoResult{LastCommit: now.AddDate(-2, 0, 0)}},
+		{name: "no commits", result: RepoResult{}, after: after},
+		{name: "errors are not checked", result: RepoResult{LastCommit: now.AddDate(-2, 0, 0), Error: errors.New("boom")}, after: after},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Dormant(tt.result, now, tt.after); got != tt.want {
+				t.Errorf("Dormant() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDormancyUnknown(t *testing.T) {
+	after := 90 * 24 * time.Hour
+	lastCommit := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		result RepoResult
+		after  time.Duration
+		want   bool
+	}{
+		{name: "checked", result: RepoResult{LastCommit: lastCommit}, after: after},
+		{name: "no commits", result: RepoResult{}, after: after, want: true},
+		{name: "error", result: RepoResult{LastCommit: lastCommit, Error: errors.New("boom")}, after: after, want: true},
+		{name: "not configured", result: RepoResult{Error: errors.New("boom")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DormancyUnknown(tt.result, tt.after); got != tt.want {
+				t.Errorf("DormancyUnknown() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonitor_GetRecentCommits_LastCommit(t *testing.T) {
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	if err := os.MkdirAll(repoPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initTestRepoWithOldCommit(repoPath); err != nil {
+		t.Fatalf("Failed to initialize repo: %v", err)
+	}
+
+	monitor := NewMonitorWithRepos([]config.Repo{{Name: "repo", Path: repoPath}})
+	results, err := monitor.GetRecentCommits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results[0].Commits) != 0 {
+		t.Fatalf("Expected no commits in the window, got %d", len(results[0].Commits))
+	}
+	if results[0].LastCommit.IsZero() || time.Since(results[0].LastCommit) < 24*time.Hour {
+		t.Errorf("Expected the date of the old tip commit, got %v", results[0].LastCommit)
+	}
+}
@@ -0,0 +1,122 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/plars/repomon/internal/config"
+)
+
+// fetchUpstream fetches the upstream branch of a local repository's branch, or of HEAD if
+// branch is empty, into its remote-tracking branch, and returns the name of that. Only the
+// remote-tracking branch is written: the working tree and local branches are left alone.
+// It returns "" if the branch tracks no remote branch.
+func fetchUpstream(ctx context.Context, gitRepo *git.Repository, path, branch string) (plumbing.ReferenceName, error) {
+	if branch == "" {
+		head, err := gitRepo.Head()
+		if err != nil {
+			return "", fmt.Errorf("failed to get HEAD reference: %w", err)
+		}
+		if !head.Name().IsBranch() {
+			slog.Debug("HEAD is detached, nothing to fetch", "path", path)
+			return "", nil
+		}
+		branch = head.Name().Short()
+	}
+
+	branchCfg, err := gitRepo.Branch(branch)
+	if errors.Is(err, git.ErrBranchNotFound) {
+		slog.Debug("Branch has no upstream, nothing to fetch", "path", path, "branch", branch)
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read branch configuration: %w", err)
+	}
+	// A remote of "." tracks another local branch
+	if branchCfg.Remote == "" || branchCfg.Remote == "." || branchCfg.Merge == "" {
+		slog.Debug("Branch has no upstream, nothing to fetch", "path", path, "branch", branch)
+		return "", nil
+	}
+
+	cfg, err := gitRepo.Config()
+	if err != nil {
+		return "", fmt.Errorf("failed to read repository configuration: %w", err)
+	}
+	remote := cfg.Remotes[branchCfg.Remote]
+	if remote == nil {
+		return "", fmt.Errorf("remote %q of branch %s is not configured", branchCfg.Remote, branch)
+	}
+	var tracking plumbing.ReferenceName
+	for _, spec := range remote.Fetch {
+		if spec.Match(branchCfg.Merge) {
+			tracking = spec.Dst(branchCfg.Merge)
+			break
+		}
+	}
+	if !tracking.IsRemote() {
+		return "", fmt.Errorf("upstream %s of branch %s is not fetched into a remote-tracking branch", branchCfg.Merge.Short(), branch)
+	}
+
+	// An explicit refspec writes only the remote-tracking branch, whatever else the remote
+	// is configured to fetch
+	refspec := fmt.Sprintf("+%s:%s", branchCfg.Merge, tracking)
+	if output, err := runGit(ctx, path, "fetch", "--quiet", "--no-tags", "--no-write-fetch-head", "--no-recurse-submodules", branchCfg.Remote, refspec); err != nil {
+		return "", fmt.Errorf("git fetch failed: %w: %s", err, output)
+	}
+	return tracking, nil
+}
+
+// incomingHashes returns the commits reachable from upstream but not from local, newest
+// first, as "git rev-list local..upstream" lists them
+func incomingHashes(ctx context.Context, path string, local, upstream plumbing.Hash) ([]plumbing.Hash, error) {
+	output, err := runGit(ctx, path, "rev-list", local.String()+".."+upstream.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incoming commits: %w: %s", err, output)
+	}
+	var hashes []plumbing.Hash
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if line := scanner.Text(); plumbing.IsHash(line) {
+			hashes = append(hashes, plumbing.NewHash(line))
+		}
+	}
+	return hashes, scanner.Err()
+}
+
+// collectIncoming fills in the commits in the window that the remote-tracking branch
+// tracking of a local repository has and the local branch at local does not
+func (m *Monitor) collectIncoming(ctx context.Context, repo config.Repo, local plumbing.Hash, tracking plumbing.ReferenceName, cutoff time.Time, result *RepoResult) error {
+	// Opened again, so that the fetched objects and references are seen
+	gitRepo, err := git.PlainOpen(repo.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open git repository: %w", err)
+	}
+	ref, err := gitRepo.Reference(tracking, true)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", tracking.Short(), err)
+	}
+	result.IncomingFrom = tracking.Short()
+
+	hashes, err := incomingHashes(ctx, repo.Path, local, ref.Hash())
+	if err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		c, err := gitRepo.CommitObject(hash)
+		if err != nil {
+			return fmt.Errorf("failed to get commit %s: %w", hash, err)
+		}
+		when := commitDate(c, m.dateField)
+		if m.inWindow(when, cutoff) {
+			result.Incoming = append(result.Incoming, m.newCommit(ctx, repo, c, when))
+		}
+	}
+	return nil
+}
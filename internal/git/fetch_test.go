@@ -0,0 +1,97 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/plars/repomon/internal/config"
+)
+
+func TestMonitor_GetRecentCommits_Fetch(t *testing.T) {
+	sourcePath := filepath.Join(t.TempDir(), "source")
+	if err := os.MkdirAll(sourcePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initTestRepo(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize repo: %v", err)
+	}
+	repoPath := filepath.Join(t.TempDir(), "work")
+	if output, err := exec.Command("git", "clone", "-q", sourcePath, repoPath).CombinedOutput(); err != nil {
+		t.Fatalf("git clone failed: %v: %s", err, output)
+	}
+	gitIn := func(dir string, args ...string) string {
+		t.Helper()
+		args = append([]string{"-C", dir, "-c", "user.name=Test User", "-c", "user.email=test@example.com"}, args...)
+		output, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	gitIn(sourcePath, "commit", "-q", "--allow-empty", "-m", "Upstream work")
+	gitIn(repoPath, "commit", "-q", "--allow-empty", "-m", "Local work")
+	localHead := gitIn(repoPath, "rev-parse", "HEAD")
+	branch := gitIn(repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+
+	monitor := NewMonitorWithRepos([]config.Repo{{Name: "work", Path: repoPath}})
+	monitor.SetStatus(true)
+	results, err := monitor.GetRecentCommits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Incoming != nil || results[0].IncomingFrom != "" {
+		t.Errorf("Expected no incoming commits without fetching, got %+v", results[0].Incoming)
+	}
+
+	monitor = NewMonitorWithRepos([]config.Repo{{Name: "work", Path: repoPath, Fetch: true}})
+	monitor.SetStatus(true)
+	results, err = monitor.GetRecentCommits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := results[0]
+	if result.Error != nil || result.FetchError != nil {
+		t.Fatalf("Unexpected errors: %v, %v", result.Error, result.FetchError)
+	}
+	if result.IncomingFrom != "origin/"+branch || len(result.Incoming) != 1 || result.Incoming[0].Message != "Upstream work" {
+		t.Errorf("Expected the upstream commit as incoming from origin/%s, got %q %+v", branch, result.IncomingFrom, result.Incoming)
+	}
+	if len(result.Commits) != 2 || result.Commits[0].Message != "Local work" {
+		t.Errorf("Expected the local commits apart from incoming ones, got %+v", result.Commits)
+	}
+	if result.Status == nil || result.Status.Ahead != 1 || result.Status.Behind != 1 {
+		t.Errorf("Expected the status to count the fetched commits, got %+v", result.Status)
+	}
+
+	// Fetching must leave local branches and the working tree alone
+	if head := gitIn(repoPath, "rev-parse", "HEAD"); head != localHead {
+		t.Errorf("Expected HEAD to stay at %s, got %s", localHead, head)
+	}
+	content, err := os.ReadFile(filepath.Join(repoPath, "test.txt"))
+	if err != nil || string(content) != "test content" {
+		t.Errorf("Expected the working tree to be untouched, got %q (%v)", content, err)
+	}
+}
+
+func TestMonitor_GetRecentCommits_FetchWithoutUpstream(t *testing.T) {
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	if err := os.MkdirAll(repoPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initTestRepo(repoPath); err != nil {
+		t.Fatalf("Failed to initialize repo: %v", err)
+	}
+
+	monitor := NewMonitorWithRepos([]config.Repo{{Name: "repo", Path: repoPath, Fetch: true}})
+	results, err := monitor.GetRecentCommits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := results[0]; result.Error != nil || result.FetchError != nil || result.IncomingFrom != "" || len(result.Commits) != 1 {
+		t.Errorf("Expected only local commits for a repository without upstream, got %+v", result)
+	}
+}
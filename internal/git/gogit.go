@@ -0,0 +1,336 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// Clone backends
+const (
+	// BackendGit clones with the git binary
+	BackendGit = "git"
+	// BackendGoGit clones with go-git, without needing a git binary
+	BackendGoGit = "go-git"
+)
+
+// Storage modes for the go-git backend
+const (
+	// StorageMemory keeps clones in memory
+	StorageMemory = "memory"
+	// StorageDisk writes clones to a temporary directory, for repositories too large for memory
+	StorageDisk = "disk"
+)
+
+// maxGoGitDepth is the deepest shallow clone tried before fetching full history. Clones
+// held in memory stop there, as every file of every commit fetched is held too.
+var maxGoGitDepth = 6400
+
+// ValidBackend reports whether backend is a supported clone backend
+func ValidBackend(backend string) bool {
+	return backend == BackendGit || backend == BackendGoGit
+}
+
+// ValidStorage reports whether storage is a supported go-git storage mode
+func ValidStorage(storage string) bool {
+	return storage == StorageMemory || storage == StorageDisk
+}
+
+// DefaultBackend returns the git backend when a git binary is installed, and go-git otherwise
+func DefaultBackend() string {
+	if _, err := exec.LookPath("git"); err != nil {
+		return BackendGoGit
+	}
+	return BackendGit
+}
+
+// RepositoryCloner is implemented by cloners that can hand over an opened repository
+// directly, such as one held in memory. The monitor prefers it to Clone.
+type RepositoryCloner interface {
+	CloneRepository(ctx context.Context, repoURL string, opts CloneOptions) (repo *git.Repository, cleanup func(), err error)
+}
+
+// GoGitAuth holds the credentials used by the go-git backend
+type GoGitAuth struct {
+	// SSHKey is an unencrypted private key file for SSH URLs. If empty, the SSH agent is used.
+	SSHKey string
+	// HTTPSUsername and HTTPSToken are basic auth credentials for HTTPS URLs.
+	// Most hosts accept a personal access token with any non-empty username.
+	HTTPSUsername string
+	HTTPSToken    string
+}
+
+// GoGitCloner implements GitCloner and RepositoryCloner with go-git, so no git binary
+// is needed. Clones are single-branch, without tags and without a checkout, but go-git
+// cannot leave out file contents: every version of every file within the fetched depth
+// is downloaded, into memory unless the storage is StorageDisk.
+type GoGitCloner struct {
+	storage string
+	auth    GoGitAuth
+}
+
+// NewGoGitCloner creates a GoGitCloner using the given storage mode
+func NewGoGitCloner(storage string, auth GoGitAuth) *GoGitCloner {
+	if storage == "" {
+		storage = StorageMemory
+	}
+	return &GoGitCloner{storage: storage, auth: auth}
+}
+
+// Clone clones into a temporary directory and returns its path
+func (c *GoGitCloner) Clone(ctx context.Context, repoURL string, opts CloneOptions) (string, func(), error) {
+	tempDir, err := os.MkdirTemp("", "repomon-*")
+	if err != nil {
+		return "", func() {}, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(tempDir) }
+
+	if _, err := c.clone(ctx, repoURL, tempDir, opts); err != nil {
+		cleanup()
+		return "", func() {}, err
+	}
+	return tempDir, cleanup, nil
+}
+
+// CloneRepository clones into the configured storage and returns the opened repository
+func (c *GoGitCloner) CloneRepository(ctx context.Context, repoURL string, opts CloneOptions) (*git.Repository, func(), error) {
+	if c.storage == StorageDisk {
+		repoPath, cleanup, err := c.Clone(ctx, repoURL, opts)
+		if err != nil {
+			return nil, func() {}, err
+		}
+		repo, err := git.PlainOpen(repoPath)
+		if err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("failed to open cloned repository: %w", err)
+		}
+		return repo, cleanup, nil
+	}
+
+	repo, err := c.clone(ctx, repoURL, "", opts)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	return repo, func() {}, nil
+}
+
+// clone clones repoURL into dir, or into memory if dir is empty.
+// go-git cannot fetch by date, so the clone is deepened with increasing depth until
+// its history reaches back to opts.Since. In memory, it is not deepened past
+// maxGoGitDepth: the window is then reported as truncated.
+func (c *GoGitCloner) clone(ctx context.Context, repoURL, dir string, opts CloneOptions) (*git.Repository, error) {
+	if opts.Offline {
+		return nil, ErrNotCached
+	}
+	auth, err := c.authFor(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	cloneOpts := &git.CloneOptions{
+		URL:          repoURL,
+		Auth:         auth,
+		SingleBranch: true,
+		NoCheckout:   true,
+		Tags:         git.NoTags,
+		Depth:        defaultCloneDepth,
+	}
+	if opts.Branch != "" {
+		cloneOpts.ReferenceName = plumbing.NewBranchReferenceName(opts.Branch)
+	}
+	if opts.AllBranches {
+		return cloneBranches(ctx, dir, cloneOpts)
+	}
+
+	repo, err := cloneInto(ctx, dir, cloneOpts)
+	if err != nil {
+		return nil, fmt.Errorf("go-git clone failed: %w", err)
+	}
+	depth := cloneOpts.Depth
+	for depth != 0 && !opts.Since.IsZero() {
+		covered, err := historyCovers(repo, opts.Since)
+		if err != nil {
+			return nil, err
+		}
+		if covered {
+			break
+		}
+
+		if depth >= maxGoGitDepth {
+			if dir == "" {
+				slog.Warn("Not fetching more history into memory, use clone.storage disk for the whole window", "url", repoURL, "depth", depth)
+				break
+			}
+			// The deepest depth git itself asks for to unshallow a repository
+			depth = math.MaxInt32
+		} else {
+			depth *= 4
+		}
+		slog.Debug("Shallow clone does not reach the window start, fetching deeper", "url", repoURL, "depth", depth)
+		err = repo.FetchContext(ctx, &git.FetchOptions{
+			Auth:  auth,
+			Depth: depth,
+			Tags:  git.NoTags,
+			Force: true,
+		})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return nil, fmt.Errorf("go-git fetch failed: %w", err)
+		}
+		if err := trimShallow(repo); err != nil {
+			return nil, err
+		}
+		if depth == math.MaxInt32 {
+			break
+		}
+	}
+	return repo, nil
+}
+
+// cloneBranches clones the whole history of the default branch, and only the tip of
+// each other branch that is not in it. go-git cannot leave out file contents, so cloning
+// the history of every branch would fetch every version of every file in them.
+func cloneBranches(ctx context.Context, dir string, cloneOpts *git.CloneOptions) (*git.Repository, error) {
+	cloneOpts.Depth = 0
+	repo, err := cloneInto(ctx, dir, cloneOpts)
+	if err != nil {
+		return nil, fmt.Errorf("go-git clone failed: %w", err)
+	}
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get remote: %w", err)
+	}
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: cloneOpts.Auth})
+	if err != nil {
+		return nil, fmt.Errorf("go-git failed to list branches: %w", err)
+	}
+
+	var tips []gitconfig.RefSpec
+	for _, ref := range refs {
+		if !ref.Name().IsBranch() || ref.Type() != plumbing.HashReference {
+			continue
+		}
+		if _, err := repo.Storer.EncodedObject(plumbing.CommitObject, ref.Hash()); err == nil {
+			// Already in the history of the default branch
+			if err := repo.Storer.SetReference(ref); err != nil {
+				return nil, fmt.Errorf("failed to store branch %s: %w", ref.Name().Short(), err)
+			}
+			continue
+		}
+		tips = append(tips, gitconfig.RefSpec("+"+ref.Name().String()+":"+ref.Name().String()))
+	}
+	if len(tips) == 0 {
+		return repo, nil
+	}
+	err = repo.FetchContext(ctx, &git.FetchOptions{
+		RefSpecs: tips,
+		Auth:     cloneOpts.Auth,
+		Depth:    1,
+		Tags:     git.NoTags,
+		Force:    true,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("go-git fetch failed: %w", err)
+	}
+	return repo, nil
+}
+
+// cloneInto clones into a bare repository in dir, or into memory if dir is empty
+func cloneInto(ctx context.Context, dir string, opts *git.CloneOptions) (*git.Repository, error) {
+	if dir == "" {
+		return git.CloneContext(ctx, memory.NewStorage(), nil, opts)
+	}
+	// Start from an empty directory, discarding anything left by an earlier attempt
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	return git.PlainCloneContext(ctx, dir, true, opts)
+}
+
+// trimShallow drops the commits whose parents a deepening fetch brought in from the
+// shallow commits, which go-git only ever adds to
+func trimShallow(repo *git.Repository) error {
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return fmt.Errorf("failed to read shallow commits: %w", err)
+	}
+	var kept []plumbing.Hash
+	for _, h := range shallow {
+		c, err := repo.CommitObject(h)
+		if err != nil {
+			return fmt.Errorf("failed to get shallow commit %s: %w", h, err)
+		}
+		for _, parent := range c.ParentHashes {
+			if _, err := repo.Storer.EncodedObject(plumbing.CommitObject, parent); err != nil {
+				kept = append(kept, h)
+				break
+			}
+		}
+	}
+	return repo.Storer.SetShallow(kept)
+}
+
+// historyCovers reports whether a shallow repository holds every commit committed at or
+// after since, which is the case when each commit at the shallow boundary predates it
+func historyCovers(repo *git.Repository, since time.Time) (bool, error) {
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return false, fmt.Errorf("failed to read shallow commits: %w", err)
+	}
+	for _, h := range shallow {
+		c, err := repo.CommitObject(h)
+		if err != nil {
+			return false, fmt.Errorf("failed to get shallow commit %s: %w", h, err)
+		}
+		if !c.Committer.When.Before(since) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// authFor returns the credentials to use for repoURL, or nil to use go-git's defaults
+// (the SSH agent for SSH URLs, anonymous access otherwise)
+func (c *GoGitCloner) authFor(repoURL string) (transport.AuthMethod, error) {
+	ep, err := transport.NewEndpoint(repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository URL %q: %w", repoURL, err)
+	}
+
+	switch ep.Protocol {
+	case "ssh":
+		if c.auth.SSHKey == "" {
+			return nil, nil
+		}
+		user := ep.User
+		if user == "" {
+			user = ssh.DefaultUsername
+		}
+		keys, err := ssh.NewPublicKeysFromFile(user, c.auth.SSHKey, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SSH key %s: %w", c.auth.SSHKey, err)
+		}
+		return keys, nil
+	case "http", "https":
+		if c.auth.HTTPSToken == "" {
+			return nil, nil
+		}
+		user := c.auth.HTTPSUsername
+		if user == "" {
+			user = "git"
+		}
+		return &http.BasicAuth{Username: user, Password: c.auth.HTTPSToken}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,242 @@
+package git
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/plars/repomon/internal/config"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func TestGoGitCloner_Interface(t *testing.T) {
+	var _ GitCloner = &GoGitCloner{}
+	var _ RepositoryCloner = &GoGitCloner{}
+}
+
+func TestGoGitCloner_CloneRepository(t *testing.T) {
+	now := time.Now()
+	sourcePath, hashes := initRepoWithCommits(t, datedHistory(now, 150))
+	sourceURL := "file://" + sourcePath
+
+	t.Run("memory storage without a window uses the default depth", func(t *testing.T) {
+		repo, cleanup, err := NewGoGitCloner(StorageMemory, GoGitAuth{}).CloneRepository(context.Background(), sourceURL, CloneOptions{})
+		if err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		defer cleanup()
+
+		head, err := repo.Head()
+		if err != nil {
+			t.Fatalf("Failed to get HEAD: %v", err)
+		}
+		if head.Hash() != hashes[len(hashes)-1] {
+			t.Errorf("Expected HEAD %s, got %s", hashes[len(hashes)-1], head.Hash())
+		}
+		shallow, err := repo.Storer.Shallow()
+		if err != nil {
+			t.Fatalf("Failed to read shallow commits: %v", err)
+		}
+		if len(shallow) != 1 || shallow[0] != hashes[len(hashes)-defaultCloneDepth] {
+			t.Errorf("Expected a shallow boundary %d commits deep, got %v", defaultCloneDepth, shallow)
+		}
+	})
+
+	t.Run("deepens until the window is covered", func(t *testing.T) {
+		since := now.AddDate(0, 0, -130).Add(-time.Hour)
+		repo, cleanup, err := NewGoGitCloner(StorageMemory, GoGitAuth{}).CloneRepository(context.Background(), sourceURL, CloneOptions{Since: since})
+		if err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		defer cleanup()
+
+		covered, err := historyCovers(repo, since)
+		if err != nil {
+			t.Fatalf("Failed to check history: %v", err)
+		}
+		if !covered {
+			t.Error("Expected the clone to reach back to the window start")
+		}
+	})
+
+	t.Run("deepens to the whole history", func(t *testing.T) {
+		since := now.AddDate(-1, 0, 0)
+		repo, cleanup, err := NewGoGitCloner(StorageDisk, GoGitAuth{}).CloneRepository(context.Background(), sourceURL, CloneOptions{Since: since})
+		if err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		defer cleanup()
+
+		if _, err := repo.CommitObject(hashes[0]); err != nil {
+			t.Errorf("Expected the root commit in the clone: %v", err)
+		}
+		shallow, err := repo.Storer.Shallow()
+		if err != nil {
+			t.Fatalf("Failed to read shallow commits: %v", err)
+		}
+		if len(shallow) != 0 {
+			t.Errorf("Expected a complete history, got shallow commits %v", shallow)
+		}
+	})
+
+	t.Run("memory storage stops deepening at the cap", func(t *testing.T) {
+		defer func(depth int) { maxGoGitDepth = depth }(maxGoGitDepth)
+		maxGoGitDepth = defaultCloneDepth
+
+		repo, cleanup, err := NewGoGitCloner(StorageMemory, GoGitAuth{}).CloneRepository(context.Background(), sourceURL, CloneOptions{Since: now.AddDate(-1, 0, 0)})
+		if err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		defer cleanup()
+
+		shallow, err := repo.Storer.Shallow()
+		if err != nil {
+			t.Fatalf("Failed to read shallow commits: %v", err)
+		}
+		if len(shallow) != 1 || shallow[0] != hashes[len(hashes)-defaultCloneDepth] {
+			t.Errorf("Expected the clone to stay %d commits deep, got shallow commits %v", defaultCloneDepth, shallow)
+		}
+	})
+
+	t.Run("disk storage", func(t *testing.T) {
+		repo, cleanup, err := NewGoGitCloner(StorageDisk, GoGitAuth{}).CloneRepository(context.Background(), sourceURL, CloneOptions{Since: now.AddDate(0, 0, -3)})
+		if err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		if _, err := repo.CommitObject(hashes[len(hashes)-1]); err != nil {
+			t.Errorf("Expected the tip commit in the clone: %v", err)
+		}
+		cleanup()
+	})
+
+	t.Run("missing repository fails", func(t *testing.T) {
+		_, cleanup, err := NewGoGitCloner(StorageMemory, GoGitAuth{}).CloneRepository(context.Background(), "file:///nonexistent/repo", CloneOptions{})
+		defer cleanup()
+		if err == nil {
+			t.Error("Expected error when cloning a missing repository")
+		}
+	})
+}
+
+func TestGoGitCloner_Clone_Path(t *testing.T) {
+	sourcePath := t.TempDir()
+	if err := initGitRepoWithBranch(sourcePath, "feature"); err != nil {
+		t.Fatalf("Failed to initialize repo with branch: %v", err)
+	}
+
+	cloner := NewGoGitCloner(StorageDisk, GoGitAuth{})
+	repoPath, cleanup, err := cloner.Clone(context.Background(), "file://"+sourcePath, CloneOptions{Branch: "feature"})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+
+	// Clones are bare: only metadata, no checkout
+	if _, err := os.Stat(filepath.Join(repoPath, "HEAD")); err != nil {
+		t.Errorf("Expected a bare repository at %s: %v", repoPath, err)
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "feature.txt")); !os.IsNotExist(err) {
+		t.Error("Expected no checked out files")
+	}
+
+	cleanup()
+	if _, err := os.Stat(repoPath); !os.IsNotExist(err) {
+		t.Error("Expected cleanup to remove the clone")
+	}
+}
+
+func TestMonitor_getRepoCommits_GoGitCloner(t *testing.T) {
+	now := time.Now()
+	sourcePath, _ := initRepoWithCommits(t, datedHistory(now, 10))
+
+	monitor := NewMonitorWithCloner([]config.Repo{}, NewGoGitCloner(StorageMemory, GoGitAuth{}))
+	monitor.SetDays(5)
+	repo := config.Repo{Name: "remote-repo", URL: "file://" + sourcePath}
+
+	var result RepoResult
+	if err := monitor.collectRepo(context.Background(), repo, &result); err != nil {
+		t.Fatalf("Failed to collect repo: %v", err)
+	}
+	if len(result.Commits) != 4 {
+		t.Errorf("Expected 4 commits, got %v", commitMessages(result.Commits))
+	}
+	if result.Truncated {
+		t.Error("Expected complete history")
+	}
+}
+
+func TestGoGitCloner_authFor(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519")
+	writeTestSSHKey(t, keyPath)
+
+	tests := []struct {
+		name     string
+		auth     GoGitAuth
+		url      string
+		wantType string
+		wantUser string
+		wantErr  bool
+	}{
+		{name: "anonymous https", url: "https://github.com/plars/repomon"},
+		{name: "https token", auth: GoGitAuth{HTTPSToken: "secret"}, url: "https://github.com/plars/repomon", wantType: "basic", wantUser: "git"},
+		{name: "https token with username", auth: GoGitAuth{HTTPSUsername: "oauth2", HTTPSToken: "secret"}, url: "https://gitlab.com/group/project.git", wantType: "basic", wantUser: "oauth2"},
+		{name: "ssh agent by default", auth: GoGitAuth{HTTPSToken: "secret"}, url: "git@github.com:plars/repomon.git"},
+		{name: "ssh key", auth: GoGitAuth{SSHKey: keyPath}, url: "git@github.com:plars/repomon.git", wantType: "ssh", wantUser: "git"},
+		{name: "ssh key with user", auth: GoGitAuth{SSHKey: keyPath}, url: "ssh://deploy@git.example.com/repo.git", wantType: "ssh", wantUser: "deploy"},
+		{name: "missing ssh key", auth: GoGitAuth{SSHKey: filepath.Join(t.TempDir(), "missing")}, url: "git@github.com:plars/repomon.git", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := NewGoGitCloner("", tt.auth).authFor(tt.url)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			switch a := auth.(type) {
+			case nil:
+				if tt.wantType != "" {
+					t.Errorf("Expected %s auth, got none", tt.wantType)
+				}
+			case *http.BasicAuth:
+				if tt.wantType != "basic" || a.Username != tt.wantUser {
+					t.Errorf("Expected %s auth for %q, got basic auth for %q", tt.wantType, tt.wantUser, a.Username)
+				}
+			case *ssh.PublicKeys:
+				if tt.wantType != "ssh" || a.User != tt.wantUser {
+					t.Errorf("Expected %s auth for %q, got SSH key auth for %q", tt.wantType, tt.wantUser, a.User)
+				}
+			default:
+				t.Errorf("Unexpected auth method %T", auth)
+			}
+		})
+	}
+}
+
+// writeTestSSHKey writes a new unencrypted OpenSSH private key to path
+func writeTestSSHKey(t *testing.T, path string) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := gossh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
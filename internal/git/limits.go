@@ -0,0 +1,95 @@
+package git
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/plars/repomon/internal/config"
+)
+
+// DefaultJobs is the default number of remote repositories collected at once
+const DefaultJobs = 10
+
+// repoLimiter bounds how many repositories are collected at once. Remote repositories
+// count against a global limit and against the limit of their host, if it has one.
+// Local repositories need no network, so they only share a limit sized to the CPUs.
+type repoLimiter struct {
+	remote chan struct{}
+	local  chan struct{}
+	hosts  map[string]chan struct{}
+}
+
+// newRepoLimiter creates a repoLimiter allowing jobs remote repositories at once, and at
+// most hostLimits[host] for each listed host
+func newRepoLimiter(jobs int, hostLimits map[string]int) *repoLimiter {
+	if jobs <= 0 {
+		jobs = DefaultJobs
+	}
+	l := &repoLimiter{
+		remote: make(chan struct{}, jobs),
+		local:  make(chan struct{}, runtime.NumCPU()),
+		hosts:  make(map[string]chan struct{}, len(hostLimits)),
+	}
+	for host, limit := range hostLimits {
+		if limit > 0 {
+			l.hosts[strings.ToLower(host)] = make(chan struct{}, limit)
+		}
+	}
+	return l
+}
+
+// acquire waits until repo may be collected and returns the function releasing its
+// slots. It gives up, holding no slot, when ctx is done first.
+func (l *repoLimiter) acquire(ctx context.Context, repo config.Repo) (func(), error) {
+	if repo.URL == "" {
+		if err := take(ctx, l.local); err != nil {
+			return nil, err
+		}
+		return func() { <-l.local }, nil
+	}
+
+	// Take the host slot first, so waiting on a busy host does not hold a global slot
+	host := repoHost(repo.URL)
+	hostSem := l.hosts[host]
+	if hostSem != nil {
+		if err := take(ctx, hostSem); err != nil {
+			return nil, err
+		}
+	}
+	if err := take(ctx, l.remote); err != nil {
+		if hostSem != nil {
+			<-hostSem
+		}
+		return nil, err
+	}
+	slog.Debug("Acquired fetch slot", "repo", repo.Name, "host", host)
+
+	return func() {
+		<-l.remote
+		if hostSem != nil {
+			<-hostSem
+		}
+	}, nil
+}
+
+// take waits for a slot of sem until ctx is done
+func take(ctx context.Context, sem chan struct{}) error {
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// repoHost returns the lower-cased host name of a repository URL, or "" if it has none
+func repoHost(repoURL string) string {
+	ep, err := transport.NewEndpoint(repoURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(ep.Host)
+}
@@ -0,0 +1,97 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/plars/repomon/internal/config"
+)
+
+func TestRepoHost(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/plars/repomon":            "github.com",
+		"https://GitLab.Example.com:8443/group/x.git": "gitlab.example.com",
+		"git@gitlab.example.com:group/project.git":    "gitlab.example.com",
+		"ssh://deploy@git.example.com:2222/repo.git":  "git.example.com",
+		"file:///srv/git/repo.git":                    "",
+	}
+	for url, want := range tests {
+		if got := repoHost(url); got != want {
+			t.Errorf("repoHost(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+// acquired reports whether acquire returns within a short time, releasing the slot if so
+func acquired(l *repoLimiter, repo config.Repo) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	release, err := l.acquire(ctx, repo)
+	if err != nil {
+		return false
+	}
+	release()
+	return true
+}
+
+// mustAcquire acquires the slots of repo, which must be free
+func mustAcquire(t *testing.T, l *repoLimiter, repo config.Repo) func() {
+	t.Helper()
+	release, err := l.acquire(context.Background(), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return release
+}
+
+func TestRepoLimiter(t *testing.T) {
+	limited := config.Repo{Name: "a", URL: "https://gitlab.example.com/group/a.git"}
+	other := config.Repo{Name: "b", URL: "https://github.com/plars/b"}
+	local := config.Repo{Name: "local", Path: "/path/to/local"}
+
+	t.Run("per-host limit", func(t *testing.T) {
+		l := newRepoLimiter(3, map[string]int{"GitLab.example.com": 1})
+		release := mustAcquire(t, l, limited)
+		if acquired(l, limited) {
+			t.Error("Expected a second fetch from the limited host to wait")
+		}
+		if !acquired(l, other) {
+			t.Error("Expected other hosts to be unaffected by the host limit")
+		}
+		release()
+		if !acquired(l, limited) {
+			t.Error("Expected the host slot to be free once released")
+		}
+	})
+
+	t.Run("global limit", func(t *testing.T) {
+		l := newRepoLimiter(2, nil)
+		first, second := mustAcquire(t, l, other), mustAcquire(t, l, limited)
+		if acquired(l, other) {
+			t.Error("Expected a third remote fetch to wait for the global limit")
+		}
+		if !acquired(l, local) {
+			t.Error("Expected local repositories not to count against the network limit")
+		}
+		first()
+		second()
+	})
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		l := newRepoLimiter(3, map[string]int{"gitlab.example.com": 1})
+		release := mustAcquire(t, l, limited)
+		defer release()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := l.acquire(ctx, limited); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected the wait to be cancelled, got %v", err)
+		}
+		// The cancelled wait holds no slot, so the other two global slots are free
+		second := mustAcquire(t, l, other)
+		defer second()
+		if !acquired(l, other) {
+			t.Error("Expected the cancelled wait to hold no global slot")
+		}
+	})
+}
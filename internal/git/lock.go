@@ -0,0 +1,89 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// lockPollInterval is how often a busy lock is retried
+const lockPollInterval = 100 * time.Millisecond
+
+// fileLock is an advisory lock on a file. The operating system releases it when the
+// process exits, so a crashed run never leaves a cache entry locked.
+type fileLock struct {
+	f *os.File
+}
+
+// lockFile locks path, creating it if needed. An exclusive lock waits for every other
+// holder; a shared lock only for an exclusive one. Waiting stops when ctx is done.
+func lockFile(ctx context.Context, path string, exclusive bool) (*fileLock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := waitLock(ctx, f, exclusive); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileLock{f: f}, nil
+}
+
+// waitLock locks f, polling while the lock is held elsewhere until ctx is done
+func waitLock(ctx context.Context, f *os.File, exclusive bool) error {
+	waiting := false
+	for {
+		ok, err := tryLock(f, exclusive)
+		if err != nil {
+			return fmt.Errorf("failed to lock %s: %w", f.Name(), err)
+		}
+		if ok {
+			return nil
+		}
+		if !waiting {
+			slog.Debug("Waiting for lock held by another process", "path", f.Name())
+			waiting = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for lock on %s: %w", f.Name(), ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// tryLockFile is lockFile without waiting: ok is false if the lock is held elsewhere
+func tryLockFile(path string, exclusive bool) (lock *fileLock, ok bool, err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	ok, err = tryLock(f, exclusive)
+	if err != nil || !ok {
+		f.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		return nil, false, nil
+	}
+	return &fileLock{f: f}, true, nil
+}
+
+// share turns an exclusive lock into a shared one, letting other readers in. The
+// conversion is not atomic, so another process may take the lock exclusively in
+// between; share then waits for it until ctx is done, and the lock is lost on error.
+func (l *fileLock) share(ctx context.Context) error {
+	if err := waitLock(ctx, l.f, false); err != nil {
+		return fmt.Errorf("failed to downgrade lock: %w", err)
+	}
+	return nil
+}
+
+// unlock releases the lock
+func (l *fileLock) unlock() {
+	_ = unlockFile(l.f)
+	l.f.Close()
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package git
+
+import "os"
+
+// tryLock always succeeds: cache entries are not locked on this platform
+func tryLock(f *os.File, exclusive bool) (bool, error) {
+	return true, nil
+}
+
+// unlockFile is a no-op on this platform
+func unlockFile(f *os.File) error {
+	return nil
+}
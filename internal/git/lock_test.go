@@ -0,0 +1,120 @@
+//go:build unix
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entry.lock")
+	ctx := context.Background()
+
+	first, err := lockFile(ctx, path, false)
+	if err != nil {
+		t.Fatalf("Failed to take shared lock: %v", err)
+	}
+	second, err := lockFile(ctx, path, false)
+	if err != nil {
+		t.Fatalf("Expected shared locks to coexist: %v", err)
+	}
+
+	// An exclusive lock waits for every shared holder, until its context is done
+	timeout, cancel := context.WithTimeout(ctx, 3*lockPollInterval)
+	defer cancel()
+	if _, err := lockFile(timeout, path, true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the exclusive lock to time out, got %v", err)
+	}
+
+	first.unlock()
+	second.unlock()
+	exclusive, err := lockFile(ctx, path, true)
+	if err != nil {
+		t.Fatalf("Failed to take exclusive lock after release: %v", err)
+	}
+	exclusive.unlock()
+}
+
+func TestFileLock_Share(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entry.lock")
+	ctx := context.Background()
+
+	lock, err := lockFile(ctx, path, true)
+	if err != nil {
+		t.Fatalf("Failed to take exclusive lock: %v", err)
+	}
+	defer lock.unlock()
+	if err := lock.share(ctx); err != nil {
+		t.Fatalf("Failed to downgrade lock: %v", err)
+	}
+
+	// Other readers get in, but writers still wait
+	reader, ok, err := tryLockFile(path, false)
+	if err != nil || !ok {
+		t.Fatalf("Expected a shared lock alongside the downgraded one, got %v, %v", ok, err)
+	}
+	reader.unlock()
+	if _, ok, err := tryLockFile(path, true); err != nil || ok {
+		t.Errorf("Expected the exclusive lock to be held off, got %v, %v", ok, err)
+	}
+}
+
+func TestLockFile_ReleasedOnCrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entry.lock")
+
+	// Hold the lock in a child process, then kill it
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLockFile_HelperProcess$")
+	cmd.Env = append(os.Environ(), "REPOMON_LOCK_HELPER="+path)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start helper: %v", err)
+	}
+	defer func() { _ = cmd.Process.Kill() }()
+	// The helper prints once it holds the lock
+	if _, err := fmt.Fscanln(stdout); err != nil {
+		t.Fatalf("Helper did not take the lock: %v", err)
+	}
+
+	timeout, cancel := context.WithTimeout(context.Background(), 3*lockPollInterval)
+	defer cancel()
+	if _, err := lockFile(timeout, path, true); err == nil {
+		t.Fatal("Expected the lock to be held by the helper")
+	}
+
+	if err := cmd.Process.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	_ = cmd.Wait()
+
+	timeout, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lock, err := lockFile(timeout, path, true)
+	if err != nil {
+		t.Fatalf("Expected the lock to be released when its holder died: %v", err)
+	}
+	lock.unlock()
+}
+
+// TestLockFile_HelperProcess holds a lock until killed, for TestLockFile_ReleasedOnCrash
+func TestLockFile_HelperProcess(t *testing.T) {
+	path := os.Getenv("REPOMON_LOCK_HELPER")
+	if path == "" {
+		t.Skip("helper process")
+	}
+	if _, err := lockFile(context.Background(), path, true); err != nil {
+		os.Exit(1)
+	}
+	fmt.Println()
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
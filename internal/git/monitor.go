@@ -1,12 +1,12 @@
 package git
 
 import (
+	"bytes"
 	"context"
-	"crypto/sha256"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -16,176 +16,276 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/plars/repomon/internal/config"
-	"github.com/schollz/progressbar/v3"
 )
 
-const maxConcurrentRepos = 10
-
 // Commit represents a git commit
 type Commit struct {
 	Hash      string
 	Message   string
 	Author    string
 	Timestamp time.Time
+	// Body is the commit message after the subject line, including trailers
+	Body string
+	// Trailers holds the "Key: value" trailers from the end of the message
+	Trailers Trailers
+	// CoAuthors lists the names from Co-authored-by trailers
+	CoAuthors []string
+	// Conventional is the parsed Conventional Commits header, or nil if the
+	// message does not follow the convention.
+	Conventional *ConventionalCommit
+	// Stat is the commit's diffstat, or nil if stats were not requested or
+	// could not be computed (e.g. the parent is beyond a shallow clone boundary).
+	Stat *DiffStat
+	// Signature is the result of signature verification, or nil if verification is disabled
+	Signature *CommitSignature
+}
+
+// Authors returns the commit author followed by any co-authors
+func (c Commit) Authors() []string {
+	authors := make([]string, 0, 1+len(c.CoAuthors))
+	authors = append(authors, c.Author)
+	for _, name := range c.CoAuthors {
+		if name != c.Author {
+			authors = append(authors, name)
+		}
+	}
+	return authors
+}
+
+// HasAuthor reports whether the author or a co-author of the commit matches pattern,
+// ignoring case. Like "git log --author", part of a name matches.
+func (c Commit) HasAuthor(pattern string) bool {
+	pattern = strings.ToLower(pattern)
+	for _, name := range c.Authors() {
+		if strings.Contains(strings.ToLower(name), pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterByAuthor returns the commits with an author or co-author matching any of
+// patterns (see Commit.HasAuthor), or all of them if there are no patterns
+func FilterByAuthor(commits []Commit, patterns []string) []Commit {
+	if len(patterns) == 0 {
+		return commits
+	}
+	var matched []Commit
+	for _, commit := range commits {
+		for _, pattern := range patterns {
+			if commit.HasAuthor(pattern) {
+				matched = append(matched, commit)
+				break
+			}
+		}
+	}
+	return matched
 }
 
 // RepoResult represents result for a single repository
 type RepoResult struct {
 	Repo    config.Repo
 	Commits []Commit
-	Error   error
+	// Error is a *RepoError, whose kind tells why the repository could not be read
+	Error error
+	// Truncated is set when the history walk reached the shallow boundary of the
+	// repository before leaving the window, so older in-window commits may be missing
+	Truncated bool
+	// FetchedAt is set when the result comes from a cached clone that was not fetched
+	// in this run, to when it was last fetched from the remote
+	FetchedAt time.Time
+	// Attempts is how many times the clone was tried, for remote repositories
+	Attempts int
+	// Duration is the time spent collecting the repository
+	Duration time.Duration
+	// LastCommit is the date of the newest commit on the branch, in or out of the window
+	LastCommit time.Time
+	// Rewrite is set when the last fetch of a cached clone found the branch's history
+	// rewritten, such as by a force push
+	Rewrite *Rewrite
+	// Violations describes how the repository falls short of its expectations
+	Violations []string
+	// Dormant is set when the repository has gone without a commit for longer than the
+	// configured dormant_after (see Dormant)
+	Dormant bool
+	// Idle is how long the repository had gone without a commit when it was checked for
+	// dormancy, so the report does not age it further. It is zero if it was not checked.
+	Idle time.Duration
+	// DormancyUnknown is set when dormant_after is configured but the repository could
+	// not be checked, because it could not be read or has no commits
+	DormancyUnknown bool
+	// Status is the working tree status, for local repositories when asked for
+	Status *WorkTreeStatus
+	// Incoming are the commits in the window on the upstream branch of a fetched local
+	// repository that its local branch does not have yet, newest first
+	Incoming []Commit
+	// IncomingFrom is the remote-tracking branch Incoming come from, e.g. "origin/main"
+	IncomingFrom string
+	// FetchError is set when a local repository could not be fetched, so Incoming may be
+	// missing or out of date. The local commits are reported regardless.
+	FetchError error
+	// DefaultBranch and Branches are filled in by the stale branch report (see
+	// GetStaleBranches) instead of the commits
+	DefaultBranch string
+	Branches      []Branch
 }
 
 // GitCloner defines the interface for cloning git repositories.
 // Clone returns the path to the cloned repo and a cleanup function to call when done.
+// When opts.Since is set, the clone must contain every commit on the branch committed
+// at or after Since; older history may be cut off at a shallow boundary.
 type GitCloner interface {
-	Clone(ctx context.Context, repoURL, branch string) (repoPath string, cleanup func(), err error)
+	Clone(ctx context.Context, repoURL string, opts CloneOptions) (repoPath string, cleanup func(), err error)
 }
 
-// RealGitCloner implements GitCloner using the git binary
-type RealGitCloner struct{}
-
-func (c *RealGitCloner) Clone(ctx context.Context, repoURL, branch string) (string, func(), error) {
-	tempDir, err := os.MkdirTemp("", "repomon-*")
-	if err != nil {
-		return "", func() {}, fmt.Errorf("failed to create temp directory: %w", err)
-	}
-	cleanup := func() { os.RemoveAll(tempDir) }
-
-	args := []string{
-		"-c", "filter.lfs.smudge=",
-		"-c", "filter.lfs.clean=",
-		"-c", "filter.lfs.process=",
-		"-c", "filter.lfs.required=false",
-		"clone", repoURL, tempDir, "--depth", "100", "--no-tags",
-	}
-	if branch != "" {
-		args = append(args, "--branch", branch)
-	}
-	cmd := exec.CommandContext(ctx, "git", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		cleanup()
-		return "", func() {}, fmt.Errorf("git clone failed: %w: %s", err, output)
-	}
-	return tempDir, cleanup, nil
+// CloneOptions controls what a GitCloner fetches
+type CloneOptions struct {
+	// Branch to clone, or the remote's default branch if empty
+	Branch string
+	// Since is the oldest committer date the caller needs history for.
+	// A zero Since fetches the last defaultCloneDepth commits.
+	Since time.Time
+	// Blobs asks for the file contents of the commits since Since, for features that
+	// diff commits. Cloners that fetch only metadata must fetch them when set.
+	Blobs bool
+	// MaxAge lets a cloner use a cached clone without fetching if it was fetched
+	// within MaxAge and reaches back to Since
+	MaxAge time.Duration
+	// Offline forbids network access: only clones already cached may be used, and
+	// ErrNotCached is returned for the others
+	Offline bool
+	// AllBranches asks for the head of every branch instead, with the whole history of
+	// commits to tell which are merged into Branch. File contents are not needed, and
+	// Since and Blobs do not apply.
+	AllBranches bool
+	// ClockSkew is how far committer dates may be out of order, for cloners that check
+	// the history they fetch for rewrites (see SetClockSkew)
+	ClockSkew time.Duration
 }
 
-// CachingGitCloner implements GitCloner with local caching
-type CachingGitCloner struct {
-	cacheDir string
-}
+// ErrNotCached is returned in offline mode for repositories that have never been cached
+var ErrNotCached = errors.New("not available offline: the repository has not been cached yet")
 
-// NewCachingGitCloner creates a CachingGitCloner
-func NewCachingGitCloner(cacheDir string) *CachingGitCloner {
-	return &CachingGitCloner{
-		cacheDir: cacheDir,
-	}
+// FetchRecorder is implemented by cloners that may return a clone without fetching it.
+// LastFetched returns when the clone at repoPath was last fetched, or zero if unknown.
+type FetchRecorder interface {
+	LastFetched(repoPath string) time.Time
 }
 
-func (c *CachingGitCloner) Clone(ctx context.Context, repoURL, branch string) (string, func(), error) {
-	cacheName := sanitizeRepoName(repoURL, branch)
-	cachePath := filepath.Join(c.cacheDir, cacheName)
+// defaultCloneDepth is the clone depth used when no window start is given
+const defaultCloneDepth = 100
 
-	if _, err := os.Stat(cachePath); err == nil {
-		slog.Debug("Using cached repository", "path", cachePath)
-		if err := c.fetchUpdates(ctx, cachePath, branch); err != nil {
-			slog.Warn("Fetch failed, re-cloning", "error", err)
-			if err := os.RemoveAll(cachePath); err != nil {
-				slog.Warn("Failed to remove broken cache", "error", err)
-			}
-		} else {
-			return cachePath, func() {}, nil
-		}
+// historyArgs returns the clone/fetch arguments that limit history to what opts needs
+func historyArgs(opts CloneOptions) []string {
+	if opts.AllBranches {
+		return nil
 	}
-
-	if err := c.cloneToCache(ctx, repoURL, cachePath, branch); err != nil {
-		return "", func() {}, err
+	if opts.Since.IsZero() {
+		return []string{"--depth", strconv.Itoa(defaultCloneDepth)}
 	}
+	return []string{"--shallow-since=" + opts.Since.UTC().Format(time.RFC3339)}
+}
 
-	return cachePath, func() {}, nil
+// noShallowCommits reports whether git rejected a --shallow-since request because the
+// branch has no commits after the date. The tip alone is then all the history needed.
+func noShallowCommits(output []byte) bool {
+	return bytes.Contains(output, []byte("no commits selected for shallow requests"))
 }
 
-func (c *CachingGitCloner) fetchUpdates(ctx context.Context, repoPath, branch string) error {
-	cmd := exec.CommandContext(ctx, "git", "fetch", "origin")
-	cmd.Dir = repoPath
-	cmd.Env = append(os.Environ(), "GIT_LFS_SKIP_SMUDGE=1")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("git fetch failed: %w: %s", err, output)
+// gitClone clones repoURL into dest, fetching only the history opts needs.
+// extraArgs are passed on to git clone.
+func gitClone(ctx context.Context, repoURL, dest string, opts CloneOptions, extraArgs ...string) error {
+	clone := func(history []string) ([]byte, error) {
+		args := []string{
+			"-c", "filter.lfs.smudge=",
+			"-c", "filter.lfs.clean=",
+			"-c", "filter.lfs.process=",
+			"-c", "filter.lfs.required=false",
+			"clone", repoURL, dest, "--no-tags",
+		}
+		args = append(args, extraArgs...)
+		args = append(args, history...)
+		if opts.Branch != "" {
+			args = append(args, "--branch", opts.Branch)
+		}
+		return gitCommand(ctx, args...).CombinedOutput()
 	}
 
-	// Reset working tree to match the fetched remote state
-	resetRef := "origin/HEAD"
-	if branch != "" {
-		resetRef = "origin/" + branch
+	output, err := clone(historyArgs(opts))
+	if err != nil && noShallowCommits(output) {
+		slog.Debug("No commits since window start, cloning tip only", "url", repoURL, "since", opts.Since)
+		output, err = clone([]string{"--depth", "1"})
 	}
-	cmd = exec.CommandContext(ctx, "git", "reset", "--hard", resetRef)
-	cmd.Dir = repoPath
-	cmd.Env = append(os.Environ(), "GIT_LFS_SKIP_SMUDGE=1")
-	output, err = cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("git reset failed: %w: %s", err, output)
+		return fmt.Errorf("git clone failed: %w: %s", err, output)
 	}
 	return nil
 }
 
-func (c *CachingGitCloner) cloneToCache(ctx context.Context, repoURL, cachePath, branch string) error {
-	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
-		return fmt.Errorf("failed to create cache directory: %w", err)
-	}
+// RealGitCloner implements GitCloner using the git binary
+type RealGitCloner struct{}
 
-	args := []string{
-		"-c", "filter.lfs.smudge=",
-		"-c", "filter.lfs.clean=",
-		"-c", "filter.lfs.process=",
-		"-c", "filter.lfs.required=false",
-		"clone", repoURL, cachePath, "--depth", "100", "--no-tags",
+func (c *RealGitCloner) Clone(ctx context.Context, repoURL string, opts CloneOptions) (string, func(), error) {
+	if opts.Offline {
+		return "", func() {}, ErrNotCached
 	}
-	if branch != "" {
-		args = append(args, "--branch", branch)
-	}
-	cmd := exec.CommandContext(ctx, "git", args...)
-	output, err := cmd.CombinedOutput()
+	tempDir, err := os.MkdirTemp("", "repomon-*")
 	if err != nil {
-		return fmt.Errorf("git clone failed: %w: %s", err, output)
+		return "", func() {}, fmt.Errorf("failed to create temp directory: %w", err)
 	}
-	return nil
-}
+	cleanup := func() { os.RemoveAll(tempDir) }
 
-func sanitizeRepoName(repoURL, branch string) string {
-	key := repoURL
-	if branch != "" {
-		key = key + "#" + branch
+	var extraArgs []string
+	if opts.AllBranches {
+		// Every branch, but only its commits
+		extraArgs = []string{"--bare", "--filter=tree:0"}
+	}
+	if err := gitClone(ctx, repoURL, tempDir, opts, extraArgs...); err != nil {
+		cleanup()
+		return "", func() {}, err
 	}
-	h := sha256.Sum256([]byte(key))
-	// Use repo basename for readability + hash prefix for uniqueness
-	base := strings.TrimSuffix(filepath.Base(repoURL), ".git")
-	return fmt.Sprintf("%s-%x", base, h[:8])
+	return tempDir, cleanup, nil
 }
 
 type Monitor struct {
-	repos  []config.Repo
-	days   int
-	cloner GitCloner
+	repos        []config.Repo
+	days         int
+	since        time.Time
+	until        time.Time
+	stat         bool
+	status       bool
+	keyring      *Keyring
+	dateField    DateField
+	clockSkew    time.Duration
+	cacheTTL     time.Duration
+	cacheMaxSize int64
+	cacheMaxAge  time.Duration
+	offline      bool
+	jobs         int
+	hostLimits   map[string]int
+	timeout      time.Duration
+	retries      int
+	progress     ProgressReporter
+	cloner       GitCloner
 }
 
 func NewMonitor(cfg *config.Config) *Monitor {
 	repos, _, err := cfg.GetRepos("default") // Handle the new error return
 	if err != nil {
 		slog.Error("Failed to get default repos for monitor initialization", "error", err)
-		return &Monitor{repos: []config.Repo{}, days: 1} // Return empty monitor on error
+		return &Monitor{repos: []config.Repo{}, days: 1, dateField: DateAuthor, clockSkew: DefaultClockSkew} // Return empty monitor on error
 	}
 	return NewMonitorWithRepos(repos)
 }
 
 func NewMonitorWithRepos(repos []config.Repo) *Monitor {
 	return &Monitor{
-		repos:  repos,
-		days:   1,
-		cloner: &RealGitCloner{},
+		repos:     repos,
+		days:      1,
+		dateField: DateAuthor,
+		clockSkew: DefaultClockSkew,
+		cloner:    &RealGitCloner{},
 	}
 }
 
@@ -195,12 +295,11 @@ func NewMonitorWithCache(repos []config.Repo, cacheEnabled bool, cacheDir string
 
 	if cacheEnabled {
 		if cacheDir == "" {
-			// Default cache directory
-			home, err := os.UserHomeDir()
+			dir, err := DefaultCacheDir()
 			if err != nil {
 				slog.Warn("Failed to get home directory for cache", "error", err)
 			} else {
-				cacheDir = filepath.Join(home, ".cache", "repomon")
+				cacheDir = dir
 			}
 		}
 		if cacheDir != "" {
@@ -209,160 +308,461 @@ func NewMonitorWithCache(repos []config.Repo, cacheEnabled bool, cacheDir string
 	}
 
 	return &Monitor{
-		repos:  repos,
-		days:   1,
-		cloner: cloner,
+		repos:     repos,
+		days:      1,
+		dateField: DateAuthor,
+		clockSkew: DefaultClockSkew,
+		cloner:    cloner,
 	}
 }
 
 // NewMonitorWithCloner creates a Monitor with a custom GitCloner for testing
 func NewMonitorWithCloner(repos []config.Repo, cloner GitCloner) *Monitor {
 	return &Monitor{
-		repos:  repos,
-		days:   1,
-		cloner: cloner,
+		repos:     repos,
+		days:      1,
+		dateField: DateAuthor,
+		clockSkew: DefaultClockSkew,
+		cloner:    cloner,
 	}
 }
 
+// SetCloner replaces the GitCloner used for remote repositories
+func (m *Monitor) SetCloner(cloner GitCloner) {
+	m.cloner = cloner
+}
+
 func (m *Monitor) SetDays(days int) {
 	m.days = days
 }
 
+// SetWindow limits the report to commits made at or after since and before until.
+// A zero since falls back to the number of days set by SetDays, and a zero until
+// leaves the window open up to now.
+func (m *Monitor) SetWindow(since, until time.Time) {
+	m.since = since
+	m.until = until
+}
+
+// windowStart returns the start of the reporting window
+func (m *Monitor) windowStart() time.Time {
+	if !m.since.IsZero() {
+		return m.since
+	}
+	return time.Now().AddDate(0, 0, -m.days)
+}
+
+// inWindow reports whether t falls inside the reporting window starting at cutoff
+func (m *Monitor) inWindow(t, cutoff time.Time) bool {
+	if t.Before(cutoff) {
+		return false
+	}
+	return m.until.IsZero() || t.Before(m.until)
+}
+
+// SetDateField selects which commit date is compared against the window
+func (m *Monitor) SetDateField(field DateField) {
+	m.dateField = field
+}
+
+// SetClockSkew sets how far committer dates may be out of order before the
+// history walk stops looking for in-window commits
+func (m *Monitor) SetClockSkew(skew time.Duration) {
+	m.clockSkew = skew
+}
+
+// SetKeyring enables signature verification of every reported commit against the
+// given trusted keys. A nil keyring disables verification.
+func (m *Monitor) SetKeyring(kr *Keyring) {
+	m.keyring = kr
+}
+
+// SetCacheTTL lets cached clones fetched within ttl be used without fetching again
+func (m *Monitor) SetCacheTTL(ttl time.Duration) {
+	m.cacheTTL = ttl
+}
+
+// SetCacheLimits makes each run end by evicting least recently used cache entries
+// unused for longer than maxAge, or beyond maxSize bytes. Zero limits are not enforced.
+func (m *Monitor) SetCacheLimits(maxSize int64, maxAge time.Duration) {
+	m.cacheMaxSize = maxSize
+	m.cacheMaxAge = maxAge
+}
+
+// SetConcurrency sets how many remote repositories are collected at once, overall and
+// per host name. Local repositories do not count against these limits.
+func (m *Monitor) SetConcurrency(jobs int, hostLimits map[string]int) {
+	m.jobs = jobs
+	m.hostLimits = hostLimits
+}
+
+// SetTimeout bounds the time spent on each repository, retries included. Zero means no limit.
+func (m *Monitor) SetTimeout(timeout time.Duration) {
+	m.timeout = timeout
+}
+
+// SetRetries sets how many times a transient clone or fetch failure is retried, with
+// exponential backoff. Other failures, such as authentication errors, are not retried.
+func (m *Monitor) SetRetries(retries int) {
+	m.retries = retries
+}
+
+// SetProgress sets how progress is reported while collecting repositories. By default
+// nothing is reported.
+func (m *Monitor) SetProgress(progress ProgressReporter) {
+	m.progress = progress
+}
+
+// SetOffline reports remote repositories from cached clones only, without network access
+func (m *Monitor) SetOffline(offline bool) {
+	m.offline = offline
+}
+
+// SetStat enables computing a diffstat for every reported commit.
+// This diffs each commit against its parent, which is slow on large repositories.
+func (m *Monitor) SetStat(stat bool) {
+	m.stat = stat
+}
+
+// SetStatus reports the working tree status of local repositories along with their commits
+func (m *Monitor) SetStatus(status bool) {
+	m.status = status
+}
+
+// GetRecentCommits collects the recent commits of every repository, in repository order
 func (m *Monitor) GetRecentCommits(ctx context.Context) ([]RepoResult, error) {
 	results := make([]RepoResult, len(m.repos))
+	err := m.StreamRecentCommits(ctx, func(index int, result RepoResult) {
+		results[index] = result
+	})
+	return results, err
+}
+
+// StreamRecentCommits collects the recent commits of every repository like
+// GetRecentCommits, but hands each result to fn as soon as its repository is done,
+// along with the repository's index. Results arrive in the order repositories finish,
+// and fn is never called concurrently. If ctx is cancelled, the repositories not done
+// by then are handed over with a KindCancelled error, so every repository has a result.
+func (m *Monitor) StreamRecentCommits(ctx context.Context, fn func(index int, result RepoResult)) error {
+	return m.streamRepos(ctx, m.collectRepo, fn)
+}
+
+// collectFunc fills in the result for a single repository
+type collectFunc func(ctx context.Context, repo config.Repo, result *RepoResult) error
+
+// streamRepos collects every repository with collect, handing each result to fn as
+// described for StreamRecentCommits
+func (m *Monitor) streamRepos(ctx context.Context, collect collectFunc, fn func(index int, result RepoResult)) error {
 	var wg sync.WaitGroup
+	// mu serializes the calls to fn and to the progress reporter
+	var mu sync.Mutex
 
-	// Use a semaphore to limit concurrent goroutines
-	sem := make(chan struct{}, maxConcurrentRepos)
+	limiter := newRepoLimiter(m.jobs, m.hostLimits)
 
-	bar := progressbar.NewOptions(len(m.repos),
-		progressbar.OptionSetDescription("Fetching commits"),
-		progressbar.OptionShowCount(),
-		progressbar.OptionSetWriter(os.Stderr),
-	)
+	progress := m.progress
+	if progress == nil {
+		progress = NoProgress{}
+	}
+	progress.Start(len(m.repos))
 
 	for i, repo := range m.repos {
 		wg.Add(1)
 		go func(index int, repo config.Repo) {
 			defer wg.Done()
-			defer func() { _ = bar.Add(1) }()
 
-			sem <- struct{}{}        // Acquire
-			defer func() { <-sem }() // Release
-
-			result := RepoResult{Repo: repo}
-			commits, err := m.getRepoCommits(ctx, repo)
-			if err != nil {
-				location := repo.Path
-				if repo.URL != "" {
-					location = repo.URL
-				}
-				slog.Debug("Failed to get commits for repository",
-					"repo", repo.Name,
-					"location", location,
-					"error", err)
-				result.Error = err
+			var result RepoResult
+			if release, err := limiter.acquire(ctx, repo); err != nil {
+				// Cancelled while waiting for a slot, so the repository never started
+				result = RepoResult{Repo: repo, Error: cancelledError(ctx)}
 			} else {
-				result.Commits = commits
-				slog.Debug("Retrieved commits for repository",
-					"repo", repo.Name,
-					"commits", len(commits))
+				mu.Lock()
+				progress.RepoStarted(index, repo)
+				mu.Unlock()
+				result = m.collectResult(ctx, repo, collect)
+				release()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			// Keep a progress bar off any output fn writes; it is redrawn on the next step
+			if c, ok := progress.(progressClearer); ok {
+				c.clear()
 			}
-			results[index] = result
+			fn(index, result)
+			progress.RepoFinished(index, result)
 		}(i, repo)
 	}
 
 	wg.Wait()
-	_ = bar.Finish()
-	return results, nil
+	progress.Finish()
+
+	m.evictCache(ctx)
+	return nil
+}
+
+// collectResult collects a single repository, recording any failure in the result
+func (m *Monitor) collectResult(ctx context.Context, repo config.Repo, collect collectFunc) RepoResult {
+	result := RepoResult{Repo: repo}
+	if ctx.Err() != nil {
+		result.Error = cancelledError(ctx)
+		return result
+	}
+	start := time.Now()
+	err := m.collectWithTimeout(ctx, repo, &result, collect)
+	result.Duration = time.Since(start)
+	if err != nil && ctx.Err() != nil {
+		// Whatever failed, it was because the run was cancelled
+		slog.Debug("Cancelled while collecting repository", "repo", repo.Name, "error", err)
+		result.Error = cancelledError(ctx)
+	} else if err != nil {
+		location := repo.Path
+		if repo.URL != "" {
+			location = repo.URL
+		}
+		slog.Debug("Failed to get commits for repository",
+			"repo", repo.Name,
+			"location", location,
+			"attempts", result.Attempts,
+			"duration", result.Duration,
+			"error", err)
+		result.Error = classifyRepoError(repo, err)
+	} else {
+		slog.Debug("Retrieved commits for repository",
+			"repo", repo.Name,
+			"commits", len(result.Commits),
+			"truncated", result.Truncated,
+			"attempts", result.Attempts,
+			"duration", result.Duration)
+	}
+	return result
+}
+
+// cancelledError returns the error of a repository not done when ctx was cancelled
+func cancelledError(ctx context.Context) error {
+	return &RepoError{Kind: KindCancelled, Err: fmt.Errorf("cancelled before it finished: %w", context.Cause(ctx))}
+}
+
+// evictCache enforces the cache limits, if any, once every repository is collected
+func (m *Monitor) evictCache(ctx context.Context) {
+	cache, ok := m.cloner.(*CachingGitCloner)
+	if !ok || (m.cacheMaxSize <= 0 && m.cacheMaxAge <= 0) || ctx.Err() != nil {
+		return
+	}
+	evicted, err := cache.Evict(ctx, m.cacheMaxSize, m.cacheMaxAge)
+	if err != nil {
+		// Not fatal: the report is complete, and the next run tries again
+		slog.Warn("Failed to evict cache entries", "error", err)
+	}
+	slog.Debug("Evicted cache entries", "count", len(evicted))
+}
+
+// collectWithTimeout runs collect within the per-repository timeout
+func (m *Monitor) collectWithTimeout(ctx context.Context, repo config.Repo, result *RepoResult, collect collectFunc) error {
+	if m.timeout <= 0 {
+		return collect(ctx, repo, result)
+	}
+	repoCtx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	err := collect(repoCtx, repo, result)
+	if err != nil && ctx.Err() == nil && errors.Is(repoCtx.Err(), context.DeadlineExceeded) {
+		return &RepoError{Kind: KindTimeout, Err: fmt.Errorf("timed out after %s: %w", m.timeout, err)}
+	}
+	return err
 }
 
 // getRepoCommits retrieves recent commits for a single repository
 func (m *Monitor) getRepoCommits(ctx context.Context, repo config.Repo) ([]Commit, error) {
-	var gitRepo *git.Repository
-	var err error
+	result := RepoResult{Repo: repo}
+	err := m.collectRepo(ctx, repo, &result)
+	return result.Commits, err
+}
 
-	// Determine if this is a remote or local repository
-	if repo.URL != "" {
-		var cleanup func()
-		gitRepo, cleanup, err = m.cloneRemoteRepo(ctx, repo.URL, repo.Branch)
-		if err != nil {
-			return nil, fmt.Errorf("failed to clone remote repository: %w", err)
-		}
-		defer cleanup()
-	} else if repo.Path != "" {
-		// Local repository - check if path exists
-		if _, err := os.Stat(repo.Path); os.IsNotExist(err) {
-			return nil, fmt.Errorf("repository path does not exist: %s", repo.Path)
-		}
+// collectRepo fills in the result for a single repository
+func (m *Monitor) collectRepo(ctx context.Context, repo config.Repo, result *RepoResult) error {
+	cutoff := m.windowStart()
+	// The walk never looks at commits committed before this, so neither must the clone
+	prune := cutoff.Add(-m.clockSkew)
 
-		// Open local git repository
-		gitRepo, err = git.PlainOpen(repo.Path)
-		if err != nil {
-			return nil, fmt.Errorf("failed to open git repository: %w", err)
-		}
-	} else {
-		// Neither URL nor Path provided
-		return nil, fmt.Errorf("repository configuration must specify either 'path' or 'url'")
+	opts := m.cloneOptions(repo)
+	opts.Since = prune
+	gitRepo, cleanup, err := m.openRepo(ctx, repo, opts, result)
+	if err != nil {
+		return err
 	}
+	defer cleanup()
 
-	// Get reference to branch or HEAD
-	var ref *plumbing.Reference
-	if repo.Branch != "" {
-		// Try to resolve branch
-		ref, err = gitRepo.Reference(plumbing.NewBranchReferenceName(repo.Branch), true)
+	// Fetched before anything is read, so that the status is current too
+	var tracking plumbing.ReferenceName
+	if repo.Fetch && repo.URL == "" && !m.offline {
+		tracking, err = fetchUpstream(ctx, gitRepo, repo.Path, repo.Branch)
 		if err != nil {
-			// Fallback to resolving the name directly if it's not a simple branch name
-			ref, err = gitRepo.Reference(plumbing.ReferenceName(repo.Branch), true)
-			if err != nil {
-				slog.Debug("Failed to resolve branch reference", "branch", repo.Branch, "error", err)
-				return nil, fmt.Errorf("failed to resolve branch '%s': %w", repo.Branch, err)
-			}
+			slog.Warn("Failed to fetch local repository", "repo", repo.Name, "error", err)
+			result.FetchError = err
 		}
-	} else {
-		ref, err = gitRepo.Head()
+	}
+
+	if m.status && repo.URL == "" {
+		status, err := getWorkTreeStatus(ctx, gitRepo, repo.Path)
 		if err != nil {
-			slog.Debug("Failed to get HEAD reference", "error", err)
-			return nil, fmt.Errorf("failed to get HEAD reference: %w", err)
+			// Not fatal: the commits can still be reported
+			slog.Warn("Failed to get working tree status", "repo", repo.Name, "error", err)
+		} else {
+			result.Status = status
 		}
 	}
-	slog.Debug("Got reference for commit retrieval", "hash", ref.Hash(), "name", ref.Name())
 
-	// Get commit history
-	commitIter, err := gitRepo.Log(&git.LogOptions{
-		From:  ref.Hash(),
-		Order: git.LogOrderCommitterTime,
-	})
+	ref, err := resolveRef(gitRepo, repo.Branch)
 	if err != nil {
-		slog.Debug("Failed to get commit history", "error", err, "ref", ref.Hash())
-		return nil, fmt.Errorf("failed to get commit history: %w", err)
+		return err
+	}
+	slog.Debug("Got reference for commit retrieval", "hash", ref.Hash(), "name", ref.Name())
+	if tip, err := gitRepo.CommitObject(ref.Hash()); err == nil {
+		result.LastCommit = commitDate(tip, m.dateField)
 	}
-	defer commitIter.Close()
 
-	cutoff := time.Now().AddDate(0, 0, -m.days)
+	// Clones are complete back to prune (see GitCloner), but nothing is known about
+	// how far back a shallow local repository goes, or a cached clone used offline
+	var completeSince time.Time
+	if repo.URL != "" && !m.offline {
+		completeSince = prune
+	}
 
 	var commits []Commit
-	err = commitIter.ForEach(func(c *object.Commit) error {
-		if c.Author.When.Before(cutoff) {
-			return storer.ErrStop
+	walk, err := walkHistory(ctx, gitRepo, ref.Hash(), cutoff, m.clockSkew, completeSince, func(c *object.Commit) error {
+		when := commitDate(c, m.dateField)
+		if !m.inWindow(when, cutoff) {
+			// Out of the window, but in-window commits may still follow in committer order
+			return nil
 		}
 
-		message := getOneLineCommitMessage(c.Message)
-		commits = append(commits, Commit{
-			Hash:      c.Hash.String(),
-			Message:   message,
-			Author:    c.Author.Name,
-			Timestamp: c.Author.When,
-		})
-
+		commits = append(commits, m.newCommit(ctx, repo, c, when))
 		return nil
 	})
-
 	if err != nil {
-		return nil, fmt.Errorf("failed to iterate commits: %w", err)
+		slog.Debug("Failed to walk commit history", "error", err, "ref", ref.Hash())
+		return fmt.Errorf("failed to iterate commits: %w", err)
 	}
 
-	return commits, nil
+	if walk.shallowBoundary {
+		slog.Debug("Commit walk reached the shallow boundary", "repo", repo.Name)
+	}
+	result.Commits = commits
+	result.Truncated = walk.shallowBoundary
+
+	if tracking != "" {
+		if err := m.collectIncoming(ctx, repo, ref.Hash(), tracking, cutoff, result); err != nil {
+			slog.Warn("Failed to list incoming commits", "repo", repo.Name, "error", err)
+			result.FetchError = err
+		}
+	}
+	return nil
+}
+
+// newCommit builds the reported commit of c, dated when
+func (m *Monitor) newCommit(ctx context.Context, repo config.Repo, c *object.Commit, when time.Time) Commit {
+	message := getOneLineCommitMessage(c.Message)
+	_, body := splitCommitMessage(c.Message)
+	trailers := parseTrailers(body)
+	commit := Commit{
+		Hash:         c.Hash.String(),
+		Message:      message,
+		Author:       c.Author.Name,
+		Timestamp:    when,
+		Body:         body,
+		Trailers:     trailers,
+		Conventional: parseConventionalCommit(c.Message),
+	}
+	for _, value := range trailers.Get("Co-authored-by") {
+		commit.CoAuthors = append(commit.CoAuthors, trailerName(value))
+	}
+
+	if m.stat {
+		stat, err := getCommitStat(ctx, c)
+		if err != nil {
+			// Not fatal: the parent may be missing from a shallow clone
+			slog.Debug("Failed to compute commit stats", "repo", repo.Name, "hash", c.Hash, "error", err)
+		} else {
+			commit.Stat = stat
+		}
+	}
+
+	if m.keyring != nil {
+		commit.Signature = verifyCommitSignature(c, m.keyring)
+	}
+	return commit
+}
+
+// cloneOptions returns the options remote repositories are cloned with by default
+func (m *Monitor) cloneOptions(repo config.Repo) CloneOptions {
+	return CloneOptions{Branch: repo.Branch, Blobs: m.stat, MaxAge: m.cacheTTL, Offline: m.offline, ClockSkew: m.clockSkew}
+}
+
+// openRepo opens a local repository or clones a remote one with opts.
+// The returned cleanup function must be called when done with the repository.
+func (m *Monitor) openRepo(ctx context.Context, repo config.Repo, opts CloneOptions, result *RepoResult) (*git.Repository, func(), error) {
+	// Determine if this is a remote or local repository
+	if repo.URL != "" {
+		for {
+			result.Attempts++
+			gitRepo, cleanup, err := m.cloneRemoteRepo(ctx, repo, opts, result)
+			if err == nil {
+				return gitRepo, cleanup, nil
+			}
+			if errors.Is(err, ErrNotCached) {
+				return nil, func() {}, ErrNotCached
+			}
+			if result.Attempts > m.retries || !isTransient(err) || ctx.Err() != nil {
+				return nil, func() {}, fmt.Errorf("failed to clone remote repository: %w", err)
+			}
+
+			delay := retryDelay(result.Attempts)
+			slog.Debug("Transient clone failure, retrying", "repo", repo.Name, "attempt", result.Attempts, "delay", delay, "error", err)
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, func() {}, fmt.Errorf("failed to clone remote repository: %w", err)
+			}
+		}
+	}
+
+	if repo.Path != "" {
+		// Local repository - check if path exists
+		if _, err := os.Stat(repo.Path); os.IsNotExist(err) {
+			return nil, func() {}, fmt.Errorf("repository path does not exist: %s", repo.Path)
+		}
+
+		// Open local git repository
+		gitRepo, err := git.PlainOpen(repo.Path)
+		if err != nil {
+			return nil, func() {}, fmt.Errorf("failed to open git repository: %w", err)
+		}
+		return gitRepo, func() {}, nil
+	}
+
+	// Neither URL nor Path provided
+	return nil, func() {}, fmt.Errorf("repository configuration must specify either 'path' or 'url'")
+}
+
+// resolveRef returns the reference for a branch, or HEAD if branch is empty
+func resolveRef(gitRepo *git.Repository, branch string) (*plumbing.Reference, error) {
+	if branch == "" {
+		ref, err := gitRepo.Head()
+		if err != nil {
+			slog.Debug("Failed to get HEAD reference", "error", err)
+			return nil, fmt.Errorf("failed to get HEAD reference: %w", err)
+		}
+		return ref, nil
+	}
+
+	// Try to resolve branch
+	ref, err := gitRepo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		// Fallback to resolving the name directly if it's not a simple branch name
+		ref, err = gitRepo.Reference(plumbing.ReferenceName(branch), true)
+		if err != nil {
+			slog.Debug("Failed to resolve branch reference", "branch", branch, "error", err)
+			return nil, fmt.Errorf("failed to resolve branch '%s': %w", branch, err)
+		}
+	}
+	return ref, nil
 }
 
 // getOneLineCommitMessage extracts the first line of a commit message (like git log --oneline)
@@ -380,11 +780,24 @@ func getOneLineCommitMessage(message string) string {
 }
 
 // cloneRemoteRepo obtains a git repository for a remote URL using the configured GitCloner.
-func (m *Monitor) cloneRemoteRepo(ctx context.Context, repoURL, branch string) (*git.Repository, func(), error) {
-	repoPath, cleanup, err := m.cloner.Clone(ctx, repoURL, branch)
+// It sets the age of cached data and any history rewrite the cloner recorded on result.
+func (m *Monitor) cloneRemoteRepo(ctx context.Context, repo config.Repo, opts CloneOptions, result *RepoResult) (*git.Repository, func(), error) {
+	repoURL := repo.URL
+	if rc, ok := m.cloner.(RepositoryCloner); ok {
+		gitRepo, cleanup, err := rc.CloneRepository(ctx, repoURL, opts)
+		if err != nil {
+			slog.Debug("Failed to clone remote repository", "error", err, "url", repoURL, "branch", opts.Branch)
+			return nil, func() {}, err
+		}
+		slog.Debug("Successfully opened remote repository", "url", repoURL)
+		return gitRepo, cleanup, nil
+	}
+
+	start := time.Now()
+	repoPath, cleanup, err := m.cloner.Clone(ctx, repoURL, opts)
 	if err != nil {
-		slog.Debug("Failed to clone remote repository", "error", err, "url", repoURL, "branch", branch)
-		return nil, func() {}, fmt.Errorf("git clone failed: %w", err)
+		slog.Debug("Failed to clone remote repository", "error", err, "url", repoURL, "branch", opts.Branch)
+		return nil, func() {}, err
 	}
 
 	gitRepo, err := git.PlainOpen(repoPath)
@@ -393,6 +806,14 @@ func (m *Monitor) cloneRemoteRepo(ctx context.Context, repoURL, branch string) (
 		return nil, func() {}, fmt.Errorf("failed to open cloned repository: %w", err)
 	}
 
-	slog.Debug("Successfully opened remote repository", "url", repoURL, "path", repoPath)
+	// Report the age of cached data that was not fetched in this run
+	if fr, ok := m.cloner.(FetchRecorder); ok {
+		if last := fr.LastFetched(repoPath); !last.IsZero() && last.Before(start) {
+			result.FetchedAt = last
+		}
+	}
+	result.Rewrite = m.recordedRewrite(ctx, repo, gitRepo, repoPath)
+
+	slog.Debug("Successfully opened remote repository", "url", repoURL, "path", repoPath, "fetched_at", result.FetchedAt)
 	return gitRepo, cleanup, nil
 }
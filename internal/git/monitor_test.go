@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -437,10 +439,20 @@ func TestMonitor_getRepoCommits_NoPathOrURL(t *testing.T) {
 type mockGitCloner struct {
 	cloneErr error
 	cloneDir string // Directory to use as the "cloned" repo
+	// failures is how many calls fail with cloneErr before one succeeds; zero fails them all
+	failures int
+	// hang makes Clone block until its context is done
+	hang  bool
+	calls int
 }
 
-func (m *mockGitCloner) Clone(ctx context.Context, repoURL, branch string) (string, func(), error) {
-	if m.cloneErr != nil {
+func (m *mockGitCloner) Clone(ctx context.Context, repoURL string, opts CloneOptions) (string, func(), error) {
+	m.calls++
+	if m.hang {
+		<-ctx.Done()
+		return "", func() {}, fmt.Errorf("git clone failed: signal: killed")
+	}
+	if m.cloneErr != nil && (m.failures == 0 || m.calls <= m.failures) {
 		return "", func() {}, m.cloneErr
 	}
 	return m.cloneDir, func() {}, nil
@@ -534,7 +546,7 @@ func TestRealGitCloner_Clone(t *testing.T) {
 	t.Run("clone from local path", func(t *testing.T) {
 		cloner := &RealGitCloner{}
 
-		repoPath, cleanup, err := cloner.Clone(context.Background(), sourceRepoPath, "")
+		repoPath, cleanup, err := cloner.Clone(context.Background(), sourceRepoPath, CloneOptions{})
 		if err != nil {
 			t.Fatalf("Clone failed: %v", err)
 		}
@@ -558,7 +570,7 @@ func TestRealGitCloner_Clone(t *testing.T) {
 	t.Run("clone from non-existent source fails", func(t *testing.T) {
 		cloner := &RealGitCloner{}
 
-		_, cleanup, err := cloner.Clone(context.Background(), "/nonexistent/repo", "")
+		_, cleanup, err := cloner.Clone(context.Background(), "/nonexistent/repo", CloneOptions{})
 		defer cleanup()
 		if err == nil {
 			t.Error("Expected error when cloning from non-existent source")
@@ -588,7 +600,7 @@ func TestRealGitCloner_Clone_WithBranch(t *testing.T) {
 	t.Run("clone specific branch", func(t *testing.T) {
 		cloner := &RealGitCloner{}
 
-		repoPath, cleanup, err := cloner.Clone(context.Background(), sourceRepoPath, "feature")
+		repoPath, cleanup, err := cloner.Clone(context.Background(), sourceRepoPath, CloneOptions{Branch: "feature"})
 		if err != nil {
 			t.Fatalf("Clone with branch failed: %v", err)
 		}
@@ -601,6 +613,91 @@ func TestRealGitCloner_Clone_WithBranch(t *testing.T) {
 	})
 }
 
+// countCommits returns the number of commits reachable from HEAD in a clone
+func countCommits(t *testing.T, repoPath string) int {
+	t.Helper()
+	out, err := exec.Command("git", "-C", repoPath, "rev-list", "--count", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("git rev-list failed: %v", err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Fatalf("Unexpected rev-list output %q: %v", out, err)
+	}
+	return n
+}
+
+// datedHistory returns commits committed 1, 2, ... n days ago, oldest first
+func datedHistory(now time.Time, n int) []testCommit {
+	commits := make([]testCommit, 0, n)
+	for i := n; i >= 1; i-- {
+		when := now.AddDate(0, 0, -i)
+		commits = append(commits, testCommit{fmt.Sprintf("Commit %d days ago", i), when, when})
+	}
+	return commits
+}
+
+func TestRealGitCloner_Clone_Since(t *testing.T) {
+	now := time.Now()
+	sourcePath, _ := initRepoWithCommits(t, datedHistory(now, 150))
+	// Shallow options are ignored for plain local paths
+	sourceURL := "file://" + sourcePath
+
+	t.Run("fetches the whole window beyond the default depth", func(t *testing.T) {
+		repoPath, cleanup, err := (&RealGitCloner{}).Clone(context.Background(), sourceURL, CloneOptions{Since: now.AddDate(0, 0, -120).Add(-time.Hour)})
+		if err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		defer cleanup()
+		if got := countCommits(t, repoPath); got != 120 {
+			t.Errorf("Expected 120 commits, got %d", got)
+		}
+	})
+
+	t.Run("short window fetches little history", func(t *testing.T) {
+		repoPath, cleanup, err := (&RealGitCloner{}).Clone(context.Background(), sourceURL, CloneOptions{Since: now.AddDate(0, 0, -3).Add(-time.Hour)})
+		if err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		defer cleanup()
+		if got := countCommits(t, repoPath); got != 3 {
+			t.Errorf("Expected 3 commits, got %d", got)
+		}
+	})
+
+	t.Run("no commits in window clones the tip only", func(t *testing.T) {
+		repoPath, cleanup, err := (&RealGitCloner{}).Clone(context.Background(), sourceURL, CloneOptions{Since: now})
+		if err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		defer cleanup()
+		if got := countCommits(t, repoPath); got != 1 {
+			t.Errorf("Expected 1 commit, got %d", got)
+		}
+	})
+}
+
+func TestMonitor_collectRepo_ShallowCloneNotTruncated(t *testing.T) {
+	now := time.Now()
+	sourcePath, _ := initRepoWithCommits(t, datedHistory(now, 10))
+
+	monitor := NewMonitorWithCloner([]config.Repo{}, &RealGitCloner{})
+	monitor.SetDays(5)
+	repo := config.Repo{Name: "remote-repo", URL: "file://" + sourcePath}
+
+	var result RepoResult
+	if err := monitor.collectRepo(context.Background(), repo, &result); err != nil {
+		t.Fatalf("Failed to collect repo: %v", err)
+	}
+	if result.Truncated {
+		t.Error("Expected a clone covering the window not to be reported as truncated")
+	}
+	// "Commit 5 days ago" is a few milliseconds older than the cutoff
+	if len(result.Commits) != 4 {
+		t.Errorf("Expected 4 commits, got %v", commitMessages(result.Commits))
+	}
+}
+
 // Helper function to initialize a test git repository using go-git
 func initTestRepo(repoPath string) error {
 	return initGitRepo(repoPath)
@@ -648,127 +745,6 @@ func initGitRepo(repoPath string) error {
 	return err
 }
 
-func TestSanitizeRepoName(t *testing.T) {
-	tests := []struct {
-		name     string
-		repoURL  string
-		branch   string
-		wantSame bool // true if two entries should produce the same result
-		other    [2]string
-	}{
-		{
-			name:    "HTTPS URL",
-			repoURL: "https://github.com/user/repo.git",
-			branch:  "",
-		},
-		{
-			name:    "SSH URL",
-			repoURL: "git@github.com:user/repo.git",
-			branch:  "",
-		},
-		{
-			name:    "with branch",
-			repoURL: "https://github.com/user/repo.git",
-			branch:  "main",
-		},
-		{
-			name:    "same repo different branch produces different name",
-			repoURL: "https://github.com/user/repo.git",
-			branch:  "develop",
-		},
-	}
-
-	seen := make(map[string]string) // sanitized name -> description
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := sanitizeRepoName(tt.repoURL, tt.branch)
-			if result == "" {
-				t.Error("sanitizeRepoName returned empty string")
-			}
-			// Check no collisions with previous test cases
-			key := tt.repoURL + "#" + tt.branch
-			for prevResult, prevDesc := range seen {
-				if result == prevResult {
-					t.Errorf("collision: %q produced same name as %q: %s", key, prevDesc, result)
-				}
-			}
-			seen[result] = key
-		})
-	}
-
-	// Verify URLs that previously would have collided now don't
-	t.Run("no collision for similar URLs", func(t *testing.T) {
-		a := sanitizeRepoName("https://github.com/foo/bar", "")
-		b := sanitizeRepoName("https://github.com/foo_bar", "")
-		if a == b {
-			t.Errorf("expected different names, both got %q", a)
-		}
-	})
-}
-
-func TestCachingGitCloner_Clone(t *testing.T) {
-	tempDir := t.TempDir()
-
-	// Create a source repo to clone from
-	sourceRepoPath := filepath.Join(tempDir, "source-repo")
-	if err := os.MkdirAll(sourceRepoPath, 0755); err != nil {
-		t.Fatalf("Failed to create source repo dir: %v", err)
-	}
-	if err := initTestRepo(sourceRepoPath); err != nil {
-		t.Fatalf("Failed to initialize source repo: %v", err)
-	}
-
-	cacheDir := filepath.Join(tempDir, "cache")
-	cloner := NewCachingGitCloner(cacheDir)
-
-	t.Run("first call populates cache and returns repo path", func(t *testing.T) {
-		repoPath, cleanup, err := cloner.Clone(context.Background(), sourceRepoPath, "")
-		if err != nil {
-			t.Fatalf("Clone failed: %v", err)
-		}
-		defer cleanup()
-
-		// Verify returned path has the repo content
-		content, err := os.ReadFile(filepath.Join(repoPath, "test.txt"))
-		if err != nil {
-			t.Fatalf("Failed to read from repo path: %v", err)
-		}
-		if string(content) != "test content" {
-			t.Errorf("Expected 'test content', got %q", content)
-		}
-
-		// Verify cache directory was created
-		entries, err := os.ReadDir(cacheDir)
-		if err != nil {
-			t.Fatalf("Failed to read cache dir: %v", err)
-		}
-		if len(entries) == 0 {
-			t.Error("Expected cache directory to have entries")
-		}
-	})
-
-	t.Run("second call returns same cache path", func(t *testing.T) {
-		repoPath, cleanup, err := cloner.Clone(context.Background(), sourceRepoPath, "")
-		if err != nil {
-			t.Fatalf("Cached clone failed: %v", err)
-		}
-		defer cleanup()
-
-		// Verify the returned path still has correct content
-		content, err := os.ReadFile(filepath.Join(repoPath, "test.txt"))
-		if err != nil {
-			t.Fatalf("Failed to read from repo path: %v", err)
-		}
-		if string(content) != "test content" {
-			t.Errorf("Expected 'test content', got %q", content)
-		}
-	})
-}
-
-func TestCachingGitCloner_Interface(t *testing.T) {
-	var _ GitCloner = &CachingGitCloner{}
-}
-
 func TestNewMonitorWithCache(t *testing.T) {
 	t.Run("cache disabled uses RealGitCloner", func(t *testing.T) {
 		monitor := NewMonitorWithCache([]config.Repo{}, false, "")
@@ -835,3 +811,42 @@ func initTestRepoWithOldCommit(repoPath string) error {
 
 	return err
 }
+
+func TestMonitor_StreamRecentCommits(t *testing.T) {
+	var repos []config.Repo
+	for _, name := range []string{"one", "two", "three"} {
+		repoPath := filepath.Join(t.TempDir(), name)
+		if err := os.MkdirAll(repoPath, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := initTestRepo(repoPath); err != nil {
+			t.Fatalf("Failed to initialize repo: %v", err)
+		}
+		repos = append(repos, config.Repo{Name: name, Path: repoPath})
+	}
+	repos = append(repos, config.Repo{Name: "missing", Path: filepath.Join(t.TempDir(), "missing")})
+
+	monitor := NewMonitorWithRepos(repos)
+	got := make(map[int]RepoResult)
+	err := monitor.StreamRecentCommits(context.Background(), func(index int, result RepoResult) {
+		if _, ok := got[index]; ok {
+			t.Errorf("Result %d delivered twice", index)
+		}
+		got[index] = result
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != len(repos) {
+		t.Fatalf("Expected %d results, got %d", len(repos), len(got))
+	}
+	for i, repo := range repos {
+		if got[i].Repo.Name != repo.Name {
+			t.Errorf("Result %d: expected %s, got %s", i, repo.Name, got[i].Repo.Name)
+		}
+	}
+	if got[3].Error == nil || got[0].Error != nil || len(got[0].Commits) == 0 {
+		t.Errorf("Unexpected results: %+v", got)
+	}
+}
@@ -0,0 +1,271 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/plars/repomon/internal/config"
+	"github.com/schollz/progressbar/v3"
+)
+
+// ProgressReporter is told how collecting the repositories progresses. The monitor
+// calls it from several goroutines, but never concurrently.
+type ProgressReporter interface {
+	// Start is called before any repository is collected
+	Start(total int)
+	// RepoStarted is called when the repository at index starts being collected
+	RepoStarted(index int, repo config.Repo)
+	// RepoFinished is called with the result of the repository at index
+	RepoFinished(index int, result RepoResult)
+	// Finish is called once every repository is collected
+	Finish()
+}
+
+// progressClearer is implemented by progress reporters that draw on a terminal, which
+// clear their drawing before results are handed on, as those may be written out
+type progressClearer interface {
+	clear()
+}
+
+// NoProgress reports nothing
+type NoProgress struct{}
+
+// Start does nothing
+func (NoProgress) Start(total int) {}
+
+// RepoStarted does nothing
+func (NoProgress) RepoStarted(index int, repo config.Repo) {}
+
+// RepoFinished does nothing
+func (NoProgress) RepoFinished(index int, result RepoResult) {}
+
+// Finish does nothing
+func (NoProgress) Finish() {}
+
+// BarProgress draws a progress bar, for terminals
+type BarProgress struct {
+	w   io.Writer
+	bar *progressbar.ProgressBar
+}
+
+// NewBarProgress creates a BarProgress drawing on w
+func NewBarProgress(w io.Writer) *BarProgress {
+	return &BarProgress{w: w}
+}
+
+// Start draws an empty bar for total repositories
+func (p *BarProgress) Start(total int) {
+	p.bar = progressbar.NewOptions(total,
+		progressbar.OptionSetDescription("Fetching commits"),
+		progressbar.OptionShowCount(),
+		progressbar.OptionSetWriter(p.w),
+	)
+}
+
+// RepoStarted does nothing: the bar only counts finished repositories
+func (p *BarProgress) RepoStarted(index int, repo config.Repo) {}
+
+// RepoFinished advances the bar by one repository
+func (p *BarProgress) RepoFinished(index int, result RepoResult) {
+	_ = p.bar.Add(1)
+}
+
+// Finish completes the bar
+func (p *BarProgress) Finish() {
+	_ = p.bar.Finish()
+}
+
+func (p *BarProgress) clear() {
+	_ = p.bar.Clear()
+}
+
+// EventProgress writes a JSON object per line for each step, for programs that wrap
+// repomon and show the status of each repository. Log records can be written to the
+// same stream as events too (see LogHandler).
+type EventProgress struct {
+	// mu serializes writes, as log records come from any goroutine
+	mu    sync.Mutex
+	enc   *json.Encoder
+	start time.Time
+	now   func() time.Time
+}
+
+// NewEventProgress creates an EventProgress writing to w
+func NewEventProgress(w io.Writer) *EventProgress {
+	return &EventProgress{enc: json.NewEncoder(w), now: time.Now}
+}
+
+// progressEvent is a line written by EventProgress
+type progressEvent struct {
+	Event      string    `json:"event"`
+	Time       time.Time `json:"time"`
+	Total      int       `json:"total,omitempty"`
+	Index      *int      `json:"index,omitempty"`
+	Repo       string    `json:"repo,omitempty"`
+	Branch     string    `json:"branch,omitempty"`
+	Commits    *int      `json:"commits,omitempty"`
+	Attempts   int       `json:"attempts,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	ErrorKind  string    `json:"error_kind,omitempty"`
+	DurationMS *int64    `json:"duration_ms,omitempty"`
+	// Level, Message and Attrs are set on log events
+	Level   string         `json:"level,omitempty"`
+	Message string         `json:"message,omitempty"`
+	Attrs   map[string]any `json:"attrs,omitempty"`
+}
+
+// Progress events
+const (
+	// EventRunStarted is the first event, with the total number of repositories
+	EventRunStarted = "run_started"
+	// EventRepoStarted is written when a repository starts being collected, with its
+	// index, name and branch
+	EventRepoStarted = "repo_started"
+	// EventRepoFinished is written when a repository is done, with its number of
+	// commits, attempts and duration, and its error and error kind if it failed
+	EventRepoFinished = "repo_finished"
+	// EventRunFinished is the last event, with the duration of the whole run
+	EventRunFinished = "run_finished"
+	// EventLog is a log record, with its level, message and attributes
+	EventLog = "log"
+)
+
+// Start writes the run_started event
+func (p *EventProgress) Start(total int) {
+	p.start = p.now()
+	p.write(progressEvent{Event: EventRunStarted, Time: p.start, Total: total})
+}
+
+// RepoStarted writes a repo_started event
+func (p *EventProgress) RepoStarted(index int, repo config.Repo) {
+	p.write(progressEvent{Event: EventRepoStarted, Time: p.now(), Index: &index, Repo: repo.Name, Branch: repo.Branch})
+}
+
+// RepoFinished writes a repo_finished event
+func (p *EventProgress) RepoFinished(index int, result RepoResult) {
+	commits := len(result.Commits)
+	duration := result.Duration.Milliseconds()
+	event := progressEvent{
+		Event:      EventRepoFinished,
+		Time:       p.now(),
+		Index:      &index,
+		Repo:       result.Repo.Name,
+		Branch:     result.Repo.Branch,
+		Commits:    &commits,
+		Attempts:   result.Attempts,
+		DurationMS: &duration,
+	}
+	if result.Error != nil {
+		event.Error = result.Error.Error()
+		event.ErrorKind = string(KindOf(result.Error))
+	}
+	p.write(event)
+}
+
+// Finish writes the run_finished event
+func (p *EventProgress) Finish() {
+	now := p.now()
+	duration := now.Sub(p.start).Milliseconds()
+	p.write(progressEvent{Event: EventRunFinished, Time: now, DurationMS: &duration})
+}
+
+func (p *EventProgress) write(event progressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Progress is best effort: a closed pipe must not fail the report
+	_ = p.enc.Encode(event)
+}
+
+// LogHandler returns a slog.Handler writing records of at least level as log events,
+// so that the stream holds nothing but events
+func (p *EventProgress) LogHandler(level slog.Leveler) slog.Handler {
+	return &eventLogHandler{progress: p, level: level}
+}
+
+// eventLogHandler writes log records as log events of an EventProgress
+type eventLogHandler struct {
+	progress *EventProgress
+	level    slog.Leveler
+	// attrs are those added by WithAttrs, keyed with the groups they were added in
+	attrs map[string]any
+	// prefix is the group prefix of attributes, ending in a dot
+	prefix string
+}
+
+// Enabled reports whether records of level are written
+func (h *eventLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle writes the record as a log event
+func (h *eventLogHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make(map[string]any, len(h.attrs)+r.NumAttrs())
+	maps.Copy(attrs, h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(attrs, h.prefix, a)
+		return true
+	})
+	if len(attrs) == 0 {
+		attrs = nil
+	}
+	when := r.Time
+	if when.IsZero() {
+		when = h.progress.now()
+	}
+	h.progress.write(progressEvent{Event: EventLog, Time: when, Level: r.Level.String(), Message: r.Message, Attrs: attrs})
+	return nil
+}
+
+// WithAttrs returns a handler adding attrs to every record
+func (h *eventLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = maps.Clone(h.attrs)
+	if clone.attrs == nil {
+		clone.attrs = make(map[string]any, len(attrs))
+	}
+	for _, a := range attrs {
+		addAttr(clone.attrs, h.prefix, a)
+	}
+	return &clone
+}
+
+// WithGroup returns a handler nesting the attributes that follow under name
+func (h *eventLogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// addAttr adds a to attrs, flattening groups into dotted keys. Errors are kept as their
+// message, which encoding/json would otherwise drop.
+func addAttr(attrs map[string]any, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(attrs, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	value := a.Value.Any()
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case time.Duration:
+		value = v.String()
+	}
+	attrs[prefix+a.Key] = value
+}
@@ -0,0 +1,156 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/plars/repomon/internal/config"
+)
+
+// recordingProgress records the calls a ProgressReporter gets
+type recordingProgress struct {
+	calls []string
+}
+
+func (p *recordingProgress) Start(total int) {
+	p.calls = append(p.calls, "start")
+}
+
+func (p *recordingProgress) RepoStarted(index int, repo config.Repo) {
+	p.calls = append(p.calls, "started "+repo.Name)
+}
+
+func (p *recordingProgress) RepoFinished(index int, result RepoResult) {
+	p.calls = append(p.calls, "finished "+result.Repo.Name)
+}
+
+func (p *recordingProgress) Finish() {
+	p.calls = append(p.calls, "finish")
+}
+
+func TestMonitor_SetProgress(t *testing.T) {
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	if err := os.MkdirAll(repoPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initTestRepo(repoPath); err != nil {
+		t.Fatalf("Failed to initialize repo: %v", err)
+	}
+
+	progress := &recordingProgress{}
+	monitor := NewMonitorWithRepos([]config.Repo{{Name: "repo", Path: repoPath}})
+	monitor.SetProgress(progress)
+	if _, err := monitor.GetRecentCommits(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "start, started repo, finished repo, finish"
+	if got := strings.Join(progress.calls, ", "); got != want {
+		t.Errorf("Expected calls %q, got %q", want, got)
+	}
+}
+
+func TestEventProgress(t *testing.T) {
+	var out bytes.Buffer
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	now := start
+	progress := NewEventProgress(&out)
+	progress.now = func() time.Time { return now }
+
+	progress.Start(2)
+	progress.RepoStarted(0, config.Repo{Name: "alpha", Branch: "main"})
+	progress.RepoStarted(1, config.Repo{Name: "beta"})
+	now = start.Add(1500 * time.Millisecond)
+	progress.RepoFinished(1, RepoResult{
+		Repo:     config.Repo{Name: "beta"},
+		Error:    &RepoError{Kind: KindAuthRequired, Err: errors.New("authentication failed")},
+		Attempts: 1,
+		Duration: 1500 * time.Millisecond,
+	})
+	progress.RepoFinished(0, RepoResult{Repo: config.Repo{Name: "alpha", Branch: "main"}, Commits: []Commit{{Hash: "a"}}, Duration: time.Second})
+	now = start.Add(2 * time.Second)
+	progress.Finish()
+
+	var events []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var event map[string]any
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("Invalid event %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+
+	wantEvents := []string{EventRunStarted, EventRepoStarted, EventRepoStarted, EventRepoFinished, EventRepoFinished, EventRunFinished}
+	if len(events) != len(wantEvents) {
+		t.Fatalf("Expected %d events, got %d:\n%s", len(wantEvents), len(events), out.String())
+	}
+	for i, want := range wantEvents {
+		if events[i]["event"] != want {
+			t.Errorf("Event %d: expected %s, got %v", i, want, events[i]["event"])
+		}
+	}
+	if events[0]["total"] != 2.0 {
+		t.Errorf("Expected the total in the first event, got %v", events[0])
+	}
+	if events[1]["index"] != 0.0 || events[1]["repo"] != "alpha" || events[1]["branch"] != "main" {
+		t.Errorf("Unexpected repo_started event: %v", events[1])
+	}
+	failed := events[3]
+	if failed["repo"] != "beta" || failed["error_kind"] != "auth_required" || failed["duration_ms"] != 1500.0 || failed["commits"] != 0.0 {
+		t.Errorf("Unexpected repo_finished event for a failure: %v", failed)
+	}
+	if _, ok := events[4]["error"]; ok || events[4]["commits"] != 1.0 {
+		t.Errorf("Unexpected repo_finished event for a success: %v", events[4])
+	}
+	if events[5]["duration_ms"] != 2000.0 {
+		t.Errorf("Expected the run duration in the last event, got %v", events[5])
+	}
+}
+
+func TestEventProgress_LogHandler(t *testing.T) {
+	var out bytes.Buffer
+	progress := NewEventProgress(&out)
+	logger := slog.New(progress.LogHandler(slog.LevelInfo))
+
+	progress.Start(1)
+	logger.Debug("Left out below the level")
+	logger.With("repo", "alpha").WithGroup("fetch").Warn("Fetch failed", "error", errors.New("connection refused"), "attempt", 2)
+	progress.Finish()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 events, got:\n%s", out.String())
+	}
+	var event map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &event); err != nil {
+		t.Fatalf("Invalid event %q: %v", lines[1], err)
+	}
+	if event["event"] != EventLog || event["level"] != "WARN" || event["message"] != "Fetch failed" {
+		t.Errorf("Unexpected log event: %v", event)
+	}
+	attrs, _ := event["attrs"].(map[string]any)
+	if attrs["repo"] != "alpha" || attrs["fetch.error"] != "connection refused" || attrs["fetch.attempt"] != 2.0 {
+		t.Errorf("Unexpected log attributes: %v", event["attrs"])
+	}
+}
+
+func TestBarProgress(t *testing.T) {
+	var out bytes.Buffer
+	progress := NewBarProgress(&out)
+	progress.Start(2)
+	progress.RepoFinished(0, RepoResult{})
+	progress.RepoFinished(1, RepoResult{})
+	progress.Finish()
+
+	if !strings.Contains(out.String(), "Fetching commits") || !strings.Contains(out.String(), "(2/2)") {
+		t.Errorf("Expected a completed progress bar, got %q", out.String())
+	}
+}
@@ -0,0 +1,52 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Defaults for the time spent on each repository
+const (
+	// DefaultRepoTimeout bounds the time spent on one repository, retries included
+	DefaultRepoTimeout = 5 * time.Minute
+	// DefaultRetries is how many times a transient clone or fetch failure is retried
+	DefaultRetries = 2
+)
+
+// retryBaseDelay is the wait before the first retry; it doubles for each further one
+var retryBaseDelay = time.Second
+
+// maxRetryDelay caps the wait between retries
+const maxRetryDelay = 30 * time.Second
+
+// isTransient reports whether a clone or fetch error is likely to go away on retry,
+// such as a DNS hiccup, a reset connection or a server error. Errors not known to be
+// transient fail fast.
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return errorKind(err) == KindNetwork
+}
+
+// retryDelay returns the wait before the given retry, starting at 1
+func retryDelay(retry int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < retry && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// sleepContext waits for d, or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
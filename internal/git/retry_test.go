@@ -0,0 +1,127 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/plars/repomon/internal/config"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "DNS failure", err: errors.New("git clone failed: exit status 128: fatal: unable to access 'https://github.com/x/y/': Could not resolve host: github.com"), want: true},
+		{name: "connection reset", err: errors.New("fatal: read error: Connection reset by peer"), want: true},
+		{name: "hung up", err: errors.New("fatal: the remote end hung up unexpectedly"), want: true},
+		{name: "HTTP 502", err: errors.New("fatal: unable to access 'https://git.example.com/x.git/': The requested URL returned error: 502"), want: true},
+		{name: "net timeout", err: fmt.Errorf("go-git clone failed: %w", &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}), want: true},
+		{name: "DNS error", err: &net.DNSError{Err: "no such host", Name: "git.example.com"}, want: true},
+		{name: "syscall reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), want: true},
+		{name: "authentication", err: errors.New("fatal: Authentication failed for 'https://github.com/x/y/'"), want: false},
+		{name: "HTTP 404 not found", err: errors.New("remote: Repository not found.\nfatal: repository 'https://github.com/x/y/' not found"), want: false},
+		{name: "missing branch", err: errors.New("fatal: Remote branch nope not found in upstream origin"), want: false},
+		{name: "go-git auth", err: fmt.Errorf("go-git clone failed: %w", transport.ErrAuthenticationRequired), want: false},
+		{name: "go-git not found", err: fmt.Errorf("go-git clone failed: %w", transport.ErrRepositoryNotFound), want: false},
+		{name: "context canceled", err: context.Canceled, want: false},
+		{name: "not cached", err: ErrNotCached, want: false},
+		{name: "unknown", err: errors.New("something odd"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err); got != tt.want {
+				t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second}
+	for i, w := range want {
+		if got := retryDelay(i + 1); got != w {
+			t.Errorf("retryDelay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestMonitor_collectRepo_Retries(t *testing.T) {
+	defer func(d time.Duration) { retryBaseDelay = d }(retryBaseDelay)
+	retryBaseDelay = time.Millisecond
+
+	sourcePath := filepath.Join(t.TempDir(), "source")
+	if err := os.MkdirAll(sourcePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initTestRepo(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize source repo: %v", err)
+	}
+	repo := config.Repo{Name: "remote-repo", URL: "https://git.example.com/test.git"}
+	transient := errors.New("fatal: unable to access: Could not resolve host: git.example.com")
+
+	t.Run("transient failure is retried", func(t *testing.T) {
+		cloner := &mockGitCloner{cloneDir: sourcePath, cloneErr: transient, failures: 2}
+		monitor := NewMonitorWithCloner([]config.Repo{repo}, cloner)
+		monitor.SetRetries(2)
+
+		results, err := monitor.GetRecentCommits(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if results[0].Error != nil {
+			t.Fatalf("Expected the retry to succeed, got %v", results[0].Error)
+		}
+		if results[0].Attempts != 3 || results[0].Duration <= 0 {
+			t.Errorf("Expected 3 attempts and a duration, got %d and %v", results[0].Attempts, results[0].Duration)
+		}
+	})
+
+	t.Run("retries are bounded", func(t *testing.T) {
+		cloner := &mockGitCloner{cloneErr: transient}
+		monitor := NewMonitorWithCloner([]config.Repo{repo}, cloner)
+		monitor.SetRetries(2)
+
+		results, _ := monitor.GetRecentCommits(context.Background())
+		if results[0].Error == nil || cloner.calls != 3 {
+			t.Errorf("Expected failure after 3 attempts, got %d attempts and %v", cloner.calls, results[0].Error)
+		}
+	})
+
+	t.Run("permanent failure fails fast", func(t *testing.T) {
+		cloner := &mockGitCloner{cloneErr: errors.New("fatal: Authentication failed")}
+		monitor := NewMonitorWithCloner([]config.Repo{repo}, cloner)
+		monitor.SetRetries(5)
+
+		results, _ := monitor.GetRecentCommits(context.Background())
+		if results[0].Error == nil || results[0].Attempts != 1 {
+			t.Errorf("Expected one failed attempt, got %d attempts and %v", results[0].Attempts, results[0].Error)
+		}
+	})
+
+	t.Run("hung clone times out", func(t *testing.T) {
+		cloner := &mockGitCloner{hang: true}
+		monitor := NewMonitorWithCloner([]config.Repo{repo}, cloner)
+		monitor.SetRetries(2)
+		monitor.SetTimeout(50 * time.Millisecond)
+
+		results, _ := monitor.GetRecentCommits(context.Background())
+		if results[0].Error == nil || !strings.Contains(results[0].Error.Error(), "timed out after 50ms") {
+			t.Errorf("Expected a timeout error, got %v", results[0].Error)
+		}
+		if cloner.calls != 1 {
+			t.Errorf("Expected no retry past the timeout, got %d attempts", cloner.calls)
+		}
+	})
+}
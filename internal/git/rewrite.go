@@ -0,0 +1,132 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/plars/repomon/internal/config"
+)
+
+// maxDroppedCommits caps the commits listed for a rewrite, e.g. when a branch was
+// replaced by unrelated history
+const maxDroppedCommits = 100
+
+// Rewrite is a rewrite of a branch's history, such as a force push: the tip the branch
+// had when last fetched is no longer in its history
+type Rewrite struct {
+	OldTip string
+	NewTip string
+	// DetectedAt is when the fetch that found the rewrite ran
+	DetectedAt time.Time
+	// Dropped are the commits of the old history that the new one lacks, newest first.
+	// They are only listed while their objects are still in the cache.
+	Dropped []Commit
+}
+
+// RewriteRecorder is implemented by cloners that keep the tip of each branch they fetch,
+// to tell when its history was rewritten. LastRewrite returns the rewrite found by a
+// fetch of the clone at repoPath that is still reported, or nil. Only the hashes of its
+// dropped commits are set.
+type RewriteRecorder interface {
+	LastRewrite(repoPath string) *Rewrite
+}
+
+// isRewritten reports whether oldTip is missing from the history of newTip, allowing for
+// committer dates out of order by up to clockSkew. It reports false when that cannot be
+// told, such as when the old tip is no longer in the repository or a shallow history
+// ends before reaching back to it.
+func isRewritten(gitRepo *git.Repository, oldTip, newTip plumbing.Hash, clockSkew time.Duration) (bool, error) {
+	if oldTip == newTip {
+		return false, nil
+	}
+	old, err := gitRepo.CommitObject(oldTip)
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		// E.g. the clone was made again: a rewrite since the last fetch goes unnoticed
+		slog.Warn("Previous branch tip is gone, a history rewrite may have been missed", "tip", oldTip)
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get commit %s: %w", oldTip, err)
+	}
+
+	// Any line of history from the new tip back to the old one only holds commits
+	// committed after it, give or take some clock skew, so the walk stops below that
+	bound := old.Committer.When.Add(-clockSkew)
+	seen := make(map[plumbing.Hash]bool)
+	pending := []plumbing.Hash{newTip}
+	for len(pending) > 0 {
+		hash := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		if hash == oldTip {
+			return false, nil
+		}
+
+		c, err := gitRepo.CommitObject(hash)
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			slog.Warn("History ends before the previous branch tip, a history rewrite may have been missed", "tip", oldTip)
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to get commit %s: %w", hash, err)
+		}
+		if c.Committer.When.Before(bound) {
+			continue
+		}
+		pending = append(pending, c.ParentHashes...)
+	}
+	return true, nil
+}
+
+// droppedHashes returns the commits reachable from oldTip but not from newTip, newest
+// first, up to maxDroppedCommits
+func droppedHashes(ctx context.Context, path string, oldTip, newTip plumbing.Hash) ([]plumbing.Hash, error) {
+	output, err := runGit(ctx, path, "rev-list", "--max-count="+strconv.Itoa(maxDroppedCommits), oldTip.String(), "--not", newTip.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dropped commits: %w: %s", err, output)
+	}
+	var hashes []plumbing.Hash
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if line := scanner.Text(); plumbing.IsHash(line) {
+			hashes = append(hashes, plumbing.NewHash(line))
+		}
+	}
+	return hashes, scanner.Err()
+}
+
+// recordedRewrite returns the rewrite the cloner recorded for the clone at repoPath, if
+// it records them, with the details of the dropped commits still in gitRepo
+func (m *Monitor) recordedRewrite(ctx context.Context, repo config.Repo, gitRepo *git.Repository, repoPath string) *Rewrite {
+	rr, ok := m.cloner.(RewriteRecorder)
+	if !ok {
+		return nil
+	}
+	rewrite := rr.LastRewrite(repoPath)
+	if rewrite == nil {
+		return nil
+	}
+
+	dropped := rewrite.Dropped
+	rewrite.Dropped = nil
+	for _, commit := range dropped {
+		c, err := gitRepo.CommitObject(plumbing.NewHash(commit.Hash))
+		if err != nil {
+			slog.Debug("Dropped commit is no longer in the cache", "repo", repo.Name, "hash", commit.Hash, "error", err)
+			continue
+		}
+		rewrite.Dropped = append(rewrite.Dropped, m.newCommit(ctx, repo, c, commitDate(c, m.dateField)))
+	}
+	return rewrite
+}
@@ -0,0 +1,220 @@
+package git
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/plars/repomon/internal/config"
+)
+
+func TestIsRewritten(t *testing.T) {
+	now := time.Now()
+	repoPath, hashes := initRepoWithCommits(t, datedHistory(now, 5))
+	gitRepo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		oldTip plumbing.Hash
+		newTip plumbing.Hash
+		want   bool
+	}{
+		{name: "unchanged", oldTip: hashes[4], newTip: hashes[4]},
+		{name: "fast-forward", oldTip: hashes[1], newTip: hashes[4]},
+		{name: "reset to an older commit", oldTip: hashes[4], newTip: hashes[2], want: true},
+		{name: "old tip gone", oldTip: plumbing.NewHash("1111111111111111111111111111111111111111"), newTip: hashes[4]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isRewritten(gitRepo, tt.oldTip, tt.newTip, DefaultClockSkew)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("isRewritten() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonitor_GetRecentCommits_Rewrite(t *testing.T) {
+	sourcePath, _ := initRepoWithCommits(t, datedHistory(time.Now(), 3))
+	gitIn := func(args ...string) string {
+		t.Helper()
+		args = append([]string{"-C", sourcePath, "-c", "user.name=Test User", "-c", "user.email=test@example.com"}, args...)
+		output, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+
+	repo := config.Repo{Name: "remote-repo", URL: "file://" + sourcePath}
+	monitor := NewMonitorWithCloner([]config.Repo{repo}, NewCachingGitCloner(t.TempDir()))
+	monitor.SetDays(10)
+	run := func() RepoResult {
+		t.Helper()
+		results, err := monitor.GetRecentCommits(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if results[0].Error != nil {
+			t.Fatalf("Unexpected error: %v", results[0].Error)
+		}
+		return results[0]
+	}
+
+	if result := run(); result.Rewrite != nil {
+		t.Errorf("Expected no rewrite on the first fetch, got %+v", result.Rewrite)
+	}
+
+	gitIn("commit", "-q", "--allow-empty", "-m", "Pushed work")
+	oldTip := gitIn("rev-parse", "HEAD")
+	if result := run(); result.Rewrite != nil {
+		t.Errorf("Expected no rewrite for a fast-forward, got %+v", result.Rewrite)
+	}
+
+	// Force-push a branch that drops the last commit
+	gitIn("reset", "-q", "--hard", "HEAD~1")
+	gitIn("commit", "-q", "--allow-empty", "-m", "Replacement work")
+	newTip := gitIn("rev-parse", "HEAD")
+	result := run()
+	rewrite := result.Rewrite
+	if rewrite == nil {
+		t.Fatal("Expected the force push to be found")
+	}
+	if rewrite.OldTip != oldTip || rewrite.NewTip != newTip || rewrite.DetectedAt.IsZero() {
+		t.Errorf("Expected a rewrite from %s to %s, got %+v", oldTip, newTip, rewrite)
+	}
+	if len(rewrite.Dropped) != 1 || rewrite.Dropped[0].Message != "Pushed work" || rewrite.Dropped[0].Author != "Test User" {
+		t.Errorf("Expected the dropped commit with its details, got %+v", rewrite.Dropped)
+	}
+	if !containsMessage(result.Commits, "Replacement work") || containsMessage(result.Commits, "Pushed work") {
+		t.Errorf("Expected the commits of the new history, got %v", commitMessages(result.Commits))
+	}
+
+	// Later fetches keep reporting it, for runs that missed the one that found it
+	gitIn("commit", "-q", "--allow-empty", "-m", "More work")
+	if result := run(); result.Rewrite == nil || result.Rewrite.OldTip != oldTip || result.Rewrite.NewTip != newTip {
+		t.Errorf("Expected the rewrite to be kept, got %+v", result.Rewrite)
+	}
+
+	// Until it is acknowledged
+	cloner := monitor.cloner.(*CachingGitCloner)
+	entries, err := cloner.Entries()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected 1 cache entry, got %v: %v", entries, err)
+	}
+	if ok, err := cloner.AcknowledgeRewrite(context.Background(), entries[0]); err != nil || !ok {
+		t.Fatalf("Expected the rewrite to be acknowledged, got %v: %v", ok, err)
+	}
+	if result := run(); result.Rewrite != nil {
+		t.Errorf("Expected no rewrite once acknowledged, got %+v", result.Rewrite)
+	}
+	if ok, err := cloner.AcknowledgeRewrite(context.Background(), entries[0]); err != nil || ok {
+		t.Errorf("Expected nothing left to acknowledge, got %v: %v", ok, err)
+	}
+}
+
+func TestIsRewritten_ClockSkew(t *testing.T) {
+	now := time.Now()
+	// The commit after the old tip claims to be committed 2 hours before it
+	oldTipDate := now.Add(-24 * time.Hour)
+	repoPath, hashes := initRepoWithCommits(t, []testCommit{
+		{"Base", now.AddDate(0, 0, -3), now.AddDate(0, 0, -3)},
+		{"Old tip", oldTipDate, oldTipDate},
+		{"Skewed", oldTipDate.Add(-2 * time.Hour), oldTipDate.Add(-2 * time.Hour)},
+		{"New tip", now, now},
+	})
+	gitRepo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		clockSkew time.Duration
+		want      bool
+	}{
+		{name: "skew tolerated", clockSkew: 3 * time.Hour},
+		{name: "skew beyond tolerance", clockSkew: time.Hour, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isRewritten(gitRepo, hashes[1], hashes[3], tt.clockSkew)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("isRewritten() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("the monitor's clock skew is passed to the cloner", func(t *testing.T) {
+		monitor := NewMonitorWithCloner(nil, NewCachingGitCloner(t.TempDir()))
+		monitor.SetClockSkew(3 * time.Hour)
+		if got := monitor.cloneOptions(config.Repo{}).ClockSkew; got != 3*time.Hour {
+			t.Errorf("Expected a clock skew of 3h, got %v", got)
+		}
+	})
+}
+
+func TestRecordUse_Rewrite(t *testing.T) {
+	const url = "https://github.com/plars/app"
+	now := time.Now()
+	first := &cacheRewrite{OldTip: "a1", NewTip: "b1", DetectedAt: now.Add(-time.Hour), Dropped: []string{"a1", "a0"}}
+	second := &cacheRewrite{OldTip: "b1", NewTip: "c1", DetectedAt: now, Dropped: []string{"b1", "a0"}}
+
+	tests := []struct {
+		name    string
+		kept    *cacheRewrite
+		fetched bool
+		found   *cacheRewrite
+		want    *cacheRewrite
+	}{
+		{name: "found", fetched: true, found: first, want: first},
+		{name: "kept by a fetch finding none", kept: first, fetched: true, want: first},
+		{name: "kept without a fetch", kept: first, want: first},
+		{
+			name: "rewritten again", kept: first, fetched: true, found: second,
+			want: &cacheRewrite{OldTip: "a1", NewTip: "c1", DetectedAt: now, Dropped: []string{"b1", "a0", "a1"}},
+		},
+		{
+			name: "expired", fetched: true,
+			kept: &cacheRewrite{OldTip: "a1", NewTip: "b1", DetectedAt: now.Add(-rewriteRetention - time.Hour)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cachePath := t.TempDir()
+			if err := writeCacheMetadata(cachePath, &cacheMetadata{URL: url, Rewrite: tt.kept}); err != nil {
+				t.Fatal(err)
+			}
+			recordUse(cachePath, url, "", tt.fetched, "", tt.found)
+
+			meta, err := readCacheMetadata(cachePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := meta.Rewrite
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("Expected rewrite %+v, got %+v", tt.want, got)
+			}
+			if got != nil && (got.OldTip != tt.want.OldTip || got.NewTip != tt.want.NewTip ||
+				!got.DetectedAt.Equal(tt.want.DetectedAt) || strings.Join(got.Dropped, ",") != strings.Join(tt.want.Dropped, ",")) {
+				t.Errorf("Expected rewrite %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
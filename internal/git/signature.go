@@ -0,0 +1,292 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/ssh"
+)
+
+// SignatureStatus describes whether a commit is signed and whether the signature verifies
+type SignatureStatus string
+
+const (
+	// SignatureUnsigned means the commit carries no signature
+	SignatureUnsigned SignatureStatus = "unsigned"
+	// SignatureUnverified means the commit is signed, but the signature is invalid
+	// or was made by a key that is not in the keyring
+	SignatureUnverified SignatureStatus = "unverified"
+	// SignatureVerified means the signature is valid and made by a trusted key
+	SignatureVerified SignatureStatus = "verified"
+)
+
+// Signature types
+const (
+	SignatureTypeOpenPGP = "openpgp"
+	SignatureTypeSSH     = "ssh"
+)
+
+const (
+	sshSignatureArmorStart = "-----BEGIN SSH SIGNATURE-----"
+	sshSignatureArmorEnd   = "-----END SSH SIGNATURE-----"
+	sshSignatureMagic      = "SSHSIG"
+	// sshSignatureNamespace is the namespace git uses when signing commits with SSH keys
+	sshSignatureNamespace = "git"
+)
+
+// CommitSignature holds the result of verifying a commit's signature
+type CommitSignature struct {
+	Status SignatureStatus
+	// Type is the signature format ("openpgp" or "ssh"), empty for unsigned commits
+	Type string
+	// Signer identifies the verifying key: the OpenPGP identity or the SSH principal
+	Signer string
+	// Reason explains why a signed commit could not be verified
+	Reason string
+}
+
+// Keyring holds the trusted keys used to verify commit signatures
+type Keyring struct {
+	armoredPGP     string
+	allowedSigners []allowedSigner
+}
+
+// allowedSigner is one entry of an SSH allowed_signers file
+type allowedSigner struct {
+	principals []string
+	namespaces []string
+	key        ssh.PublicKey
+}
+
+// LoadKeyring loads trusted keys from an armored OpenPGP public keyring and an
+// SSH allowed_signers file (see ssh-keygen(1)). Either path may be empty.
+func LoadKeyring(pgpKeyringPath, allowedSignersPath string) (*Keyring, error) {
+	kr := &Keyring{}
+
+	if pgpKeyringPath != "" {
+		data, err := os.ReadFile(pgpKeyringPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OpenPGP keyring %s: %w", pgpKeyringPath, err)
+		}
+		kr.armoredPGP = string(data)
+	}
+
+	if allowedSignersPath != "" {
+		data, err := os.ReadFile(allowedSignersPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read allowed signers file %s: %w", allowedSignersPath, err)
+		}
+		signers, err := parseAllowedSigners(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse allowed signers file %s: %w", allowedSignersPath, err)
+		}
+		kr.allowedSigners = signers
+	}
+
+	return kr, nil
+}
+
+// parseAllowedSigners parses an SSH allowed_signers file.
+// Each line is "principals [options] keytype base64-key [comment]", which is the
+// authorized_keys format prefixed with a comma-separated list of principals.
+func parseAllowedSigners(data []byte) ([]allowedSigner, error) {
+	var signers []allowedSigner
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		principals, rest, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing public key", lineNo)
+		}
+		key, _, options, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(rest)))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		signer := allowedSigner{
+			principals: strings.Split(strings.Trim(principals, `"`), ","),
+			key:        key,
+		}
+		for _, opt := range options {
+			if value, ok := strings.CutPrefix(strings.ToLower(opt), "namespaces="); ok {
+				signer.namespaces = strings.Split(strings.Trim(value, `"`), ",")
+			}
+		}
+		signers = append(signers, signer)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return signers, nil
+}
+
+// verifyCommitSignature determines the signature status of a commit.
+// A nil keyring trusts no keys, so every signed commit is reported as unverified.
+func verifyCommitSignature(c *object.Commit, kr *Keyring) *CommitSignature {
+	if c.PGPSignature == "" {
+		return &CommitSignature{Status: SignatureUnsigned}
+	}
+
+	if strings.Contains(c.PGPSignature, sshSignatureArmorStart) {
+		sig := &CommitSignature{Status: SignatureUnverified, Type: SignatureTypeSSH}
+		if kr == nil || len(kr.allowedSigners) == 0 {
+			sig.Reason = "no allowed signers configured"
+			return sig
+		}
+		principal, err := kr.verifySSH(c)
+		if err != nil {
+			sig.Reason = err.Error()
+			return sig
+		}
+		sig.Status = SignatureVerified
+		sig.Signer = principal
+		return sig
+	}
+
+	sig := &CommitSignature{Status: SignatureUnverified, Type: SignatureTypeOpenPGP}
+	if kr == nil || kr.armoredPGP == "" {
+		sig.Reason = "no OpenPGP keyring configured"
+		return sig
+	}
+	entity, err := c.Verify(kr.armoredPGP)
+	if err != nil {
+		sig.Reason = err.Error()
+		return sig
+	}
+	sig.Status = SignatureVerified
+	// The primary identity, since a key may have several and map order is random
+	if identity := entity.PrimaryIdentity(); identity != nil {
+		sig.Signer = identity.Name
+	}
+	return sig
+}
+
+// sshSignature is the wire format of an SSH signature blob (see PROTOCOL.sshsig in OpenSSH),
+// following the "SSHSIG" magic preamble.
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is the structure that is actually signed by an SSH signature,
+// following the "SSHSIG" magic preamble.
+type sshSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+// verifySSH verifies an SSH commit signature against the allowed signers and
+// returns the principal of the matching key.
+func (kr *Keyring) verifySSH(c *object.Commit) (string, error) {
+	blob, err := decodeSSHSignatureArmor(c.PGPSignature)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.HasPrefix(blob, []byte(sshSignatureMagic)) {
+		return "", errors.New("invalid SSH signature: missing magic preamble")
+	}
+
+	var sig sshSignature
+	if err := ssh.Unmarshal(blob[len(sshSignatureMagic):], &sig); err != nil {
+		return "", fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	if sig.Version != 1 {
+		return "", fmt.Errorf("unsupported SSH signature version %d", sig.Version)
+	}
+	if sig.Namespace != sshSignatureNamespace {
+		return "", fmt.Errorf("unexpected SSH signature namespace %q", sig.Namespace)
+	}
+
+	pub, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid SSH signature public key: %w", err)
+	}
+	var wireSig ssh.Signature
+	if err := ssh.Unmarshal(sig.Signature, &wireSig); err != nil {
+		return "", fmt.Errorf("invalid SSH signature blob: %w", err)
+	}
+
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha512":
+		h = sha512.New()
+	case "sha256":
+		h = sha256.New()
+	default:
+		return "", fmt.Errorf("unsupported SSH signature hash algorithm %q", sig.HashAlgorithm)
+	}
+
+	encoded := &plumbing.MemoryObject{}
+	if err := c.EncodeWithoutSignature(encoded); err != nil {
+		return "", err
+	}
+	reader, err := encoded.Reader()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	if _, err := io.Copy(h, reader); err != nil {
+		return "", err
+	}
+
+	signed := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignedData{
+		Namespace:     sig.Namespace,
+		Reserved:      sig.Reserved,
+		HashAlgorithm: sig.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+	if err := pub.Verify(signed, &wireSig); err != nil {
+		return "", fmt.Errorf("bad SSH signature: %w", err)
+	}
+
+	for _, signer := range kr.allowedSigners {
+		if !bytes.Equal(signer.key.Marshal(), pub.Marshal()) {
+			continue
+		}
+		if len(signer.namespaces) > 0 && !slices.Contains(signer.namespaces, sshSignatureNamespace) {
+			continue
+		}
+		return strings.Join(signer.principals, ","), nil
+	}
+	return "", fmt.Errorf("SSH key %s is not an allowed signer", ssh.FingerprintSHA256(pub))
+}
+
+// decodeSSHSignatureArmor strips the armor from an SSH signature and decodes the base64 body
+func decodeSSHSignatureArmor(armored string) ([]byte, error) {
+	start := strings.Index(armored, sshSignatureArmorStart)
+	end := strings.Index(armored, sshSignatureArmorEnd)
+	if start == -1 || end == -1 || end < start {
+		return nil, errors.New("invalid SSH signature armor")
+	}
+	body := armored[start+len(sshSignatureArmorStart) : end]
+	body = strings.Join(strings.Fields(body), "")
+	blob, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH signature encoding: %w", err)
+	}
+	return blob, nil
+}
@@ -0,0 +1,386 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/plars/repomon/internal/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// testSSHSigner signs commits in the SSHSIG format used by git's gpg.format=ssh
+type testSSHSigner struct {
+	signer ssh.Signer
+}
+
+func newTestSSHSigner(t *testing.T) *testSSHSigner {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate SSH key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("Failed to create SSH signer: %v", err)
+	}
+	return &testSSHSigner{signer: signer}
+}
+
+func (s *testSSHSigner) Sign(message io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(message)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha512.Sum512(data)
+	signed := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignedData{
+		Namespace:     sshSignatureNamespace,
+		HashAlgorithm: "sha512",
+		Hash:          sum[:],
+	})...)
+	sig, err := s.signer.Sign(rand.Reader, signed)
+	if err != nil {
+		return nil, err
+	}
+	blob := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignature{
+		Version:       1,
+		PublicKey:     s.signer.PublicKey().Marshal(),
+		Namespace:     sshSignatureNamespace,
+		HashAlgorithm: "sha512",
+		Signature:     ssh.Marshal(sig),
+	})...)
+
+	var buf bytes.Buffer
+	buf.WriteString(sshSignatureArmorStart + "\n")
+	encoded := base64.StdEncoding.EncodeToString(blob)
+	for len(encoded) > 70 {
+		buf.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+	buf.WriteString(encoded + "\n")
+	buf.WriteString(sshSignatureArmorEnd + "\n")
+	return buf.Bytes(), nil
+}
+
+// allowedSignersLine returns an allowed_signers entry for the signer's public key
+func (s *testSSHSigner) allowedSignersLine(principal string) string {
+	return principal + " " + strings.TrimSpace(string(ssh.MarshalAuthorizedKey(s.signer.PublicKey())))
+}
+
+func newTestPGPEntity(t *testing.T, name, email string) (*openpgp.Entity, string) {
+	t.Helper()
+	entity, err := openpgp.NewEntity(name, "", email, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate OpenPGP key: %v", err)
+	}
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return entity, buf.String()
+}
+
+// commitWithOptions creates a single-commit repository and returns the commit object
+func commitWithOptions(t *testing.T, opts *git.CommitOptions) (string, *object.Commit) {
+	t.Helper()
+	repoPath := t.TempDir()
+	repo, err := git.PlainInit(repoPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "file.txt"), []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Add("file.txt"); err != nil {
+		t.Fatal(err)
+	}
+	opts.Author = &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()}
+	hash, err := worktree.Commit("Signed commit", opts)
+	if err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	c, err := repo.CommitObject(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repoPath, c
+}
+
+func TestVerifyCommitSignature_Unsigned(t *testing.T) {
+	_, c := commitWithOptions(t, &git.CommitOptions{})
+
+	sig := verifyCommitSignature(c, &Keyring{})
+	if sig.Status != SignatureUnsigned {
+		t.Errorf("Expected unsigned, got %+v", sig)
+	}
+}
+
+func TestVerifyCommitSignature_OpenPGP(t *testing.T) {
+	entity, armored := newTestPGPEntity(t, "Alice", "alice@example.com")
+	_, otherArmored := newTestPGPEntity(t, "Mallory", "mallory@example.com")
+	_, c := commitWithOptions(t, &git.CommitOptions{SignKey: entity})
+
+	t.Run("trusted key verifies", func(t *testing.T) {
+		sig := verifyCommitSignature(c, &Keyring{armoredPGP: armored})
+		if sig.Status != SignatureVerified {
+			t.Fatalf("Expected verified, got %+v", sig)
+		}
+		if sig.Type != SignatureTypeOpenPGP {
+			t.Errorf("Expected openpgp type, got %q", sig.Type)
+		}
+		if !strings.Contains(sig.Signer, "alice@example.com") {
+			t.Errorf("Expected signer to identify Alice, got %q", sig.Signer)
+		}
+	})
+
+	t.Run("signer is the primary identity", func(t *testing.T) {
+		entity, _ := newTestPGPEntity(t, "Carol", "carol@example.com")
+		if err := entity.AddUserId("Carol", "", "carol@work.example.com", nil); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := entity.Serialize(w); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		_, c := commitWithOptions(t, &git.CommitOptions{SignKey: entity})
+		// The identities are kept in a map, so check more than one iteration order
+		for range 10 {
+			sig := verifyCommitSignature(c, &Keyring{armoredPGP: buf.String()})
+			if sig.Signer != "Carol <carol@example.com>" {
+				t.Fatalf("Expected the primary identity as signer, got %+v", sig)
+			}
+		}
+	})
+
+	t.Run("untrusted key is unverified", func(t *testing.T) {
+		sig := verifyCommitSignature(c, &Keyring{armoredPGP: otherArmored})
+		if sig.Status != SignatureUnverified {
+			t.Errorf("Expected unverified, got %+v", sig)
+		}
+		if sig.Reason == "" {
+			t.Error("Expected a reason for the failed verification")
+		}
+	})
+
+	t.Run("no keyring is unverified", func(t *testing.T) {
+		sig := verifyCommitSignature(c, &Keyring{})
+		if sig.Status != SignatureUnverified {
+			t.Errorf("Expected unverified, got %+v", sig)
+		}
+	})
+}
+
+func TestVerifyCommitSignature_SSH(t *testing.T) {
+	signer := newTestSSHSigner(t)
+	other := newTestSSHSigner(t)
+	_, c := commitWithOptions(t, &git.CommitOptions{Signer: signer})
+
+	t.Run("allowed signer verifies", func(t *testing.T) {
+		signers, err := parseAllowedSigners([]byte(signer.allowedSignersLine("alice@example.com")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := verifyCommitSignature(c, &Keyring{allowedSigners: signers})
+		if sig.Status != SignatureVerified {
+			t.Fatalf("Expected verified, got %+v", sig)
+		}
+		if sig.Type != SignatureTypeSSH || sig.Signer != "alice@example.com" {
+			t.Errorf("Unexpected signature details: %+v", sig)
+		}
+	})
+
+	t.Run("unknown key is unverified", func(t *testing.T) {
+		signers, err := parseAllowedSigners([]byte(other.allowedSignersLine("bob@example.com")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := verifyCommitSignature(c, &Keyring{allowedSigners: signers})
+		if sig.Status != SignatureUnverified {
+			t.Errorf("Expected unverified, got %+v", sig)
+		}
+		if !strings.Contains(sig.Reason, "not an allowed signer") {
+			t.Errorf("Expected reason to mention allowed signers, got %q", sig.Reason)
+		}
+	})
+
+	t.Run("namespace restriction excludes git", func(t *testing.T) {
+		line := strings.Replace(signer.allowedSignersLine("alice@example.com"), " ssh-ed25519", ` namespaces="file" ssh-ed25519`, 1)
+		signers, err := parseAllowedSigners([]byte(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := verifyCommitSignature(c, &Keyring{allowedSigners: signers})
+		if sig.Status != SignatureUnverified {
+			t.Errorf("Expected unverified, got %+v", sig)
+		}
+	})
+
+	t.Run("tampered commit is unverified", func(t *testing.T) {
+		tampered := *c
+		tampered.Message = "Tampered message"
+		signers, err := parseAllowedSigners([]byte(signer.allowedSignersLine("alice@example.com")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := verifyCommitSignature(&tampered, &Keyring{allowedSigners: signers})
+		if sig.Status != SignatureUnverified {
+			t.Errorf("Expected unverified, got %+v", sig)
+		}
+	})
+}
+
+// TestVerifyCommitSignature_SSHFixture verifies a commit signed by git itself, through
+// ssh-keygen -Y sign, rather than by the test signer above
+func TestVerifyCommitSignature_SSHFixture(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "ssh-signed-commit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := &plumbing.MemoryObject{}
+	obj.SetType(plumbing.CommitObject)
+	if _, err := obj.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	c := &object.Commit{}
+	if err := c.Decode(obj); err != nil {
+		t.Fatal(err)
+	}
+
+	allowed, err := os.ReadFile(filepath.Join("testdata", "ssh-allowed-signers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers, err := parseAllowedSigners(allowed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig := verifyCommitSignature(c, &Keyring{allowedSigners: signers})
+	if sig.Status != SignatureVerified || sig.Type != SignatureTypeSSH || sig.Signer != "alice@example.com" {
+		t.Fatalf("Expected a verified signature by alice@example.com, got %+v", sig)
+	}
+
+	tampered := *c
+	tampered.Message = "Tampered message"
+	if sig := verifyCommitSignature(&tampered, &Keyring{allowedSigners: signers}); sig.Status != SignatureUnverified {
+		t.Errorf("Expected tampered commit to be unverified, got %+v", sig)
+	}
+}
+
+func TestParseAllowedSigners(t *testing.T) {
+	signer := newTestSSHSigner(t)
+	data := "# comment\n\n" +
+		signer.allowedSignersLine("alice@example.com,alice@work.example.com") + "\n" +
+		strings.Replace(signer.allowedSignersLine("bob@example.com"), " ssh-ed25519", ` namespaces="git,file" ssh-ed25519`, 1) + "\n"
+
+	signers, err := parseAllowedSigners([]byte(data))
+	if err != nil {
+		t.Fatalf("parseAllowedSigners failed: %v", err)
+	}
+	if len(signers) != 2 {
+		t.Fatalf("Expected 2 signers, got %d", len(signers))
+	}
+	if len(signers[0].principals) != 2 || signers[0].principals[1] != "alice@work.example.com" {
+		t.Errorf("Unexpected principals: %v", signers[0].principals)
+	}
+	if len(signers[1].namespaces) != 2 || signers[1].namespaces[0] != "git" {
+		t.Errorf("Unexpected namespaces: %v", signers[1].namespaces)
+	}
+
+	if _, err := parseAllowedSigners([]byte("alice@example.com not-a-key")); err == nil {
+		t.Error("Expected error for invalid key")
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	_, armored := newTestPGPEntity(t, "Alice", "alice@example.com")
+	signer := newTestSSHSigner(t)
+
+	pgpPath := filepath.Join(dir, "keys.asc")
+	if err := os.WriteFile(pgpPath, []byte(armored), 0644); err != nil {
+		t.Fatal(err)
+	}
+	signersPath := filepath.Join(dir, "allowed_signers")
+	if err := os.WriteFile(signersPath, []byte(signer.allowedSignersLine("alice@example.com")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	kr, err := LoadKeyring(pgpPath, signersPath)
+	if err != nil {
+		t.Fatalf("LoadKeyring failed: %v", err)
+	}
+	if kr.armoredPGP == "" || len(kr.allowedSigners) != 1 {
+		t.Errorf("Keyring not fully loaded: %+v", kr)
+	}
+
+	if _, err := LoadKeyring(filepath.Join(dir, "missing.asc"), ""); err == nil {
+		t.Error("Expected error for missing keyring file")
+	}
+
+	kr, err = LoadKeyring("", "")
+	if err != nil || kr == nil {
+		t.Errorf("Expected empty keyring without error, got %v, %v", kr, err)
+	}
+}
+
+func TestMonitor_getRepoCommits_WithKeyring(t *testing.T) {
+	signer := newTestSSHSigner(t)
+	repoPath, _ := commitWithOptions(t, &git.CommitOptions{Signer: signer})
+	signers, err := parseAllowedSigners([]byte(signer.allowedSignersLine("alice@example.com")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	monitor := NewMonitorWithRepos([]config.Repo{})
+	repo := config.Repo{Name: "signed", Path: repoPath}
+
+	commits, err := monitor.getRepoCommits(context.Background(), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commits[0].Signature != nil {
+		t.Errorf("Expected no signature info without a keyring, got %+v", commits[0].Signature)
+	}
+
+	monitor.SetKeyring(&Keyring{allowedSigners: signers})
+	commits, err = monitor.getRepoCommits(context.Background(), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commits[0].Signature == nil || commits[0].Signature.Status != SignatureVerified {
+		t.Errorf("Expected verified signature, got %+v", commits[0].Signature)
+	}
+}
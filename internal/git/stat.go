@@ -0,0 +1,37 @@
+package git
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// DiffStat summarizes the changes a commit introduced relative to its first parent
+type DiffStat struct {
+	FilesChanged int
+	Insertions   int
+	Deletions    int
+	Files        []string
+}
+
+// getCommitStat computes the diffstat of a commit.
+// Root commits are compared against the empty tree; merge commits are compared
+// against their first parent, which shows what the merge brought into the branch.
+func getCommitStat(ctx context.Context, c *object.Commit) (*DiffStat, error) {
+	fileStats, err := c.StatsContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute stats for commit %s: %w", c.Hash, err)
+	}
+
+	stat := &DiffStat{
+		FilesChanged: len(fileStats),
+		Files:        make([]string, 0, len(fileStats)),
+	}
+	for _, fs := range fileStats {
+		stat.Insertions += fs.Addition
+		stat.Deletions += fs.Deletion
+		stat.Files = append(stat.Files, fs.Name)
+	}
+	return stat, nil
+}
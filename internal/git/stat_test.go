@@ -0,0 +1,115 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/plars/repomon/internal/config"
+)
+
+func TestMonitor_getRepoCommits_WithStat(t *testing.T) {
+	repoPath := t.TempDir()
+	if err := initTestRepo(repoPath); err != nil {
+		t.Fatalf("Failed to initialize test repo: %v", err)
+	}
+
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatalf("Failed to open repo: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %v", err)
+	}
+	root, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to get HEAD: %v", err)
+	}
+
+	// Second commit: rewrite test.txt and add a new file
+	if err := os.WriteFile(filepath.Join(repoPath, "test.txt"), []byte("line one\nline two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "new.txt"), []byte("a\nb\nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Add("."); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now().Add(time.Second)}
+	second, err := worktree.Commit("Second commit", &git.CommitOptions{Author: sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Merge commit whose first parent is the second commit
+	if err := os.WriteFile(filepath.Join(repoPath, "merged.txt"), []byte("merged\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Add("merged.txt"); err != nil {
+		t.Fatal(err)
+	}
+	sig = &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now().Add(2 * time.Second)}
+	if _, err := worktree.Commit("Merge branch", &git.CommitOptions{
+		Author:  sig,
+		Parents: []plumbing.Hash{second, root.Hash()},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor := NewMonitorWithRepos([]config.Repo{})
+	monitor.SetStat(true)
+
+	commits, err := monitor.getRepoCommits(context.Background(), config.Repo{Name: "test-repo", Path: repoPath})
+	if err != nil {
+		t.Fatalf("Failed to get commits: %v", err)
+	}
+	if len(commits) != 3 {
+		t.Fatalf("Expected 3 commits, got %d", len(commits))
+	}
+
+	want := map[string]DiffStat{
+		"Merge branch":   {FilesChanged: 1, Insertions: 1, Deletions: 0},
+		"Second commit":  {FilesChanged: 2, Insertions: 5, Deletions: 1},
+		"Initial commit": {FilesChanged: 1, Insertions: 1, Deletions: 0},
+	}
+	for _, c := range commits {
+		w, ok := want[c.Message]
+		if !ok {
+			t.Fatalf("Unexpected commit %q", c.Message)
+		}
+		if c.Stat == nil {
+			t.Fatalf("Expected stat for %q", c.Message)
+		}
+		if c.Stat.FilesChanged != w.FilesChanged || c.Stat.Insertions != w.Insertions || c.Stat.Deletions != w.Deletions {
+			t.Errorf("Stat for %q = %+v, want files=%d +%d -%d", c.Message, c.Stat, w.FilesChanged, w.Insertions, w.Deletions)
+		}
+		if len(c.Stat.Files) != c.Stat.FilesChanged {
+			t.Errorf("Expected %d file paths for %q, got %v", c.Stat.FilesChanged, c.Message, c.Stat.Files)
+		}
+	}
+}
+
+func TestMonitor_getRepoCommits_StatDisabled(t *testing.T) {
+	repoPath := t.TempDir()
+	if err := initTestRepo(repoPath); err != nil {
+		t.Fatalf("Failed to initialize test repo: %v", err)
+	}
+
+	monitor := NewMonitorWithRepos([]config.Repo{})
+	commits, err := monitor.getRepoCommits(context.Background(), config.Repo{Name: "test-repo", Path: repoPath})
+	if err != nil {
+		t.Fatalf("Failed to get commits: %v", err)
+	}
+	for _, c := range commits {
+		if c.Stat != nil {
+			t.Errorf("Expected no stat when disabled, got %+v", c.Stat)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// WorkTreeStatus is the state of a local repository's working tree and checked out branch
+type WorkTreeStatus struct {
+	// Branch is the checked out branch, or empty when HEAD is detached
+	Branch string
+	// Upstream is the branch Branch tracks, e.g. "origin/main", or empty if it tracks none
+	Upstream string
+	// UpstreamGone is set when the upstream branch no longer exists
+	UpstreamGone bool
+	// Ahead and Behind count the commits Branch has that Upstream lacks, and the reverse
+	Ahead  int
+	Behind int
+	// Staged counts files with changes in the index
+	Staged int
+	// Modified counts files with changes in the working tree that are not staged
+	Modified int
+	// Conflicts counts files with unresolved merge conflicts
+	Conflicts int
+	// Untracked counts files git does not track and does not ignore
+	Untracked int
+	// Stashes counts the stash entries
+	Stashes int
+}
+
+// Clean reports whether nothing is uncommitted, untracked, stashed or unpushed
+func (s *WorkTreeStatus) Clean() bool {
+	return s.Staged == 0 && s.Modified == 0 && s.Conflicts == 0 && s.Untracked == 0 &&
+		s.Stashes == 0 && s.Ahead == 0
+}
+
+// getWorkTreeStatus returns the status of the working tree of the local repository at
+// path, or nil for a bare repository, which has none
+func getWorkTreeStatus(ctx context.Context, gitRepo *git.Repository, path string) (*WorkTreeStatus, error) {
+	if _, err := gitRepo.Worktree(); errors.Is(err, git.ErrIsBareRepository) {
+		return nil, nil
+	}
+
+	// go-git's status neither reads global excludes nor scales to large trees, so git
+	// itself is asked. Optional locks are skipped so as not to get in the way of
+	// whatever else is running git in the repository.
+	cmd := gitCommand(ctx, "--no-optional-locks", "status", "--porcelain=v2", "--branch", "--show-stash")
+	cmd.Dir = path
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("git status failed: %w: %s", err, exitErr.Stderr)
+		}
+		return nil, fmt.Errorf("git status failed: %w", err)
+	}
+	return parseStatus(output)
+}
+
+// parseStatus parses the output of "git status --porcelain=v2 --branch --show-stash"
+func parseStatus(output []byte) (*WorkTreeStatus, error) {
+	status := &WorkTreeStatus{}
+	hasAheadBehind := false
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "#":
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[1] {
+			case "branch.head":
+				if fields[2] != "(detached)" {
+					status.Branch = fields[2]
+				}
+			case "branch.upstream":
+				status.Upstream = fields[2]
+			case "branch.ab":
+				if len(fields) < 4 {
+					return nil, fmt.Errorf("invalid git status line %q", line)
+				}
+				ahead, err := strconv.Atoi(strings.TrimPrefix(fields[2], "+"))
+				if err != nil {
+					return nil, fmt.Errorf("invalid git status line %q: %w", line, err)
+				}
+				behind, err := strconv.Atoi(strings.TrimPrefix(fields[3], "-"))
+				if err != nil {
+					return nil, fmt.Errorf("invalid git status line %q: %w", line, err)
+				}
+				status.Ahead, status.Behind = ahead, behind
+				hasAheadBehind = true
+			case "stash":
+				n, err := strconv.Atoi(fields[2])
+				if err != nil {
+					return nil, fmt.Errorf("invalid git status line %q: %w", line, err)
+				}
+				status.Stashes = n
+			}
+		case "1", "2":
+			// Changed or renamed entry; XY holds the index and working tree states
+			if len(fields) < 2 || len(fields[1]) != 2 {
+				return nil, fmt.Errorf("invalid git status line %q", line)
+			}
+			if fields[1][0] != '.' {
+				status.Staged++
+			}
+			if fields[1][1] != '.' {
+				status.Modified++
+			}
+		case "u":
+			status.Conflicts++
+		case "?":
+			status.Untracked++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read git status: %w", err)
+	}
+	// git leaves out the counts when the upstream branch is missing
+	status.UpstreamGone = status.Upstream != "" && !hasAheadBehind
+	return status, nil
+}
@@ -0,0 +1,135 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/plars/repomon/internal/config"
+)
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   WorkTreeStatus
+		clean  bool
+	}{
+		{
+			name:   "clean",
+			output: "# branch.oid abc\n# branch.head main\n# branch.upstream origin/main\n# branch.ab +0 -0\n",
+			want:   WorkTreeStatus{Branch: "main", Upstream: "origin/main"},
+			clean:  true,
+		},
+		{
+			name: "dirty",
+			output: "# branch.oid abc\n# branch.head feature\n# branch.upstream origin/feature\n# branch.ab +3 -1\n# stash 2\n" +
+				"1 M. N... 100644 100644 100644 abc abc staged.go\n" +
+				"1 .M N... 100644 100644 100644 abc abc modified.go\n" +
+				"1 MM N... 100644 100644 100644 abc abc both.go\n" +
+				"2 R. N... 100644 100644 100644 abc abc R100 new.go\told.go\n" +
+				"u UU N... 100644 100644 100644 100644 abc abc abc conflict.go\n" +
+				"? notes.txt\n? scratch/\n",
+			want: WorkTreeStatus{Branch: "feature", Upstream: "origin/feature", Ahead: 3, Behind: 1, Staged: 3, Modified: 2, Conflicts: 1, Untracked: 2, Stashes: 2},
+		},
+		{
+			name:   "no upstream",
+			output: "# branch.oid abc\n# branch.head wip\n",
+			want:   WorkTreeStatus{Branch: "wip"},
+			clean:  true,
+		},
+		{
+			name:   "upstream gone",
+			output: "# branch.oid abc\n# branch.head old\n# branch.upstream origin/old\n",
+			want:   WorkTreeStatus{Branch: "old", Upstream: "origin/old", UpstreamGone: true},
+			clean:  true,
+		},
+		{
+			name:   "detached",
+			output: "# branch.oid abc\n# branch.head (detached)\n",
+			want:   WorkTreeStatus{},
+			clean:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatus([]byte(tt.output))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, *got)
+			}
+			if got.Clean() != tt.clean {
+				t.Errorf("Expected Clean() = %v", tt.clean)
+			}
+		})
+	}
+
+	if _, err := parseStatus([]byte("# branch.ab +x -0\n")); err == nil {
+		t.Error("Expected an error for invalid ahead/behind counts")
+	}
+}
+
+func TestMonitor_GetRecentCommits_Status(t *testing.T) {
+	sourcePath := filepath.Join(t.TempDir(), "source")
+	if err := os.MkdirAll(sourcePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := initTestRepo(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize repo: %v", err)
+	}
+	repoPath := filepath.Join(t.TempDir(), "work")
+	gitIn := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-C", repoPath, "-c", "user.name=Test User", "-c", "user.email=test@example.com"}, args...)
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+	}
+	if output, err := exec.Command("git", "clone", "-q", sourcePath, repoPath).CombinedOutput(); err != nil {
+		t.Fatalf("git clone failed: %v: %s", err, output)
+	}
+
+	// One unpushed commit, one stash, a modified file and an untracked one
+	gitIn("commit", "-q", "--allow-empty", "-m", "Unpushed work")
+	if err := os.WriteFile(filepath.Join(repoPath, "test.txt"), []byte("stashed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn("stash", "-q")
+	if err := os.WriteFile(filepath.Join(repoPath, "test.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "notes.txt"), []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor := NewMonitorWithRepos([]config.Repo{{Name: "work", Path: repoPath}})
+	results, err := monitor.GetRecentCommits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != nil {
+		t.Error("Expected no status unless asked for")
+	}
+
+	monitor.SetStatus(true)
+	results, err = monitor.GetRecentCommits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Error != nil {
+		t.Fatalf("Unexpected error: %v", results[0].Error)
+	}
+	status := results[0].Status
+	if status == nil {
+		t.Fatal("Expected a working tree status")
+	}
+	want := WorkTreeStatus{Branch: status.Branch, Upstream: "origin/" + status.Branch, Ahead: 1, Modified: 1, Untracked: 1, Stashes: 1}
+	if status.Branch == "" || *status != want {
+		t.Errorf("Expected %+v, got %+v", want, *status)
+	}
+}
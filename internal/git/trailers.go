@@ -0,0 +1,96 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+)
+
+// trailerLine matches a "Key: value" trailer line, e.g. "Signed-off-by: A U Thor <a@example.com>"
+var trailerLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s+(\S.*)$`)
+
+// knownTrailerKeys lists trailer keys in their canonical spelling.
+// Keys are matched case-insensitively, so "Co-Authored-By" is stored as "Co-authored-by".
+var knownTrailerKeys = []string{
+	"Co-authored-by",
+	"Signed-off-by",
+	"Reviewed-by",
+	"Acked-by",
+	"Tested-by",
+	"Fixes",
+	"Change-Id",
+}
+
+// Trailers holds the trailers of a commit message, keyed by trailer name.
+// A key may appear several times, so each key maps to all of its values in order.
+type Trailers map[string][]string
+
+// Get returns the values for a trailer key, matched case-insensitively
+func (t Trailers) Get(key string) []string {
+	if values, ok := t[key]; ok {
+		return values
+	}
+	for k, values := range t {
+		if strings.EqualFold(k, key) {
+			return values
+		}
+	}
+	return nil
+}
+
+// splitCommitMessage splits a full commit message into its subject and body.
+// The body keeps everything after the subject, including any trailers.
+func splitCommitMessage(message string) (subject, body string) {
+	message = strings.TrimLeft(message, " \t\r\n")
+	subject, body, _ = strings.Cut(message, "\n")
+	return strings.TrimSpace(subject), strings.TrimSpace(body)
+}
+
+// parseTrailers extracts trailers from the last paragraph of a commit body.
+// Like git interpret-trailers, the paragraph is only treated as a trailer block
+// if every line in it is a trailer (or an indented continuation of one).
+func parseTrailers(body string) Trailers {
+	if body == "" {
+		return nil
+	}
+
+	paragraphs := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n\n")
+	last := strings.TrimSpace(paragraphs[len(paragraphs)-1])
+	if last == "" {
+		return nil
+	}
+
+	trailers := make(Trailers)
+	var lastKey string
+	for _, line := range strings.Split(last, "\n") {
+		if lastKey != "" && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			values := trailers[lastKey]
+			values[len(values)-1] += " " + strings.TrimSpace(line)
+			continue
+		}
+		m := trailerLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			return nil
+		}
+		lastKey = canonicalTrailerKey(m[1])
+		trailers[lastKey] = append(trailers[lastKey], strings.TrimSpace(m[2]))
+	}
+	return trailers
+}
+
+// canonicalTrailerKey returns the canonical spelling of well-known trailer keys
+func canonicalTrailerKey(key string) string {
+	for _, known := range knownTrailerKeys {
+		if strings.EqualFold(key, known) {
+			return known
+		}
+	}
+	return key
+}
+
+// trailerName extracts the name from an identity trailer value like "Jane Doe <jane@example.com>"
+func trailerName(value string) string {
+	if idx := strings.Index(value, "<"); idx > 0 {
+		return strings.TrimSpace(value[:idx])
+	}
+	return strings.TrimSpace(value)
+}
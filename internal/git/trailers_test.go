@@ -0,0 +1,202 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/plars/repomon/internal/config"
+)
+
+func TestSplitCommitMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     string
+		wantSubject string
+		wantBody    string
+	}{
+		{"subject only", "Fix bug\n", "Fix bug", ""},
+		{"subject and body", "Fix bug\n\nLonger explanation\nover two lines\n", "Fix bug", "Longer explanation\nover two lines"},
+		{"leading blank lines", "\n\nFix bug\n\nBody", "Fix bug", "Body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, body := splitCommitMessage(tt.message)
+			if subject != tt.wantSubject || body != tt.wantBody {
+				t.Errorf("splitCommitMessage(%q) = (%q, %q), want (%q, %q)", tt.message, subject, body, tt.wantSubject, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestParseTrailers(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want Trailers
+	}{
+		{
+			name: "empty body",
+			body: "",
+			want: nil,
+		},
+		{
+			name: "body without trailers",
+			body: "This explains the change.\nNothing else.",
+			want: nil,
+		},
+		{
+			name: "trailer block after description",
+			body: "Explain the change.\n\nSigned-off-by: Alice <alice@example.com>\nCo-authored-by: Bob <bob@example.com>\nCo-authored-by: Carol <carol@example.com>\nReviewed-by: Dave <dave@example.com>\nFixes: #123\nChange-Id: I0123456789abcdef",
+			want: Trailers{
+				"Signed-off-by":  {"Alice <alice@example.com>"},
+				"Co-authored-by": {"Bob <bob@example.com>", "Carol <carol@example.com>"},
+				"Reviewed-by":    {"Dave <dave@example.com>"},
+				"Fixes":          {"#123"},
+				"Change-Id":      {"I0123456789abcdef"},
+			},
+		},
+		{
+			name: "keys are canonicalized",
+			body: "Co-Authored-By: Bob <bob@example.com>\nCHANGE-ID: Iabc",
+			want: Trailers{
+				"Co-authored-by": {"Bob <bob@example.com>"},
+				"Change-Id":      {"Iabc"},
+			},
+		},
+		{
+			name: "continuation lines are folded",
+			body: "Fixes: a very long\n  description",
+			want: Trailers{"Fixes": {"a very long description"}},
+		},
+		{
+			name: "mixed last paragraph is not a trailer block",
+			body: "Intro.\n\nSigned-off-by: Alice <alice@example.com>\nand some prose",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseTrailers(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTrailers(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrailers_Get(t *testing.T) {
+	trailers := Trailers{"Reviewed-by": {"Dave"}, "X-Custom": {"value"}}
+
+	if got := trailers.Get("reviewed-by"); !reflect.DeepEqual(got, []string{"Dave"}) {
+		t.Errorf("Get(reviewed-by) = %v", got)
+	}
+	if got := trailers.Get("X-Custom"); !reflect.DeepEqual(got, []string{"value"}) {
+		t.Errorf("Get(X-Custom) = %v", got)
+	}
+	if got := trailers.Get("Missing"); got != nil {
+		t.Errorf("Get(Missing) = %v, want nil", got)
+	}
+
+	var empty Trailers
+	if got := empty.Get("Fixes"); got != nil {
+		t.Errorf("Get on nil trailers = %v, want nil", got)
+	}
+}
+
+func TestTrailerName(t *testing.T) {
+	if got := trailerName("Jane Doe <jane@example.com>"); got != "Jane Doe" {
+		t.Errorf("trailerName = %q, want 'Jane Doe'", got)
+	}
+	if got := trailerName("Jane Doe"); got != "Jane Doe" {
+		t.Errorf("trailerName = %q, want 'Jane Doe'", got)
+	}
+}
+
+func TestCommit_Authors(t *testing.T) {
+	c := Commit{Author: "Alice", CoAuthors: []string{"Bob", "Alice", "Carol"}}
+	want := []string{"Alice", "Bob", "Carol"}
+	if got := c.Authors(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Authors() = %v, want %v", got, want)
+	}
+}
+
+func TestFilterByAuthor(t *testing.T) {
+	commits := []Commit{
+		{Hash: "a", Author: "Alice Smith"},
+		{Hash: "b", Author: "Bob Jones", CoAuthors: []string{"Alice Smith"}},
+		{Hash: "c", Author: "Carol White"},
+	}
+	hashes := func(commits []Commit) []string {
+		var got []string
+		for _, c := range commits {
+			got = append(got, c.Hash)
+		}
+		return got
+	}
+
+	if got := hashes(FilterByAuthor(commits, []string{"alice"})); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Expected co-authored commits to match, got %v", got)
+	}
+	if got := hashes(FilterByAuthor(commits, []string{"carol", "BOB"})); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("Expected any pattern to match, got %v", got)
+	}
+	if got := FilterByAuthor(commits, nil); len(got) != 3 {
+		t.Errorf("Expected no filtering without patterns, got %v", hashes(got))
+	}
+}
+
+func TestMonitor_getRepoCommits_BodyAndTrailers(t *testing.T) {
+	repoPath := t.TempDir()
+	repo, err := git.PlainInit(repoPath, false)
+	if err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "pair.txt"), []byte("pairing"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Add("pair.txt"); err != nil {
+		t.Fatal(err)
+	}
+	message := "Add pairing notes\n\nWritten together.\n\nCo-authored-by: Bob <bob@example.com>\nReviewed-by: Carol <carol@example.com>\n"
+	if _, err := worktree.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{Name: "Alice", Email: "alice@example.com", When: time.Now()},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor := NewMonitorWithRepos([]config.Repo{})
+	commits, err := monitor.getRepoCommits(context.Background(), config.Repo{Name: "pair", Path: repoPath})
+	if err != nil {
+		t.Fatalf("Failed to get commits: %v", err)
+	}
+	if len(commits) != 1 {
+		t.Fatalf("Expected 1 commit, got %d", len(commits))
+	}
+
+	c := commits[0]
+	if c.Message != "Add pairing notes" {
+		t.Errorf("Expected subject as message, got %q", c.Message)
+	}
+	wantBody := "Written together.\n\nCo-authored-by: Bob <bob@example.com>\nReviewed-by: Carol <carol@example.com>"
+	if c.Body != wantBody {
+		t.Errorf("Body = %q, want %q", c.Body, wantBody)
+	}
+	if !reflect.DeepEqual(c.CoAuthors, []string{"Bob"}) {
+		t.Errorf("CoAuthors = %v, want [Bob]", c.CoAuthors)
+	}
+	if got := c.Trailers.Get("Reviewed-by"); !reflect.DeepEqual(got, []string{"Carol <carol@example.com>"}) {
+		t.Errorf("Reviewed-by = %v", got)
+	}
+}
@@ -0,0 +1,152 @@
+package git
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// DateField selects which commit date is used to decide whether a commit is in the window
+type DateField string
+
+const (
+	// DateAuthor filters on the author date, when the change was originally written
+	DateAuthor DateField = "author"
+	// DateCommitter filters on the committer date, when the commit was last applied
+	// (e.g. by a rebase or cherry-pick)
+	DateCommitter DateField = "committer"
+)
+
+// DefaultClockSkew is how far a commit's committer date may lag behind a newer
+// commit's before the walk stops looking for in-window commits behind it.
+const DefaultClockSkew = time.Hour
+
+// ValidDateField reports whether field is a supported date field
+func ValidDateField(field string) bool {
+	return field == string(DateAuthor) || field == string(DateCommitter)
+}
+
+// commitDate returns the commit date selected by field
+func commitDate(c *object.Commit, field DateField) time.Time {
+	if field == DateCommitter {
+		return c.Committer.When
+	}
+	return c.Author.When
+}
+
+// walkResult describes how a history walk ended
+type walkResult struct {
+	// shallowBoundary is set when the walk needed commits beyond the shallow
+	// boundary of the repository, so older in-window commits may be missing
+	shallowBoundary bool
+}
+
+// walkHistory visits the history reachable from "from" in committer-time order,
+// newest first, calling fn for every commit whose committer date is not older
+// than cutoff minus skew.
+//
+// Pruning on the committer date (rather than the author date) keeps a single
+// commit with an old author date, such as a rebased or cherry-picked one, from
+// hiding the newer commits behind it. The skew allowance tolerates committers
+// whose clocks were slightly behind. Missing parents at a shallow boundary end
+// that line of history instead of failing the walk.
+//
+// completeSince is the date the repository's history is known to be complete
+// back to, as for a clone made with --shallow-since. A boundary only counts as
+// truncating the history when it may hide commits the walk would have visited.
+func walkHistory(ctx context.Context, repo *git.Repository, from plumbing.Hash, cutoff time.Time, skew time.Duration, completeSince time.Time, fn func(*object.Commit) error) (walkResult, error) {
+	var result walkResult
+
+	start, err := repo.CommitObject(from)
+	if err != nil {
+		return result, fmt.Errorf("failed to get commit %s: %w", from, err)
+	}
+
+	shallow := make(map[plumbing.Hash]bool)
+	shallowHashes, err := repo.Storer.Shallow()
+	if err != nil {
+		return result, fmt.Errorf("failed to read shallow commits: %w", err)
+	}
+	for _, h := range shallowHashes {
+		shallow[h] = true
+	}
+
+	prune := cutoff.Add(-skew)
+	// Everything cut off by a boundary is older than completeSince, so if that is
+	// no later than prune the walk would have stopped before reaching it anyway
+	boundaryTruncates := completeSince.IsZero() || completeSince.After(prune)
+	seen := map[plumbing.Hash]bool{start.Hash: true}
+	queue := &commitQueue{start}
+
+	for queue.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		c := heap.Pop(queue).(*object.Commit)
+		if c.Committer.When.Before(prune) {
+			// Every remaining commit is older still, so nothing else can be in the window
+			break
+		}
+
+		if err := fn(c); err != nil {
+			if errors.Is(err, storer.ErrStop) {
+				break
+			}
+			return result, err
+		}
+
+		if shallow[c.Hash] {
+			if boundaryTruncates {
+				result.shallowBoundary = true
+			}
+			continue
+		}
+		for _, h := range c.ParentHashes {
+			if seen[h] {
+				continue
+			}
+			seen[h] = true
+			parent, err := object.GetCommit(repo.Storer, h)
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				// The parent was not fetched; treat it like a shallow boundary
+				if boundaryTruncates {
+					result.shallowBoundary = true
+				}
+				continue
+			}
+			if err != nil {
+				return result, fmt.Errorf("failed to get parent commit %s: %w", h, err)
+			}
+			heap.Push(queue, parent)
+		}
+	}
+
+	return result, nil
+}
+
+// commitQueue is a max-heap of commits ordered by committer date
+type commitQueue []*object.Commit
+
+func (q commitQueue) Len() int           { return len(q) }
+func (q commitQueue) Less(i, j int) bool { return q[i].Committer.When.After(q[j].Committer.When) }
+func (q commitQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x any) {
+	*q = append(*q, x.(*object.Commit))
+}
+
+func (q *commitQueue) Pop() any {
+	old := *q
+	n := len(old)
+	c := old[n-1]
+	*q = old[:n-1]
+	return c
+}
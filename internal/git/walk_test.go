@@ -0,0 +1,253 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/plars/repomon/internal/config"
+)
+
+// testCommit describes a commit to create with explicit author and committer dates
+type testCommit struct {
+	message   string
+	author    time.Time
+	committer time.Time
+}
+
+// initRepoWithCommits creates a linear history from the given commits, oldest first,
+// and returns their hashes in the same order
+func initRepoWithCommits(t *testing.T, commits []testCommit) (string, []plumbing.Hash) {
+	t.Helper()
+	repoPath := t.TempDir()
+	repo, err := git.PlainInit(repoPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashes := make([]plumbing.Hash, 0, len(commits))
+	for i, c := range commits {
+		name := filepath.Join(repoPath, "file.txt")
+		if err := os.WriteFile(name, []byte(c.message+string(rune('a'+i))), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add("file.txt"); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := worktree.Commit(c.message, &git.CommitOptions{
+			Author:    &object.Signature{Name: "Author", Email: "author@example.com", When: c.author},
+			Committer: &object.Signature{Name: "Committer", Email: "committer@example.com", When: c.committer},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+	return repoPath, hashes
+}
+
+func commitMessages(commits []Commit) []string {
+	messages := make([]string, 0, len(commits))
+	for _, c := range commits {
+		messages = append(messages, c.Message)
+	}
+	return messages
+}
+
+func containsMessage(commits []Commit, message string) bool {
+	for _, c := range commits {
+		if c.Message == message {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMonitor_getRepoCommits_OldAuthorDateDoesNotHideNewerCommits(t *testing.T) {
+	now := time.Now()
+	repoPath, _ := initRepoWithCommits(t, []testCommit{
+		{"Ancient", now.AddDate(0, 0, -60), now.AddDate(0, 0, -60)},
+		{"Recent", now.Add(-2 * time.Hour), now.Add(-2 * time.Hour)},
+		{"Cherry-picked", now.AddDate(0, 0, -30), now.Add(-time.Hour)},
+		{"Latest", now.Add(-10 * time.Minute), now.Add(-10 * time.Minute)},
+	})
+	repo := config.Repo{Name: "test-repo", Path: repoPath}
+
+	t.Run("author date", func(t *testing.T) {
+		monitor := NewMonitorWithRepos([]config.Repo{})
+		monitor.SetDays(1)
+
+		commits, err := monitor.getRepoCommits(context.Background(), repo)
+		if err != nil {
+			t.Fatalf("Failed to get commits: %v", err)
+		}
+		if !containsMessage(commits, "Latest") || !containsMessage(commits, "Recent") {
+			t.Errorf("Expected newer commits behind the cherry-pick, got %v", commitMessages(commits))
+		}
+		if containsMessage(commits, "Cherry-picked") {
+			t.Errorf("Expected cherry-pick with an old author date to be filtered, got %v", commitMessages(commits))
+		}
+		if containsMessage(commits, "Ancient") {
+			t.Errorf("Expected ancient commit to be filtered, got %v", commitMessages(commits))
+		}
+	})
+
+	t.Run("committer date", func(t *testing.T) {
+		monitor := NewMonitorWithRepos([]config.Repo{})
+		monitor.SetDays(1)
+		monitor.SetDateField(DateCommitter)
+
+		commits, err := monitor.getRepoCommits(context.Background(), repo)
+		if err != nil {
+			t.Fatalf("Failed to get commits: %v", err)
+		}
+		if len(commits) != 3 || !containsMessage(commits, "Cherry-picked") {
+			t.Errorf("Expected the three recently committed commits, got %v", commitMessages(commits))
+		}
+		for _, c := range commits {
+			if c.Message == "Cherry-picked" && c.Timestamp.Before(now.Add(-2*time.Hour)) {
+				t.Errorf("Expected timestamp to be the committer date, got %v", c.Timestamp)
+			}
+		}
+	})
+}
+
+func TestMonitor_getRepoCommits_ClockSkew(t *testing.T) {
+	now := time.Now()
+	// The middle commit's committer clock was behind: its committer date is just
+	// outside the window, while its author date and its child are inside it
+	repoPath, _ := initRepoWithCommits(t, []testCommit{
+		{"Skewed", now.Add(-2 * time.Hour), now.Add(-24*time.Hour - 30*time.Minute)},
+		{"Latest", now.Add(-10 * time.Minute), now.Add(-10 * time.Minute)},
+	})
+	repo := config.Repo{Name: "test-repo", Path: repoPath}
+
+	monitor := NewMonitorWithRepos([]config.Repo{})
+	monitor.SetDays(1)
+
+	commits, err := monitor.getRepoCommits(context.Background(), repo)
+	if err != nil {
+		t.Fatalf("Failed to get commits: %v", err)
+	}
+	if !containsMessage(commits, "Skewed") {
+		t.Errorf("Expected skewed commit within the default tolerance, got %v", commitMessages(commits))
+	}
+
+	monitor.SetClockSkew(0)
+	commits, err = monitor.getRepoCommits(context.Background(), repo)
+	if err != nil {
+		t.Fatalf("Failed to get commits: %v", err)
+	}
+	if containsMessage(commits, "Skewed") {
+		t.Errorf("Expected skewed commit to be pruned without tolerance, got %v", commitMessages(commits))
+	}
+}
+
+func TestMonitor_collectRepo_ShallowBoundary(t *testing.T) {
+	now := time.Now()
+	repoPath, hashes := initRepoWithCommits(t, []testCommit{
+		{"First", now.Add(-3 * time.Hour), now.Add(-3 * time.Hour)},
+		{"Second", now.Add(-2 * time.Hour), now.Add(-2 * time.Hour)},
+		{"Third", now.Add(-time.Hour), now.Add(-time.Hour)},
+	})
+
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pretend the history was fetched with depth 2
+	if err := repo.Storer.SetShallow([]plumbing.Hash{hashes[1]}); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor := NewMonitorWithRepos([]config.Repo{})
+	monitor.SetDays(1)
+
+	result := RepoResult{}
+	if err := monitor.collectRepo(context.Background(), config.Repo{Name: "shallow", Path: repoPath}, &result); err != nil {
+		t.Fatalf("collectRepo failed: %v", err)
+	}
+	if !result.Truncated {
+		t.Error("Expected result to be marked truncated at the shallow boundary")
+	}
+	if len(result.Commits) != 2 || containsMessage(result.Commits, "First") {
+		t.Errorf("Expected only commits above the boundary, got %v", commitMessages(result.Commits))
+	}
+
+	// A window that ends above the boundary is complete
+	monitor.SetDays(0)
+	monitor.SetClockSkew(0)
+	result = RepoResult{}
+	if err := monitor.collectRepo(context.Background(), config.Repo{Name: "shallow", Path: repoPath}, &result); err != nil {
+		t.Fatalf("collectRepo failed: %v", err)
+	}
+	if result.Truncated {
+		t.Error("Expected result not to be truncated when the window ends above the boundary")
+	}
+}
+
+func TestValidDateField(t *testing.T) {
+	if !ValidDateField("author") || !ValidDateField("committer") {
+		t.Error("Expected author and committer to be valid date fields")
+	}
+	if ValidDateField("commit") {
+		t.Error("Expected 'commit' to be an invalid date field")
+	}
+}
+
+func TestMonitor_getRepoCommits_Window(t *testing.T) {
+	// Commit dates have second precision, so the boundaries must too
+	now := time.Now().Truncate(time.Second)
+	day := func(n int) time.Time { return now.AddDate(0, 0, -n) }
+	repoPath, _ := initRepoWithCommits(t, []testCommit{
+		{"Before sprint", day(20), day(20)},
+		{"Sprint start", day(14), day(14)},
+		{"Sprint end", day(8), day(8)},
+		{"After sprint", day(3), day(3)},
+	})
+	repo := config.Repo{Name: "test-repo", Path: repoPath}
+
+	tests := []struct {
+		name  string
+		since time.Time
+		until time.Time
+		want  []string
+	}{
+		{name: "closed window", since: day(15), until: day(7), want: []string{"Sprint end", "Sprint start"}},
+		{name: "open-ended since", since: day(10), want: []string{"After sprint", "Sprint end"}},
+		{name: "until excludes the boundary", since: day(15), until: day(8), want: []string{"Sprint start"}},
+		{name: "zero since falls back to days", until: day(5), want: []string{"Sprint end", "Sprint start"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := NewMonitorWithRepos([]config.Repo{})
+			monitor.SetDays(15)
+			monitor.SetWindow(tt.since, tt.until)
+
+			commits, err := monitor.getRepoCommits(context.Background(), repo)
+			if err != nil {
+				t.Fatalf("Failed to get commits: %v", err)
+			}
+			got := commitMessages(commits)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/plars/repomon/internal/git"
+)
+
+// FormatBranches formats the stale branch report of each repository
+func (f *Formatter) FormatBranches(results []git.RepoResult) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("Stale Branch Report\n" +
+		"===================\n\n")
+	stale := 0
+	for _, result := range results {
+		if result.Error != nil {
+			section, err := f.FormatResult(result)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(section)
+			continue
+		}
+
+		fmt.Fprintf(&sb, "📁 %s (default branch: %s)\n", result.Repo.Name, result.DefaultBranch)
+		if !result.FetchedAt.IsZero() {
+			fmt.Fprintf(&sb, "   🕒 Cached data, fetched %s\n", f.formatRelativeTime(result.FetchedAt))
+		}
+		if len(result.Branches) == 0 {
+			sb.WriteString("   ✅ No stale branches\n")
+		}
+		for _, branch := range result.Branches {
+			merged := "not merged"
+			if branch.Merged {
+				merged = "merged"
+			}
+			fmt.Fprintf(&sb, "   • %s - %s, last commit %s [%s]\n", branch.Name, branch.LastAuthor, f.formatRelativeTime(branch.LastCommit), merged)
+		}
+		sb.WriteString("\n")
+		stale += len(result.Branches)
+	}
+	if stale == 0 {
+		sb.WriteString("No stale branches found in any repository.\n")
+	}
+	return sb.String(), nil
+}
+
+type jsonBranchReport struct {
+	Repos []jsonBranchRepo `json:"repos"`
+}
+
+type jsonBranchRepo struct {
+	Name   string `json:"name"`
+	Path   string `json:"path,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	jsonRepoError
+	DefaultBranch string       `json:"default_branch,omitempty"`
+	Branches      []jsonBranch `json:"branches"`
+}
+
+type jsonBranch struct {
+	Name       string    `json:"name"`
+	Hash       string    `json:"hash"`
+	LastCommit time.Time `json:"last_commit"`
+	LastAuthor string    `json:"last_author"`
+	Merged     bool      `json:"merged"`
+}
+
+// FormatBranches formats the stale branch report as indented JSON
+func (f *JSONFormatter) FormatBranches(results []git.RepoResult) (string, error) {
+	report := jsonBranchReport{Repos: make([]jsonBranchRepo, 0, len(results))}
+	for _, result := range results {
+		report.Repos = append(report.Repos, newJSONBranchRepo(result))
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// FormatBranches formats the stale branch report as a line of JSON per repository
+func (f *JSONLinesFormatter) FormatBranches(results []git.RepoResult) (string, error) {
+	var sb strings.Builder
+	for _, result := range results {
+		data, err := json.Marshal(newJSONBranchRepo(result))
+		if err != nil {
+			return "", fmt.Errorf("failed to encode JSON result: %w", err)
+		}
+		sb.Write(data)
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+func newJSONBranchRepo(result git.RepoResult) jsonBranchRepo {
+	repo := jsonBranchRepo{
+		Name:          result.Repo.Name,
+		Path:          result.Repo.Path,
+		URL:           result.Repo.URL,
+		Branch:        result.Repo.Branch,
+		jsonRepoError: newJSONRepoError(result.Error),
+		DefaultBranch: result.DefaultBranch,
+		Branches:      make([]jsonBranch, 0, len(result.Branches)),
+	}
+	for _, branch := range result.Branches {
+		repo.Branches = append(repo.Branches, jsonBranch{
+			Name:       branch.Name,
+			Hash:       branch.Hash,
+			LastCommit: branch.LastCommit,
+			LastAuthor: branch.LastAuthor,
+			Merged:     branch.Merged,
+		})
+	}
+	return repo
+}
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/plars/repomon/internal/config"
+	"github.com/plars/repomon/internal/git"
+)
+
+func TestFormatBranches(t *testing.T) {
+	lastCommit := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	results := []git.RepoResult{
+		{
+			Repo:          config.Repo{Name: "repomon"},
+			DefaultBranch: "main",
+			Branches: []git.Branch{
+				{Name: "old-feature", Hash: "abc123", LastCommit: lastCommit, LastAuthor: "Alice", Merged: true},
+				{Name: "spike", Hash: "def456", LastCommit: lastCommit, LastAuthor: "Bob"},
+			},
+		},
+		{Repo: config.Repo{Name: "tool"}, DefaultBranch: "master"},
+	}
+
+	text, err := NewFormatter().FormatBranches(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"📁 repomon (default branch: main)",
+		"• old-feature - Alice, last commit 2020-01-01 [merged]",
+		"• spike - Bob, last commit 2020-01-01 [not merged]",
+		"📁 tool (default branch: master)\n   ✅ No stale branches",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "No stale branches found") {
+		t.Error("Expected no closing note when a repository has stale branches")
+	}
+
+	lines, err := NewJSONLinesFormatter().FormatBranches(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(strings.TrimSuffix(lines, "\n"), "\n")
+	if len(parts) != 2 {
+		t.Fatalf("Expected a line per repository, got %q", lines)
+	}
+	var repo jsonBranchRepo
+	if err := json.Unmarshal([]byte(parts[0]), &repo); err != nil {
+		t.Fatal(err)
+	}
+	if repo.DefaultBranch != "main" || len(repo.Branches) != 2 || repo.Branches[1].Name != "spike" ||
+		repo.Branches[1].Merged || !repo.Branches[0].LastCommit.Equal(lastCommit) {
+		t.Errorf("Unexpected JSON repository %+v", repo)
+	}
+	if !strings.Contains(parts[1], `"branches":[]`) {
+		t.Errorf("Expected an empty list of branches, got %s", parts[1])
+	}
+}
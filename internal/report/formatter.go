@@ -1,6 +1,7 @@
 package report
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -8,59 +9,372 @@ import (
 	"github.com/plars/repomon/internal/git"
 )
 
+// Report layouts
+const (
+	// LayoutList lists each repository's commits in history order
+	LayoutList = "list"
+	// LayoutConventional groups each repository's commits by Conventional Commits type
+	LayoutConventional = "conventional"
+)
+
+// Options controls how a report is rendered
+type Options struct {
+	// Format selects the output format, "text", "json" or "jsonl"
+	Format string
+	Layout string
+	// Long shows full commit bodies
+	Long bool
+}
+
 // Formatter formats repository results into human-readable reports
-type Formatter struct{}
+type Formatter struct {
+	opts Options
+}
 
 // NewFormatter creates a new report formatter
 func NewFormatter() *Formatter {
-	return &Formatter{}
+	return NewFormatterWithOptions(Options{Layout: LayoutList})
+}
+
+// NewFormatterWithOptions creates a report formatter with the given options
+func NewFormatterWithOptions(opts Options) *Formatter {
+	if opts.Layout == "" {
+		opts.Layout = LayoutList
+	}
+	return &Formatter{opts: opts}
+}
+
+// ValidLayout reports whether layout is a supported report layout
+func ValidLayout(layout string) bool {
+	return layout == LayoutList || layout == LayoutConventional
 }
 
 // Format formats the repository results into a human-readable report
 func (f *Formatter) Format(results []git.RepoResult) (string, error) {
 	var sb strings.Builder
+	sb.WriteString(f.Header())
+	for _, result := range results {
+		section, err := f.FormatResult(result)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(section)
+	}
+	sb.WriteString(f.Footer(results))
+	return sb.String(), nil
+}
 
-	// Header
-	sb.WriteString("Repository Monitor Report\n")
-	sb.WriteString("========================\n\n")
+// Header returns the report title
+func (f *Formatter) Header() string {
+	return "Repository Monitor Report\n" +
+		"========================\n\n"
+}
 
-	hasAnyCommits := false
+// FormatResult formats the report section of a single repository
+func (f *Formatter) FormatResult(result git.RepoResult) (string, error) {
+	var sb strings.Builder
 
-	// Process each repository in order
-	for _, result := range results {
-		repoHeader := fmt.Sprintf("📁 %s", result.Repo.Name)
-		if result.Repo.Branch != "" {
-			repoHeader = fmt.Sprintf("📁 %s (%s)", result.Repo.Name, result.Repo.Branch)
-		}
+	repoHeader := fmt.Sprintf("📁 %s", result.Repo.Name)
+	if result.Repo.Branch != "" {
+		repoHeader = fmt.Sprintf("📁 %s (%s)", result.Repo.Name, result.Repo.Branch)
+	}
 
-		if result.Error != nil {
-			sb.WriteString(repoHeader + "\n")
-			fmt.Fprintf(&sb, "   ❌ Error: %s\n\n", result.Error.Error())
-			continue
+	if errors.Is(result.Error, git.ErrNotCached) {
+		sb.WriteString(repoHeader + "\n")
+		sb.WriteString("   📴 Not available offline: never cached\n\n")
+		return sb.String(), nil
+	}
+	if git.KindOf(result.Error) == git.KindCancelled {
+		sb.WriteString(repoHeader + "\n")
+		sb.WriteString("   ⏹️  Cancelled: the run ended before this repository was done\n\n")
+		return sb.String(), nil
+	}
+	if result.Error != nil {
+		sb.WriteString(repoHeader + "\n")
+		fmt.Fprintf(&sb, "   ❌ Error: %s\n", result.Error.Error())
+		if hint := git.KindOf(result.Error).Hint(); hint != "" {
+			fmt.Fprintf(&sb, "   💡 %s\n", hint)
 		}
+		sb.WriteString("\n")
+		return sb.String(), nil
+	}
 
-		if len(result.Commits) == 0 {
-			sb.WriteString(repoHeader + "\n")
-			sb.WriteString("   ✅ No recent commits\n\n")
-			continue
+	sb.WriteString(repoHeader + "\n")
+	if !result.FetchedAt.IsZero() {
+		fmt.Fprintf(&sb, "   🕒 Cached data, fetched %s\n", f.formatRelativeTime(result.FetchedAt))
+	}
+	if rewrite := result.Rewrite; rewrite != nil {
+		branch := "the branch"
+		if result.Repo.Branch != "" {
+			branch = result.Repo.Branch
 		}
+		fmt.Fprintf(&sb, "   🚨 History rewritten: %s was force-pushed from %s to %s (found %s)\n",
+			branch, shortHash(rewrite.OldTip), shortHash(rewrite.NewTip), f.formatRelativeTime(rewrite.DetectedAt))
+		if len(rewrite.Dropped) > 0 {
+			sb.WriteString("   Dropped commits:\n")
+			for _, commit := range rewrite.Dropped {
+				f.writeCommitLine(&sb, commit, commit.Message)
+			}
+		}
+	}
+	if result.Truncated {
+		sb.WriteString("   ⚠️  History truncated: older commits in the window may be missing\n")
+	}
+	if result.Status != nil {
+		fmt.Fprintf(&sb, "   🌿 %s\n", formatStatus(result.Status))
+	}
+	if result.FetchError != nil {
+		fmt.Fprintf(&sb, "   ⚠️  Fetch failed, incoming commits may be missing: %s\n", result.FetchError.Error())
+	}
+	for _, violation := range result.Violations {
+		fmt.Fprintf(&sb, "   🚨 Expectation not met: %s\n", violation)
+	}
 
-		hasAnyCommits = true
-		sb.WriteString(repoHeader + "\n")
+	if len(result.Commits) == 0 && !result.LastCommit.IsZero() {
+		fmt.Fprintf(&sb, "   ✅ No recent commits (last commit %s)\n", f.formatRelativeTime(result.LastCommit))
+	} else if len(result.Commits) == 0 {
+		sb.WriteString("   ✅ No recent commits\n")
+	} else if f.opts.Layout == LayoutConventional {
+		f.writeConventionalSections(&sb, result.Commits)
+	} else {
 		sb.WriteString("   Recent commits:\n")
-
 		for _, commit := range result.Commits {
-			timeStr := f.formatRelativeTime(commit.Timestamp)
-			fmt.Fprintf(&sb, "   • %s - %s (%s)\n", commit.Message, commit.Author, timeStr)
+			f.writeCommitLine(&sb, commit, commit.Message)
+		}
+	}
+
+	if len(result.Incoming) > 0 {
+		fmt.Fprintf(&sb, "   Incoming from %s (not pulled yet):\n", result.IncomingFrom)
+		for _, commit := range result.Incoming {
+			f.writeCommitLine(&sb, commit, commit.Message)
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String(), nil
+}
+
+// Footer returns the closing notes of the report: the repositories with rewritten
+// history, the dormant ones along with those that could not be checked for dormancy,
+// and a note when no repository has recent commits
+func (f *Formatter) Footer(results []git.RepoResult) string {
+	var sb strings.Builder
+	var rewritten, dormant, unknown []git.RepoResult
+	for _, result := range results {
+		if result.Rewrite != nil {
+			rewritten = append(rewritten, result)
+		}
+		if result.Dormant {
+			dormant = append(dormant, result)
+		}
+		if result.DormancyUnknown {
+			unknown = append(unknown, result)
+		}
+	}
+	if len(rewritten) > 0 {
+		sb.WriteString("🚨 Rewritten history (force pushes):\n")
+		for _, result := range rewritten {
+			fmt.Fprintf(&sb, "   • %s - from %s to %s\n", result.Repo.Name, shortHash(result.Rewrite.OldTip), shortHash(result.Rewrite.NewTip))
+		}
+		sb.WriteString("\n")
+	}
+	if len(dormant) > 0 || len(unknown) > 0 {
+		sb.WriteString("💤 Dormant repositories:\n")
+		for _, result := range dormant {
+			days := int(result.Idle.Hours() / 24)
+			fmt.Fprintf(&sb, "   • %s - last commit %s (%d days ago)\n", result.Repo.Name, result.LastCommit.Format("2006-01-02"), days)
+		}
+		if len(dormant) == 0 {
+			sb.WriteString("   • None\n")
+		}
+		// Repositories that errored or have no commits are left out of the check, which
+		// should not pass for them being active
+		for _, result := range unknown {
+			reason := "no commits"
+			if result.Error != nil {
+				reason = "could not be read"
+			}
+			fmt.Fprintf(&sb, "   ? %s - not checked (%s)\n", result.Repo.Name, reason)
 		}
 		sb.WriteString("\n")
 	}
 
-	if !hasAnyCommits {
-		sb.WriteString("No recent commits found in any repository.\n")
+	for _, result := range results {
+		if result.Error == nil && (len(result.Commits) > 0 || len(result.Incoming) > 0) {
+			return sb.String()
+		}
 	}
+	sb.WriteString("No recent commits found in any repository.\n")
+	return sb.String()
+}
 
-	return sb.String(), nil
+// writeCommitLine writes a single commit bullet using the given text as its summary
+func (f *Formatter) writeCommitLine(sb *strings.Builder, commit git.Commit, text string) {
+	timeStr := f.formatRelativeTime(commit.Timestamp)
+	// Co-authors are credited alongside the author
+	author := strings.Join(commit.Authors(), ", ")
+	fmt.Fprintf(sb, "   • %s - %s (%s)", text, author, timeStr)
+	if commit.Stat != nil {
+		fmt.Fprintf(sb, " [%s]", formatStat(commit.Stat))
+	}
+	if commit.Signature != nil {
+		fmt.Fprintf(sb, " [%s]", formatSignature(commit.Signature))
+	}
+	sb.WriteString("\n")
+
+	if f.opts.Long && commit.Body != "" {
+		for _, line := range strings.Split(commit.Body, "\n") {
+			sb.WriteString(strings.TrimRight("     "+line, " \t") + "\n")
+		}
+	}
+}
+
+// shortHash abbreviates a commit hash as git does by default
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// formatStatus formats a working tree status like
+// "On main, 3 unpushed and 1 behind origin/main; 2 staged, 4 untracked, 1 stash"
+func formatStatus(status *git.WorkTreeStatus) string {
+	var sb strings.Builder
+	if status.Branch != "" {
+		fmt.Fprintf(&sb, "On %s", status.Branch)
+	} else {
+		sb.WriteString("Detached HEAD")
+	}
+
+	switch {
+	case status.UpstreamGone:
+		fmt.Fprintf(&sb, ", %s is gone", status.Upstream)
+	case status.Upstream == "" && status.Branch != "":
+		sb.WriteString(", no upstream")
+	case status.Ahead > 0 && status.Behind > 0:
+		fmt.Fprintf(&sb, ", %d unpushed and %d behind %s", status.Ahead, status.Behind, status.Upstream)
+	case status.Ahead > 0:
+		fmt.Fprintf(&sb, ", %d unpushed to %s", status.Ahead, status.Upstream)
+	case status.Behind > 0:
+		fmt.Fprintf(&sb, ", %d behind %s", status.Behind, status.Upstream)
+	case status.Upstream != "":
+		fmt.Fprintf(&sb, ", up to date with %s", status.Upstream)
+	}
+
+	var changes []string
+	counts := []struct {
+		n    int
+		what string
+	}{
+		{status.Staged, "staged"},
+		{status.Modified, "modified"},
+		{status.Conflicts, "conflicted"},
+		{status.Untracked, "untracked"},
+	}
+	for _, count := range counts {
+		if count.n > 0 {
+			changes = append(changes, fmt.Sprintf("%d %s", count.n, count.what))
+		}
+	}
+	if status.Stashes == 1 {
+		changes = append(changes, "1 stash")
+	} else if status.Stashes > 1 {
+		changes = append(changes, fmt.Sprintf("%d stashes", status.Stashes))
+	}
+	if len(changes) == 0 {
+		sb.WriteString("; working tree clean")
+	} else {
+		sb.WriteString("; " + strings.Join(changes, ", "))
+	}
+	return sb.String()
+}
+
+// formatSignature formats a signature marker like "verified: alice@example.com"
+func formatSignature(sig *git.CommitSignature) string {
+	if sig.Status == git.SignatureVerified && sig.Signer != "" {
+		return fmt.Sprintf("%s: %s", sig.Status, sig.Signer)
+	}
+	return string(sig.Status)
+}
+
+// formatStat formats a diffstat like "+120 -34, 7 files"
+func formatStat(stat *git.DiffStat) string {
+	files := "files"
+	if stat.FilesChanged == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("+%d -%d, %d %s", stat.Insertions, stat.Deletions, stat.FilesChanged, files)
+}
+
+// conventionalSections lists the section titles of the conventional layout in display order.
+// Breaking changes come first; commit types not listed here fall into "Other".
+var conventionalSections = []struct {
+	commitType string
+	title      string
+}{
+	{"feat", "Features"},
+	{"fix", "Fixes"},
+	{"perf", "Performance"},
+	{"refactor", "Refactoring"},
+	{"revert", "Reverts"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build"},
+	{"ci", "CI"},
+	{"style", "Style"},
+	{"chore", "Chores"},
+}
+
+// writeConventionalSections writes commits grouped by Conventional Commits type.
+// Breaking commits are listed only under "Breaking changes", and commits that do not
+// follow the convention (or use an unknown type) are listed under "Other".
+func (f *Formatter) writeConventionalSections(sb *strings.Builder, commits []git.Commit) {
+	var breaking, other []git.Commit
+	byType := make(map[string][]git.Commit)
+	known := make(map[string]bool, len(conventionalSections))
+	for _, s := range conventionalSections {
+		known[s.commitType] = true
+	}
+
+	for _, commit := range commits {
+		cc := commit.Conventional
+		switch {
+		case cc == nil:
+			other = append(other, commit)
+		case cc.Breaking:
+			breaking = append(breaking, commit)
+		case known[cc.Type]:
+			byType[cc.Type] = append(byType[cc.Type], commit)
+		default:
+			other = append(other, commit)
+		}
+	}
+
+	f.writeConventionalSection(sb, "⚠️  Breaking changes", breaking, false)
+	for _, s := range conventionalSections {
+		f.writeConventionalSection(sb, s.title, byType[s.commitType], false)
+	}
+	// The section title doesn't imply a type for "Other", so keep the full subject
+	f.writeConventionalSection(sb, "Other", other, true)
+}
+
+// writeConventionalSection writes one titled section, skipping it when empty.
+// Commits are shown as "scope: description" unless fullSubject is set.
+func (f *Formatter) writeConventionalSection(sb *strings.Builder, title string, commits []git.Commit, fullSubject bool) {
+	if len(commits) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "   %s:\n", title)
+	for _, commit := range commits {
+		text := commit.Message
+		if cc := commit.Conventional; cc != nil && !fullSubject {
+			text = cc.Description
+			if cc.Scope != "" {
+				text = fmt.Sprintf("%s: %s", cc.Scope, cc.Description)
+			}
+		}
+		f.writeCommitLine(sb, commit, text)
+	}
 }
 
 // formatRelativeTime formats a timestamp as relative time
@@ -1,6 +1,8 @@
 package report
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -72,6 +74,26 @@ func TestFormatter_Format(t *testing.T) {
 		t.Error("Output should contain error message")
 	}
 
+	// Classified errors come with a hint
+	results[1].Error = &git.RepoError{Kind: git.KindAuthRequired, Err: fmt.Errorf("fatal: Authentication failed")}
+	output, err = formatter.Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "💡 "+git.KindAuthRequired.Hint()) {
+		t.Errorf("Output should contain a hint for the error:\n%s", output)
+	}
+
+	// Unmet expectations are listed
+	results[2].Violations = []string{"expected a commit within 14 days, last was 30 days ago"}
+	output, err = formatter.Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "🚨 Expectation not met: expected a commit within 14 days, last was 30 days ago") {
+		t.Errorf("Output should contain the unmet expectation:\n%s", output)
+	}
+
 	// Check no commits message
 	if !strings.Contains(output, "No recent commits") {
 		t.Error("Output should contain 'No recent commits'")
@@ -99,6 +121,79 @@ func TestFormatter_Format_NoCommits(t *testing.T) {
 	}
 }
 
+func TestFormatter_Format_Dormant(t *testing.T) {
+	lastCommit := time.Now().AddDate(-2, 0, 0)
+	results := []git.RepoResult{
+		{Repo: config.Repo{Name: "active"}, Commits: []git.Commit{{Hash: "a", Message: "Fix", Author: "Jane", Timestamp: time.Now()}}, LastCommit: time.Now()},
+		{Repo: config.Repo{Name: "abandoned"}, LastCommit: lastCommit, Dormant: true, Idle: 730 * 24 * time.Hour},
+		{Repo: config.Repo{Name: "broken"}, Error: errors.New("boom"), DormancyUnknown: true},
+		{Repo: config.Repo{Name: "empty"}, DormancyUnknown: true},
+	}
+
+	output, err := NewFormatter().Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"📁 abandoned\n   ✅ No recent commits (last commit " + lastCommit.Format("2006-01-02") + ")",
+		// The age is the one taken when checked, however long the report takes
+		"💤 Dormant repositories:\n   • abandoned - last commit " + lastCommit.Format("2006-01-02") + " (730 days ago)\n" +
+			"   ? broken - not checked (could not be read)\n" +
+			"   ? empty - not checked (no commits)\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Output should contain %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "• active - last commit") {
+		t.Errorf("Only dormant repositories should be listed:\n%s", output)
+	}
+
+	t.Run("none dormant", func(t *testing.T) {
+		output, err := NewFormatter().Format(results[2:])
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := "💤 Dormant repositories:\n   • None\n   ? broken - not checked (could not be read)\n"
+		if !strings.Contains(output, want) {
+			t.Errorf("Output should contain %q:\n%s", want, output)
+		}
+	})
+}
+
+func TestFormatter_Format_Rewrite(t *testing.T) {
+	results := []git.RepoResult{
+		{
+			Repo:    config.Repo{Name: "release", Branch: "release-1.x"},
+			Commits: []git.Commit{{Hash: "c", Message: "Replacement", Author: "Bob", Timestamp: time.Now()}},
+			Rewrite: &git.Rewrite{
+				OldTip:     "44ad1d718e15bc31daa4fdfb1c1a8f4abab8f613",
+				NewTip:     "ce0da052b7907c8237c6cc921cd9fd92c3db0a17",
+				DetectedAt: time.Now().Add(-2 * time.Hour),
+				Dropped:    []git.Commit{{Hash: "44ad1d7", Message: "Hotfix", Author: "Jane", Timestamp: time.Now().Add(-3 * time.Hour)}},
+			},
+		},
+		{Repo: config.Repo{Name: "quiet"}},
+	}
+
+	output, err := NewFormatter().Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"📁 release (release-1.x)\n   🚨 History rewritten: release-1.x was force-pushed from 44ad1d7 to ce0da05 (found 2 hours ago)",
+		"   Dropped commits:\n   • Hotfix - Jane (3 hours ago)",
+		"🚨 Rewritten history (force pushes):\n   • release - from 44ad1d7 to ce0da05",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Output should contain %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "• quiet - from") {
+		t.Errorf("Only rewritten repositories should be listed:\n%s", output)
+	}
+}
+
 func TestFormatter_formatRelativeTime(t *testing.T) {
 	formatter := NewFormatter()
 
@@ -152,3 +247,308 @@ func TestFormatter_formatRelativeTime(t *testing.T) {
 		t.Errorf("Expected date format '%s', got '%s'", expected, result)
 	}
 }
+
+func TestFormatter_Format_ConventionalLayout(t *testing.T) {
+	formatter := NewFormatterWithOptions(Options{Layout: LayoutConventional})
+
+	now := time.Now()
+	results := []git.RepoResult{
+		{
+			Repo: config.Repo{Name: "conventional-repo", Path: "/path/to/repo"},
+			Commits: []git.Commit{
+				{
+					Message:      "feat(api): add endpoint",
+					Author:       "Alice",
+					Timestamp:    now,
+					Conventional: &git.ConventionalCommit{Type: "feat", Scope: "api", Description: "add endpoint"},
+				},
+				{
+					Message:      "fix: handle nil config",
+					Author:       "Bob",
+					Timestamp:    now,
+					Conventional: &git.ConventionalCommit{Type: "fix", Description: "handle nil config"},
+				},
+				{
+					Message:      "feat!: drop legacy flags",
+					Author:       "Carol",
+					Timestamp:    now,
+					Conventional: &git.ConventionalCommit{Type: "feat", Description: "drop legacy flags", Breaking: true},
+				},
+				{
+					Message:   "Merge branch 'main'",
+					Author:    "Dave",
+					Timestamp: now,
+				},
+				{
+					Message:      "wip: experiment",
+					Author:       "Eve",
+					Timestamp:    now,
+					Conventional: &git.ConventionalCommit{Type: "wip", Description: "experiment"},
+				},
+			},
+		},
+	}
+
+	output, err := formatter.Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Sections appear in order: breaking, features, fixes, other
+	order := []string{"Breaking changes:", "drop legacy flags", "Features:", "api: add endpoint", "Fixes:", "handle nil config", "Other:", "Merge branch 'main'", "wip: experiment"}
+	last := -1
+	for _, want := range order {
+		idx := strings.Index(output, want)
+		if idx == -1 {
+			t.Fatalf("Output should contain %q, got:\n%s", want, output)
+		}
+		if idx < last {
+			t.Errorf("Expected %q to appear after previous section entries, got:\n%s", want, output)
+		}
+		last = idx
+	}
+
+	// Breaking commits are listed only once
+	if strings.Count(output, "drop legacy flags") != 1 {
+		t.Errorf("Expected breaking commit to be listed once, got:\n%s", output)
+	}
+	if strings.Contains(output, "Recent commits:") {
+		t.Error("Conventional layout should not use the flat commit list")
+	}
+}
+
+func TestValidLayout(t *testing.T) {
+	for _, layout := range []string{LayoutList, LayoutConventional} {
+		if !ValidLayout(layout) {
+			t.Errorf("Expected %q to be a valid layout", layout)
+		}
+	}
+	if ValidLayout("tree") {
+		t.Error("Expected 'tree' to be an invalid layout")
+	}
+}
+
+func TestFormatter_Format_WithStat(t *testing.T) {
+	formatter := NewFormatter()
+
+	results := []git.RepoResult{
+		{
+			Repo: config.Repo{Name: "stat-repo", Path: "/path/to/repo"},
+			Commits: []git.Commit{
+				{
+					Message:   "Big refactor",
+					Author:    "Alice",
+					Timestamp: time.Now(),
+					Stat:      &git.DiffStat{FilesChanged: 7, Insertions: 120, Deletions: 34},
+				},
+				{
+					Message:   "Fix typo",
+					Author:    "Bob",
+					Timestamp: time.Now(),
+					Stat:      &git.DiffStat{FilesChanged: 1, Insertions: 1, Deletions: 1},
+				},
+				{
+					Message:   "No stats",
+					Author:    "Carol",
+					Timestamp: time.Now(),
+				},
+			},
+		},
+	}
+
+	output, err := formatter.Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output, "Big refactor - Alice (0 minutes ago) [+120 -34, 7 files]") {
+		t.Errorf("Expected diffstat for multi-file commit, got:\n%s", output)
+	}
+	if !strings.Contains(output, "[+1 -1, 1 file]") {
+		t.Errorf("Expected singular file count, got:\n%s", output)
+	}
+	if !strings.Contains(output, "No stats - Carol (0 minutes ago)\n") {
+		t.Errorf("Expected commit without stats to have no diffstat, got:\n%s", output)
+	}
+}
+
+func TestFormatter_Format_Long(t *testing.T) {
+	results := []git.RepoResult{
+		{
+			Repo: config.Repo{Name: "long-repo", Path: "/path/to/repo"},
+			Commits: []git.Commit{
+				{
+					Message:   "Add pairing notes",
+					Author:    "Alice",
+					CoAuthors: []string{"Bob"},
+					Body:      "Written together.\n\nCo-authored-by: Bob <bob@example.com>",
+					Timestamp: time.Now(),
+				},
+			},
+		},
+	}
+
+	output, err := NewFormatterWithOptions(Options{Long: true}).Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "Add pairing notes - Alice, Bob (") {
+		t.Errorf("Expected co-authors next to the author, got:\n%s", output)
+	}
+	if !strings.Contains(output, "     Written together.\n\n     Co-authored-by: Bob <bob@example.com>\n") {
+		t.Errorf("Expected indented body, got:\n%s", output)
+	}
+
+	// Without --long, the body is left out but co-authors are still credited
+	output, err = NewFormatter().Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(output, "Written together.") || !strings.Contains(output, "Add pairing notes - Alice, Bob (") {
+		t.Errorf("Expected short output without body but with co-authors, got:\n%s", output)
+	}
+}
+
+func TestFormatter_Format_SignatureMarkers(t *testing.T) {
+	results := []git.RepoResult{
+		{
+			Repo: config.Repo{Name: "signed-repo", Path: "/path/to/repo"},
+			Commits: []git.Commit{
+				{Message: "Verified", Author: "Alice", Timestamp: time.Now(), Signature: &git.CommitSignature{Status: git.SignatureVerified, Signer: "alice@example.com"}},
+				{Message: "Unverified", Author: "Bob", Timestamp: time.Now(), Signature: &git.CommitSignature{Status: git.SignatureUnverified}},
+				{Message: "Unsigned", Author: "Carol", Timestamp: time.Now(), Signature: &git.CommitSignature{Status: git.SignatureUnsigned}},
+				{Message: "Not checked", Author: "Dave", Timestamp: time.Now()},
+			},
+		},
+	}
+
+	output, err := NewFormatter().Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"[verified: alice@example.com]", "Unverified - Bob (0 minutes ago) [unverified]", "[unsigned]", "Not checked - Dave (0 minutes ago)\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Output should contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestFormatter_Format_Truncated(t *testing.T) {
+	results := []git.RepoResult{
+		{
+			Repo:      config.Repo{Name: "shallow-repo", URL: "https://github.com/example/shallow"},
+			Commits:   []git.Commit{{Message: "Recent", Author: "Alice", Timestamp: time.Now()}},
+			Truncated: true,
+		},
+		{
+			Repo:    config.Repo{Name: "complete-repo", Path: "/path/to/repo"},
+			Commits: []git.Commit{{Message: "Other", Author: "Bob", Timestamp: time.Now()}},
+		},
+	}
+
+	output, err := NewFormatter().Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Count(output, "History truncated") != 1 {
+		t.Errorf("Expected one truncation warning, got:\n%s", output)
+	}
+	if strings.Index(output, "History truncated") > strings.Index(output, "complete-repo") {
+		t.Errorf("Expected truncation warning under the truncated repo, got:\n%s", output)
+	}
+}
+
+func TestFormatter_Format_Offline(t *testing.T) {
+	results := []git.RepoResult{
+		{
+			Repo:      config.Repo{Name: "cached-repo", URL: "https://github.com/example/cached"},
+			Commits:   []git.Commit{{Message: "Recent", Author: "Alice", Timestamp: time.Now()}},
+			FetchedAt: time.Now().Add(-3 * time.Hour),
+		},
+		{
+			Repo:  config.Repo{Name: "new-repo", URL: "https://github.com/example/new"},
+			Error: fmt.Errorf("wrapped: %w", git.ErrNotCached),
+		},
+	}
+
+	output, err := NewFormatter().Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "🕒 Cached data, fetched 3 hours ago") {
+		t.Errorf("Expected the data age of the cached repo, got:\n%s", output)
+	}
+	if !strings.Contains(output, "📴 Not available offline") || strings.Contains(output, "Error") {
+		t.Errorf("Expected a not available offline state instead of an error, got:\n%s", output)
+	}
+}
+
+func TestFormatter_Format_Cancelled(t *testing.T) {
+	results := []git.RepoResult{
+		{
+			Repo:  config.Repo{Name: "slow-repo", URL: "https://github.com/example/slow"},
+			Error: &git.RepoError{Kind: git.KindCancelled, Err: context.Canceled},
+		},
+	}
+
+	output, err := NewFormatter().Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "⏹️  Cancelled") || strings.Contains(output, "Error") {
+		t.Errorf("Expected a cancelled state instead of an error, got:\n%s", output)
+	}
+}
+
+func TestFormatStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status git.WorkTreeStatus
+		want   string
+	}{
+		{name: "clean", status: git.WorkTreeStatus{Branch: "main", Upstream: "origin/main"}, want: "On main, up to date with origin/main; working tree clean"},
+		{name: "unpushed", status: git.WorkTreeStatus{Branch: "main", Upstream: "origin/main", Ahead: 3}, want: "On main, 3 unpushed to origin/main; working tree clean"},
+		{name: "diverged", status: git.WorkTreeStatus{Branch: "main", Upstream: "origin/main", Ahead: 3, Behind: 1, Staged: 2, Untracked: 4, Stashes: 1}, want: "On main, 3 unpushed and 1 behind origin/main; 2 staged, 4 untracked, 1 stash"},
+		{name: "no upstream", status: git.WorkTreeStatus{Branch: "wip", Modified: 1, Stashes: 2}, want: "On wip, no upstream; 1 modified, 2 stashes"},
+		{name: "upstream gone", status: git.WorkTreeStatus{Branch: "old", Upstream: "origin/old", UpstreamGone: true}, want: "On old, origin/old is gone; working tree clean"},
+		{name: "detached", status: git.WorkTreeStatus{Conflicts: 1}, want: "Detached HEAD; 1 conflicted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatStatus(&tt.status); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatter_Format_Incoming(t *testing.T) {
+	now := time.Now()
+	results := []git.RepoResult{
+		{
+			Repo:         config.Repo{Name: "app", Path: "/src/app"},
+			Commits:      []git.Commit{{Hash: "a1", Message: "Local work", Author: "Alice", Timestamp: now}},
+			IncomingFrom: "origin/main",
+			Incoming:     []git.Commit{{Hash: "b1", Message: "Upstream fix", Author: "Bob", Timestamp: now}},
+		},
+		{
+			Repo:       config.Repo{Name: "offline", Path: "/src/offline"},
+			FetchError: fmt.Errorf("git fetch failed: exit status 128"),
+		},
+	}
+
+	output, err := NewFormatter().Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	local := strings.Index(output, "Local work")
+	incoming := strings.Index(output, "   Incoming from origin/main (not pulled yet):\n   • Upstream fix - Bob")
+	if local < 0 || incoming < local {
+		t.Errorf("Expected incoming commits listed after the local ones, got:\n%s", output)
+	}
+	if !strings.Contains(output, "⚠️  Fetch failed, incoming commits may be missing: git fetch failed") {
+		t.Errorf("Expected the fetch failure, got:\n%s", output)
+	}
+}
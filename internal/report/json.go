@@ -0,0 +1,313 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/plars/repomon/internal/git"
+)
+
+// Output formats
+const (
+	// FormatText renders the human-readable report
+	FormatText = "text"
+	// FormatJSON renders a machine-readable JSON document
+	FormatJSON = "json"
+	// FormatJSONLines renders one JSON object per repository and line, as results arrive,
+	// in any order
+	FormatJSONLines = "jsonl"
+)
+
+// ValidFormat reports whether format is a supported output format
+func ValidFormat(format string) bool {
+	return format == FormatText || format == FormatJSON || format == FormatJSONLines
+}
+
+// JSONFormatter formats repository results as a JSON document
+type JSONFormatter struct{}
+
+// NewJSONFormatter creates a new JSON formatter
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{}
+}
+
+type jsonReport struct {
+	Repos []jsonRepo `json:"repos"`
+}
+
+type jsonRepo struct {
+	Name   string `json:"name"`
+	Path   string `json:"path,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	jsonRepoError
+	FetchedAt  *time.Time `json:"fetched_at,omitempty"`
+	Truncated  bool       `json:"truncated"`
+	Attempts   int        `json:"attempts,omitempty"`
+	DurationMS int64      `json:"duration_ms"`
+	LastCommit *time.Time `json:"last_commit,omitempty"`
+	Violations []string   `json:"violations,omitempty"`
+	// Dormancy is only checked with dormant_after: idle_days is the age of the last commit
+	// in whole days, and dormancy_unknown is set for repositories that could not be checked
+	Dormant         bool         `json:"dormant,omitempty"`
+	IdleDays        *int         `json:"idle_days,omitempty"`
+	DormancyUnknown bool         `json:"dormancy_unknown,omitempty"`
+	Rewrite         *jsonRewrite `json:"rewrite,omitempty"`
+	Status          *jsonStatus  `json:"status,omitempty"`
+	FetchError      string       `json:"fetch_error,omitempty"`
+	Commits         []jsonCommit `json:"commits"`
+	// Incoming are only reported for fetched local repositories
+	IncomingFrom string       `json:"incoming_from,omitempty"`
+	Incoming     []jsonCommit `json:"incoming,omitempty"`
+}
+
+// jsonRepoError describes why a repository could not be read
+type jsonRepoError struct {
+	Error     string `json:"error,omitempty"`
+	ErrorKind string `json:"error_kind,omitempty"`
+	Hint      string `json:"hint,omitempty"`
+	NotCached bool   `json:"not_cached,omitempty"`
+}
+
+type jsonRewrite struct {
+	OldTip     string       `json:"old_tip"`
+	NewTip     string       `json:"new_tip"`
+	DetectedAt time.Time    `json:"detected_at"`
+	Dropped    []jsonCommit `json:"dropped"`
+}
+
+type jsonStatus struct {
+	Branch       string `json:"branch,omitempty"`
+	Upstream     string `json:"upstream,omitempty"`
+	UpstreamGone bool   `json:"upstream_gone,omitempty"`
+	Ahead        int    `json:"ahead"`
+	Behind       int    `json:"behind"`
+	Staged       int    `json:"staged"`
+	Modified     int    `json:"modified"`
+	Conflicts    int    `json:"conflicts"`
+	Untracked    int    `json:"untracked"`
+	Stashes      int    `json:"stashes"`
+	Clean        bool   `json:"clean"`
+}
+
+type jsonCommit struct {
+	Hash         string              `json:"hash"`
+	Message      string              `json:"message"`
+	Author       string              `json:"author"`
+	Timestamp    time.Time           `json:"timestamp"`
+	Body         string              `json:"body,omitempty"`
+	CoAuthors    []string            `json:"co_authors,omitempty"`
+	Trailers     map[string][]string `json:"trailers,omitempty"`
+	Conventional *jsonConventional   `json:"conventional,omitempty"`
+	Stat         *jsonStat           `json:"stat,omitempty"`
+	Signature    *jsonSignature      `json:"signature,omitempty"`
+}
+
+type jsonConventional struct {
+	Type        string `json:"type"`
+	Scope       string `json:"scope,omitempty"`
+	Description string `json:"description"`
+	Breaking    bool   `json:"breaking"`
+}
+
+type jsonStat struct {
+	FilesChanged int      `json:"files_changed"`
+	Insertions   int      `json:"insertions"`
+	Deletions    int      `json:"deletions"`
+	Files        []string `json:"files,omitempty"`
+}
+
+type jsonSignature struct {
+	Status string `json:"status"`
+	Type   string `json:"type,omitempty"`
+	Signer string `json:"signer,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Format formats the repository results as indented JSON
+func (f *JSONFormatter) Format(results []git.RepoResult) (string, error) {
+	report := jsonReport{Repos: make([]jsonRepo, 0, len(results))}
+	for _, result := range results {
+		report.Repos = append(report.Repos, newJSONRepo(result))
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// JSONLinesFormatter formats each repository result as a JSON object on a line of its own,
+// with the same fields as the repos of a JSON document and the index of the repository
+type JSONLinesFormatter struct{}
+
+// jsonLine is a repository result of a JSON lines report
+type jsonLine struct {
+	// Index is the position of the repository in the configuration, as lines are
+	// written in the order repositories finish
+	Index int `json:"index"`
+	jsonRepo
+}
+
+// NewJSONLinesFormatter creates a new JSON lines formatter
+func NewJSONLinesFormatter() *JSONLinesFormatter {
+	return &JSONLinesFormatter{}
+}
+
+// Format formats the repository results as JSON lines
+func (f *JSONLinesFormatter) Format(results []git.RepoResult) (string, error) {
+	var sb strings.Builder
+	for i, result := range results {
+		line, err := f.FormatIndexedResult(i, result)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(line)
+	}
+	return sb.String(), nil
+}
+
+// Header returns nothing: JSON lines have no header
+func (f *JSONLinesFormatter) Header() string {
+	return ""
+}
+
+// FormatResult formats a single repository result as a line of JSON, as the first
+// repository. Streamed reports use FormatIndexedResult.
+func (f *JSONLinesFormatter) FormatResult(result git.RepoResult) (string, error) {
+	return f.FormatIndexedResult(0, result)
+}
+
+// FormatIndexedResult formats the result of the repository at index as a line of JSON
+func (f *JSONLinesFormatter) FormatIndexedResult(index int, result git.RepoResult) (string, error) {
+	data, err := json.Marshal(jsonLine{Index: index, jsonRepo: newJSONRepo(result)})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON result: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// Footer returns nothing: JSON lines have no footer
+func (f *JSONLinesFormatter) Footer(results []git.RepoResult) string {
+	return ""
+}
+
+func newJSONRepo(result git.RepoResult) jsonRepo {
+	repo := jsonRepo{
+		Name:       result.Repo.Name,
+		Path:       result.Repo.Path,
+		URL:        result.Repo.URL,
+		Branch:     result.Repo.Branch,
+		Truncated:  result.Truncated,
+		Attempts:   result.Attempts,
+		DurationMS: result.Duration.Milliseconds(),
+		Violations: result.Violations,
+		Dormant:    result.Dormant,
+		Commits:    make([]jsonCommit, 0, len(result.Commits)),
+	}
+	repo.jsonRepoError = newJSONRepoError(result.Error)
+	if result.Idle > 0 {
+		days := int(result.Idle.Hours() / 24)
+		repo.IdleDays = &days
+	}
+	repo.DormancyUnknown = result.DormancyUnknown
+	if !result.FetchedAt.IsZero() {
+		fetchedAt := result.FetchedAt
+		repo.FetchedAt = &fetchedAt
+	}
+	if !result.LastCommit.IsZero() {
+		lastCommit := result.LastCommit
+		repo.LastCommit = &lastCommit
+	}
+	if status := result.Status; status != nil {
+		repo.Status = &jsonStatus{
+			Branch:       status.Branch,
+			Upstream:     status.Upstream,
+			UpstreamGone: status.UpstreamGone,
+			Ahead:        status.Ahead,
+			Behind:       status.Behind,
+			Staged:       status.Staged,
+			Modified:     status.Modified,
+			Conflicts:    status.Conflicts,
+			Untracked:    status.Untracked,
+			Stashes:      status.Stashes,
+			Clean:        status.Clean(),
+		}
+	}
+	if rewrite := result.Rewrite; rewrite != nil {
+		repo.Rewrite = &jsonRewrite{
+			OldTip:     rewrite.OldTip,
+			NewTip:     rewrite.NewTip,
+			DetectedAt: rewrite.DetectedAt,
+			Dropped:    make([]jsonCommit, 0, len(rewrite.Dropped)),
+		}
+		for _, commit := range rewrite.Dropped {
+			repo.Rewrite.Dropped = append(repo.Rewrite.Dropped, newJSONCommit(commit))
+		}
+	}
+	if result.FetchError != nil {
+		repo.FetchError = result.FetchError.Error()
+	}
+	for _, commit := range result.Commits {
+		repo.Commits = append(repo.Commits, newJSONCommit(commit))
+	}
+	repo.IncomingFrom = result.IncomingFrom
+	for _, commit := range result.Incoming {
+		repo.Incoming = append(repo.Incoming, newJSONCommit(commit))
+	}
+	return repo
+}
+
+func newJSONRepoError(err error) jsonRepoError {
+	if err == nil {
+		return jsonRepoError{}
+	}
+	kind := git.KindOf(err)
+	return jsonRepoError{
+		Error:     err.Error(),
+		ErrorKind: string(kind),
+		Hint:      kind.Hint(),
+		NotCached: errors.Is(err, git.ErrNotCached),
+	}
+}
+
+func newJSONCommit(commit git.Commit) jsonCommit {
+	c := jsonCommit{
+		Hash:      commit.Hash,
+		Message:   commit.Message,
+		Author:    commit.Author,
+		Timestamp: commit.Timestamp,
+		Body:      commit.Body,
+		CoAuthors: commit.CoAuthors,
+		Trailers:  commit.Trailers,
+	}
+	if cc := commit.Conventional; cc != nil {
+		c.Conventional = &jsonConventional{
+			Type:        cc.Type,
+			Scope:       cc.Scope,
+			Description: cc.Description,
+			Breaking:    cc.Breaking,
+		}
+	}
+	if stat := commit.Stat; stat != nil {
+		c.Stat = &jsonStat{
+			FilesChanged: stat.FilesChanged,
+			Insertions:   stat.Insertions,
+			Deletions:    stat.Deletions,
+			Files:        stat.Files,
+		}
+	}
+	if sig := commit.Signature; sig != nil {
+		c.Signature = &jsonSignature{
+			Status: string(sig.Status),
+			Type:   sig.Type,
+			Signer: sig.Signer,
+			Reason: sig.Reason,
+		}
+	}
+	return c
+}
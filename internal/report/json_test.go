@@ -0,0 +1,272 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/plars/repomon/internal/config"
+	"github.com/plars/repomon/internal/git"
+)
+
+func TestJSONFormatter_Format(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	results := []git.RepoResult{
+		{
+			Repo:         config.Repo{Name: "repo", URL: "https://github.com/example/repo", Branch: "main"},
+			Status:       &git.WorkTreeStatus{Branch: "main", Upstream: "origin/main", Ahead: 2, Untracked: 1},
+			IncomingFrom: "origin/main",
+			Incoming:     []git.Commit{{Hash: "def456", Message: "Upstream fix", Author: "Carol", Timestamp: ts}},
+			Commits: []git.Commit{
+				{
+					Hash:         "abc123",
+					Message:      "feat(api): add endpoint",
+					Author:       "Alice",
+					Timestamp:    ts,
+					CoAuthors:    []string{"Bob"},
+					Trailers:     git.Trailers{"Co-authored-by": {"Bob <bob@example.com>"}},
+					Conventional: &git.ConventionalCommit{Type: "feat", Scope: "api", Description: "add endpoint"},
+					Stat:         &git.DiffStat{FilesChanged: 2, Insertions: 10, Deletions: 3, Files: []string{"a.go", "b.go"}},
+					Signature:    &git.CommitSignature{Status: git.SignatureVerified, Type: git.SignatureTypeSSH, Signer: "alice@example.com"},
+				},
+			},
+		},
+		{
+			Repo:     config.Repo{Name: "broken", Path: "/path/to/broken"},
+			Error:    &git.RepoError{Kind: git.KindRepoNotFound, Err: fmt.Errorf("repository not found")},
+			Attempts: 3,
+			Duration: 1500 * time.Millisecond,
+		},
+		{
+			Repo:  config.Repo{Name: "uncached", URL: "https://github.com/example/uncached"},
+			Error: git.ErrNotCached,
+		},
+		{
+			Repo:       config.Repo{Name: "cached", URL: "https://github.com/example/cached"},
+			FetchedAt:  ts,
+			LastCommit: ts.AddDate(0, 0, -30),
+			Violations: []string{"expected a commit within 14 days, last was 30 days ago"},
+			Dormant:    true,
+			Rewrite: &git.Rewrite{
+				OldTip:     "old",
+				NewTip:     "new",
+				DetectedAt: ts,
+				Dropped:    []git.Commit{{Hash: "old", Message: "Dropped", Author: "Jane", Timestamp: ts}},
+			},
+		},
+	}
+
+	output, err := NewJSONFormatter().Format(results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var decoded struct {
+		Repos []struct {
+			Name       string     `json:"name"`
+			URL        string     `json:"url"`
+			Branch     string     `json:"branch"`
+			Error      string     `json:"error"`
+			ErrorKind  string     `json:"error_kind"`
+			Hint       string     `json:"hint"`
+			NotCached  bool       `json:"not_cached"`
+			FetchedAt  *time.Time `json:"fetched_at"`
+			Attempts   int        `json:"attempts"`
+			DurationMS int64      `json:"duration_ms"`
+			LastCommit *time.Time `json:"last_commit"`
+			Violations []string   `json:"violations"`
+			Dormant    bool       `json:"dormant"`
+			Rewrite    *struct {
+				OldTip     string    `json:"old_tip"`
+				NewTip     string    `json:"new_tip"`
+				DetectedAt time.Time `json:"detected_at"`
+				Dropped    []struct {
+					Hash    string `json:"hash"`
+					Message string `json:"message"`
+				} `json:"dropped"`
+			} `json:"rewrite"`
+			Status *struct {
+				Branch    string `json:"branch"`
+				Upstream  string `json:"upstream"`
+				Ahead     int    `json:"ahead"`
+				Untracked int    `json:"untracked"`
+				Clean     bool   `json:"clean"`
+			} `json:"status"`
+			IncomingFrom string `json:"incoming_from"`
+			Incoming     []struct {
+				Hash string `json:"hash"`
+			} `json:"incoming"`
+			Commits []struct {
+				Hash         string    `json:"hash"`
+				Timestamp    time.Time `json:"timestamp"`
+				CoAuthors    []string  `json:"co_authors"`
+				Conventional struct {
+					Type  string `json:"type"`
+					Scope string `json:"scope"`
+				} `json:"conventional"`
+				Stat struct {
+					FilesChanged int `json:"files_changed"`
+					Insertions   int `json:"insertions"`
+				} `json:"stat"`
+				Signature struct {
+					Status string `json:"status"`
+					Signer string `json:"signer"`
+				} `json:"signature"`
+			} `json:"commits"`
+		} `json:"repos"`
+	}
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+	}
+
+	if len(decoded.Repos) != 4 {
+		t.Fatalf("Expected 4 repos, got %d", len(decoded.Repos))
+	}
+	repo := decoded.Repos[0]
+	if repo.Name != "repo" || repo.Branch != "main" || repo.URL == "" {
+		t.Errorf("Unexpected repo fields: %+v", repo)
+	}
+	if len(repo.Commits) != 1 {
+		t.Fatalf("Expected 1 commit, got %d", len(repo.Commits))
+	}
+	c := repo.Commits[0]
+	if c.Hash != "abc123" || !c.Timestamp.Equal(ts) {
+		t.Errorf("Unexpected commit fields: %+v", c)
+	}
+	if c.Conventional.Type != "feat" || c.Conventional.Scope != "api" {
+		t.Errorf("Unexpected conventional fields: %+v", c.Conventional)
+	}
+	if c.Stat.FilesChanged != 2 || c.Stat.Insertions != 10 {
+		t.Errorf("Unexpected stat fields: %+v", c.Stat)
+	}
+	if c.Signature.Status != "verified" || c.Signature.Signer != "alice@example.com" {
+		t.Errorf("Unexpected signature fields: %+v", c.Signature)
+	}
+	if len(c.CoAuthors) != 1 || c.CoAuthors[0] != "Bob" {
+		t.Errorf("Unexpected co-authors: %v", c.CoAuthors)
+	}
+
+	if decoded.Repos[1].Error != "repository not found" {
+		t.Errorf("Expected error to be reported, got %q", decoded.Repos[1].Error)
+	}
+	if decoded.Repos[1].ErrorKind != "repo_not_found" || decoded.Repos[1].Hint == "" {
+		t.Errorf("Expected the error kind and a hint, got %+v", decoded.Repos[1])
+	}
+	if decoded.Repos[2].ErrorKind != "not_cached" || repo.ErrorKind != "" {
+		t.Errorf("Expected a kind for every error and none without one, got %+v", decoded.Repos)
+	}
+	if cached := decoded.Repos[3]; cached.LastCommit == nil || !cached.LastCommit.Equal(ts.AddDate(0, 0, -30)) || len(cached.Violations) != 1 || !cached.Dormant {
+		t.Errorf("Expected the last commit, unmet expectations and dormancy, got %+v", cached)
+	}
+	if rewrite := decoded.Repos[3].Rewrite; rewrite == nil || rewrite.OldTip != "old" || rewrite.NewTip != "new" ||
+		!rewrite.DetectedAt.Equal(ts) || len(rewrite.Dropped) != 1 || rewrite.Dropped[0].Message != "Dropped" {
+		t.Errorf("Expected the rewrite with its dropped commits, got %+v", rewrite)
+	}
+	if repo.LastCommit != nil || repo.Violations != nil || repo.Dormant || repo.Rewrite != nil {
+		t.Errorf("Expected no last commit or violations when unset, got %+v", repo)
+	}
+	if decoded.Repos[1].Attempts != 3 || decoded.Repos[1].DurationMS != 1500 {
+		t.Errorf("Expected attempts and duration to be reported, got %+v", decoded.Repos[1])
+	}
+	if st := repo.Status; st == nil || st.Branch != "main" || st.Upstream != "origin/main" || st.Ahead != 2 || st.Untracked != 1 || st.Clean {
+		t.Errorf("Unexpected status fields: %+v", st)
+	}
+	if repo.IncomingFrom != "origin/main" || len(repo.Incoming) != 1 || repo.Incoming[0].Hash != "def456" {
+		t.Errorf("Unexpected incoming fields: %q %+v", repo.IncomingFrom, repo.Incoming)
+	}
+	if decoded.Repos[1].Incoming != nil {
+		t.Error("Expected no incoming commits when not fetched")
+	}
+	if decoded.Repos[1].Status != nil {
+		t.Error("Expected no status when unset")
+	}
+	if decoded.Repos[1].Commits == nil {
+		t.Error("Expected empty commits array rather than null")
+	}
+	if decoded.Repos[1].NotCached || !decoded.Repos[2].NotCached {
+		t.Errorf("Expected only the uncached repo to be marked not cached, got %+v", decoded.Repos)
+	}
+	if repo.FetchedAt != nil || decoded.Repos[3].FetchedAt == nil || !decoded.Repos[3].FetchedAt.Equal(ts) {
+		t.Errorf("Expected only the cached repo to report when it was fetched, got %+v", decoded.Repos)
+	}
+}
+
+func TestValidFormat(t *testing.T) {
+	for _, format := range []string{FormatText, FormatJSON} {
+		if !ValidFormat(format) {
+			t.Errorf("Expected %q to be a valid format", format)
+		}
+	}
+	if ValidFormat("yaml") {
+		t.Error("Expected 'yaml' to be an invalid format")
+	}
+}
+
+func TestJSONFormatter_Dormancy(t *testing.T) {
+	results := []git.RepoResult{
+		{Repo: config.Repo{Name: "active"}, LastCommit: time.Now(), Idle: 3 * 24 * time.Hour},
+		{Repo: config.Repo{Name: "abandoned"}, LastCommit: time.Now().AddDate(-1, 0, 0), Dormant: true, Idle: 400 * 24 * time.Hour},
+		{Repo: config.Repo{Name: "broken"}, Error: errors.New("boom"), DormancyUnknown: true},
+		{Repo: config.Repo{Name: "unchecked"}},
+	}
+	type dormancy struct {
+		Name            string `json:"name"`
+		Dormant         bool   `json:"dormant"`
+		IdleDays        *int   `json:"idle_days"`
+		DormancyUnknown bool   `json:"dormancy_unknown"`
+	}
+	days := func(n int) *int { return &n }
+	want := []dormancy{
+		{Name: "active", IdleDays: days(3)},
+		{Name: "abandoned", Dormant: true, IdleDays: days(400)},
+		{Name: "broken", DormancyUnknown: true},
+		{Name: "unchecked"},
+	}
+
+	check := func(t *testing.T, got []dormancy) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("Expected %d repositories, got %d", len(want), len(got))
+		}
+		for i := range want {
+			g, w := got[i], want[i]
+			if g.Name != w.Name || g.Dormant != w.Dormant || g.DormancyUnknown != w.DormancyUnknown ||
+				(g.IdleDays == nil) != (w.IdleDays == nil) || (g.IdleDays != nil && *g.IdleDays != *w.IdleDays) {
+				t.Errorf("Expected %+v, got %+v", w, g)
+			}
+		}
+	}
+
+	t.Run("json", func(t *testing.T) {
+		output, err := NewJSONFormatter().Format(results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded struct {
+			Repos []dormancy `json:"repos"`
+		}
+		if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		check(t, decoded.Repos)
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		output, err := NewJSONLinesFormatter().Format(results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []dormancy
+		for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+			var repo dormancy
+			if err := json.Unmarshal([]byte(line), &repo); err != nil {
+				t.Fatalf("Invalid JSON line %q: %v", line, err)
+			}
+			got = append(got, repo)
+		}
+		check(t, got)
+	})
+}
@@ -0,0 +1,109 @@
+package report
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/plars/repomon/internal/git"
+)
+
+// StreamFormatter formats a report piece by piece, so that it can be written while
+// repositories are still being collected
+type StreamFormatter interface {
+	// Header returns what is written before any result
+	Header() string
+	// FormatResult formats the result of a single repository
+	FormatResult(result git.RepoResult) (string, error)
+	// Footer returns what is written after every result
+	Footer(results []git.RepoResult) string
+}
+
+// UnorderedFormatter is implemented by stream formatters whose results stand on their
+// own. Each is written as soon as it arrives, along with the index of its repository
+// so that readers can restore the order.
+type UnorderedFormatter interface {
+	// FormatIndexedResult formats the result of the repository at index
+	FormatIndexedResult(index int, result git.RepoResult) (string, error)
+}
+
+// StreamWriter writes a report as results arrive in any order. Each result is written
+// as soon as those of every repository before it are, so the report comes out in
+// repository order, the same as when formatted at once. Results of an
+// UnorderedFormatter are written as soon as they arrive instead.
+type StreamWriter struct {
+	w         io.Writer
+	formatter StreamFormatter
+	results   []git.RepoResult
+	done      []bool
+	next      int
+}
+
+// NewStreamWriter creates a StreamWriter for a report on count repositories
+func NewStreamWriter(w io.Writer, formatter StreamFormatter, count int) *StreamWriter {
+	return &StreamWriter{
+		w:         w,
+		formatter: formatter,
+		results:   make([]git.RepoResult, count),
+		done:      make([]bool, count),
+	}
+}
+
+// Begin writes the report header
+func (s *StreamWriter) Begin() error {
+	return s.write(s.formatter.Header())
+}
+
+// Add records the result of the repository at index, and writes it along with any
+// results it was holding back
+func (s *StreamWriter) Add(index int, result git.RepoResult) error {
+	if index < 0 || index >= len(s.results) {
+		return fmt.Errorf("result index %d out of range", index)
+	}
+	s.results[index] = result
+	s.done[index] = true
+
+	if unordered, ok := s.formatter.(UnorderedFormatter); ok {
+		section, err := unordered.FormatIndexedResult(index, result)
+		if err != nil {
+			return fmt.Errorf("failed to format report: %w", err)
+		}
+		return s.write(section)
+	}
+
+	for s.next < len(s.results) && s.done[s.next] {
+		section, err := s.formatter.FormatResult(s.results[s.next])
+		if err != nil {
+			return fmt.Errorf("failed to format report: %w", err)
+		}
+		if err := s.write(section); err != nil {
+			return err
+		}
+		s.next++
+	}
+	return nil
+}
+
+// End writes the report footer. It fails if a result is missing.
+func (s *StreamWriter) End() error {
+	for index, done := range s.done {
+		if !done {
+			return fmt.Errorf("report incomplete: missing the result of repository %d", index)
+		}
+	}
+	return s.write(s.formatter.Footer(s.results))
+}
+
+// Results returns the results added so far, in repository order
+func (s *StreamWriter) Results() []git.RepoResult {
+	return s.results
+}
+
+func (s *StreamWriter) write(text string) error {
+	if text == "" {
+		return nil
+	}
+	if _, err := io.WriteString(s.w, text); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
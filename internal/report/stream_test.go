@@ -0,0 +1,145 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/plars/repomon/internal/config"
+	"github.com/plars/repomon/internal/git"
+)
+
+func streamResults() []git.RepoResult {
+	now := time.Now()
+	return []git.RepoResult{
+		{Repo: config.Repo{Name: "first"}, Commits: []git.Commit{{Hash: "a1", Message: "Add feature", Author: "Alice", Timestamp: now}}},
+		{Repo: config.Repo{Name: "second"}},
+		{Repo: config.Repo{Name: "third"}, Commits: []git.Commit{{Hash: "c1", Message: "Fix bug", Author: "Bob", Timestamp: now}}},
+	}
+}
+
+func TestStreamWriter(t *testing.T) {
+	results := streamResults()
+	formatter := NewFormatter()
+	want, err := formatter.Format(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	writer := NewStreamWriter(&out, formatter, len(results))
+	if err := writer.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	header := out.Len()
+
+	// The last repository finishing first is held back until the others are done
+	if err := writer.Add(2, results[2]); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != header {
+		t.Errorf("Expected the third result to wait for the others, got:\n%s", out.String())
+	}
+	if err := writer.Add(0, results[0]); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "first") || strings.Contains(out.String(), "third") {
+		t.Errorf("Expected only the first result to be written, got:\n%s", out.String())
+	}
+
+	if err := writer.End(); err == nil {
+		t.Error("Expected an error ending a report with a missing result")
+	}
+	if err := writer.Add(1, results[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.End(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != want {
+		t.Errorf("Expected the streamed report to match the batch one.\nGot:\n%s\nWant:\n%s", out.String(), want)
+	}
+	if len(writer.Results()) != 3 || writer.Results()[2].Repo.Name != "third" {
+		t.Errorf("Expected the results in repository order, got %+v", writer.Results())
+	}
+
+	if err := writer.Add(3, results[0]); err == nil {
+		t.Error("Expected an error for an out of range index")
+	}
+}
+
+func TestStreamWriter_Unordered(t *testing.T) {
+	results := streamResults()
+	var out bytes.Buffer
+	writer := NewStreamWriter(&out, NewJSONLinesFormatter(), len(results))
+	if err := writer.Begin(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A slow first repository does not hold back the others
+	if err := writer.Add(2, results[2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Add(1, results[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.End(); err == nil {
+		t.Error("Expected an error ending a report with a missing result")
+	}
+	if err := writer.Add(0, results[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.End(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected one line per repository, got %d:\n%s", len(lines), out.String())
+	}
+	for i, want := range []struct {
+		index int
+		name  string
+	}{{2, "third"}, {1, "second"}, {0, "first"}} {
+		var repo struct {
+			Index int    `json:"index"`
+			Name  string `json:"name"`
+		}
+		if err := json.Unmarshal([]byte(lines[i]), &repo); err != nil {
+			t.Fatalf("Line %d is not valid JSON: %v", i, err)
+		}
+		if repo.Index != want.index || repo.Name != want.name {
+			t.Errorf("Expected line %d to be repository %d (%s), got %s", i, want.index, want.name, lines[i])
+		}
+	}
+	if writer.Results()[0].Repo.Name != "first" {
+		t.Errorf("Expected the results in repository order, got %+v", writer.Results())
+	}
+}
+
+func TestJSONLinesFormatter(t *testing.T) {
+	output, err := NewJSONLinesFormatter().Format(streamResults())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected one line per repository, got %d:\n%s", len(lines), output)
+	}
+	for i, name := range []string{"first", "second", "third"} {
+		var repo struct {
+			Index   int               `json:"index"`
+			Name    string            `json:"name"`
+			Commits []json.RawMessage `json:"commits"`
+		}
+		if err := json.Unmarshal([]byte(lines[i]), &repo); err != nil {
+			t.Fatalf("Line %d is not valid JSON: %v", i, err)
+		}
+		if repo.Index != i || repo.Name != name || repo.Commits == nil {
+			t.Errorf("Unexpected line %d: %s", i, lines[i])
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package timespec parses the time expressions accepted by --since and --until.
+package timespec
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// dayUnits are the units ParseDuration accepts in addition to those of time.ParseDuration
+var dayUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": day,
+	"w": 7 * day,
+}
+
+// unitWords maps spelled-out units ("3 days", "2 weeks") to their duration
+var unitWords = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    day,
+	"week":   7 * day,
+}
+
+var (
+	compactDuration = regexp.MustCompile(`^(?:\d+[smhdw])+$`)
+	compactPart     = regexp.MustCompile(`(\d+)([smhdw])`)
+	wordDuration    = regexp.MustCompile(`^(\d+)\s*(second|minute|hour|day|week)s?$`)
+)
+
+// dateTimeLayouts are the absolute formats accepted, tried in order.
+// All but RFC3339 are interpreted in the local time zone.
+var dateTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+}
+
+// dateLayout is a plain calendar date, which covers a whole day
+const dateLayout = "2006-01-02"
+
+// ParseDuration parses a duration such as "36h", "2w", "1w3d" or "3 days".
+// Besides the units of time.ParseDuration it accepts "d" (days) and "w" (weeks).
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if d, err := time.ParseDuration(s); err == nil {
+		if d < 0 {
+			return 0, fmt.Errorf("invalid duration %q: must not be negative", s)
+		}
+		return d, nil
+	}
+
+	if compactDuration.MatchString(s) {
+		var total time.Duration
+		for _, part := range compactPart.FindAllStringSubmatch(s, -1) {
+			n, err := strconv.Atoi(part[1])
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+			}
+			total += time.Duration(n) * dayUnits[part[2]]
+		}
+		return total, nil
+	}
+
+	if m := wordDuration.FindStringSubmatch(s); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		return time.Duration(n) * unitWords[m[2]], nil
+	}
+
+	return 0, fmt.Errorf("invalid duration %q", s)
+}
+
+// Parse parses a point in time relative to now. It accepts:
+//
+//   - RFC3339 timestamps ("2024-10-01T09:00:00Z")
+//   - local dates and times ("2024-10-01", "2024-10-01 09:00")
+//   - durations before now ("36h", "2w", "3 days ago")
+//   - "now", "today", "yesterday", and weekdays ("monday", "last monday")
+//
+// Dates and day names refer to the start of that day.
+func Parse(s string, now time.Time) (time.Time, error) {
+	t, _, err := parse(s, now)
+	return t, err
+}
+
+// ParseEnd is like Parse, but dates and day names refer to the end of that day,
+// so "--until 2024-10-14" includes commits made on October 14.
+func ParseEnd(s string, now time.Time) (time.Time, error) {
+	t, wholeDay, err := parse(s, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if wholeDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// parse parses s and reports whether it named a whole day rather than an instant
+func parse(s string, now time.Time) (time.Time, bool, error) {
+	expr := strings.ToLower(strings.TrimSpace(s))
+	today := startOfDay(now)
+
+	switch expr {
+	case "":
+		return time.Time{}, false, fmt.Errorf("empty time expression")
+	case "now":
+		return now, false, nil
+	case "today":
+		return today, true, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true, nil
+	}
+
+	if weekday, ok := parseWeekday(strings.TrimPrefix(expr, "last ")); ok {
+		// The most recent such day before today, so "last monday" on a Monday is a week ago
+		back := (int(today.Weekday())-int(weekday)+6)%7 + 1
+		return today.AddDate(0, 0, -back), true, nil
+	}
+
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(s), now.Location()); err == nil {
+			return t, false, nil
+		}
+	}
+	if t, err := time.ParseInLocation(dateLayout, expr, now.Location()); err == nil {
+		return t, true, nil
+	}
+
+	if d, err := ParseDuration(strings.TrimSuffix(expr, " ago")); err == nil {
+		return now.Add(-d), false, nil
+	}
+
+	return time.Time{}, false, fmt.Errorf("invalid time %q: expected a date (2006-01-02), an RFC3339 timestamp, a duration (36h, 2w) or a day (yesterday, last monday)", s)
+}
+
+// parseWeekday parses a full or three-letter weekday name
+func parseWeekday(s string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// startOfDay returns midnight at the start of t's day, in t's location
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,118 @@
+package timespec
+
+import (
+	"testing"
+	"time"
+)
+
+// now is Wednesday, 16 October 2024, 15:30 UTC
+var now = time.Date(2024, time.October, 16, 15, 30, 0, 0, time.UTC)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "36h", want: 36 * time.Hour},
+		{input: "90m", want: 90 * time.Minute},
+		{input: "1h30m", want: 90 * time.Minute},
+		{input: "2d", want: 48 * time.Hour},
+		{input: "2w", want: 14 * 24 * time.Hour},
+		{input: "1w3d", want: 10 * 24 * time.Hour},
+		{input: "3 days", want: 72 * time.Hour},
+		{input: "1 week", want: 7 * 24 * time.Hour},
+		{input: " 12H ", want: 12 * time.Hour},
+		{input: "-3h", wantErr: true},
+		{input: "2y", wantErr: true},
+		{input: "soon", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %v", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: "now", want: now},
+		{input: "today", want: time.Date(2024, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{input: "yesterday", want: time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{input: "last monday", want: time.Date(2024, 10, 14, 0, 0, 0, 0, time.UTC)},
+		{input: "tuesday", want: time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{input: "last wednesday", want: time.Date(2024, 10, 9, 0, 0, 0, 0, time.UTC)},
+		{input: "Thu", want: time.Date(2024, 10, 10, 0, 0, 0, 0, time.UTC)},
+		{input: "2024-10-01", want: time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{input: "2024-10-01 09:15", want: time.Date(2024, 10, 1, 9, 15, 0, 0, time.UTC)},
+		{input: "2024-10-01T09:15:30", want: time.Date(2024, 10, 1, 9, 15, 30, 0, time.UTC)},
+		{input: "2024-10-01T09:15:00+02:00", want: time.Date(2024, 10, 1, 7, 15, 0, 0, time.UTC)},
+		{input: "36h", want: now.Add(-36 * time.Hour)},
+		{input: "2w", want: now.Add(-14 * 24 * time.Hour)},
+		{input: "3 days ago", want: now.Add(-72 * time.Hour)},
+		{input: "", wantErr: true},
+		{input: "last blursday", wantErr: true},
+		{input: "2024-13-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %v", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEnd(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{input: "2024-10-14", want: time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{input: "yesterday", want: time.Date(2024, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{input: "last monday", want: time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{input: "2024-10-14 12:00", want: time.Date(2024, 10, 14, 12, 0, 0, 0, time.UTC)},
+		{input: "6h", want: now.Add(-6 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseEnd(tt.input, now)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseEnd(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}